@@ -0,0 +1,148 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"slices"
+	"sort"
+
+	pb "lab2/broker/proto"
+)
+
+// anilloConsistente reparte las ofertas entre los nodos: cada nodo ocupa varias
+// posiciones virtuales del anillo y una oferta se guarda en los N primeros
+// nodos distintos que aparecen avanzando desde la posición de su ID. Al entrar
+// o salir un nodo solo cambian de dueño las ofertas vecinas a sus posiciones.
+// Como el índice de suscripciones, se modifica con registroMu tomado.
+type anilloConsistente struct {
+	porNodo   int
+	virtuales []nodoVirtual
+}
+
+type nodoVirtual struct {
+	posicion uint64
+	nodo     string
+}
+
+// posicionEnAnillo debe coincidir con la que usan los nodos para filtrar sus
+// árboles de Merkle por rango.
+func posicionEnAnillo(clave string) uint64 {
+	suma := sha256.Sum256([]byte(clave))
+	return binary.BigEndian.Uint64(suma[:8])
+}
+
+func nuevoAnilloConsistente(porNodo int) *anilloConsistente {
+	return &anilloConsistente{porNodo: porNodo}
+}
+
+func (a *anilloConsistente) agregar(nodo string) {
+	if a.contiene(nodo) {
+		return
+	}
+	for i := 0; i < a.porNodo; i++ {
+		a.virtuales = append(a.virtuales, nodoVirtual{
+			posicion: posicionEnAnillo(fmt.Sprintf("%s#%d", nodo, i)),
+			nodo:     nodo,
+		})
+	}
+	sort.Slice(a.virtuales, func(i, j int) bool {
+		return a.virtuales[i].posicion < a.virtuales[j].posicion
+	})
+}
+
+func (a *anilloConsistente) quitar(nodo string) {
+	a.virtuales = slices.DeleteFunc(a.virtuales, func(v nodoVirtual) bool {
+		return v.nodo == nodo
+	})
+}
+
+func (a *anilloConsistente) contiene(nodo string) bool {
+	return slices.ContainsFunc(a.virtuales, func(v nodoVirtual) bool {
+		return v.nodo == nodo
+	})
+}
+
+// replicas retorna los nodos que guardan la clave, el primero es su dueño.
+func (a *anilloConsistente) replicas(clave string, n int) []string {
+	if len(a.virtuales) == 0 {
+		return nil
+	}
+	posicion := posicionEnAnillo(clave)
+	i := sort.Search(len(a.virtuales), func(i int) bool {
+		return a.virtuales[i].posicion >= posicion
+	})
+	return a.replicasDesde(i%len(a.virtuales), n)
+}
+
+func (a *anilloConsistente) replicasDesde(inicio, n int) []string {
+	var nodos []string
+	for k := 0; k < len(a.virtuales) && len(nodos) < n; k++ {
+		nodo := a.virtuales[(inicio+k)%len(a.virtuales)].nodo
+		if !slices.Contains(nodos, nodo) {
+			nodos = append(nodos, nodo)
+		}
+	}
+	return nodos
+}
+
+// recorrer visita cada tramo (inicio, fin] del anillo con sus réplicas. El
+// primer tramo da la vuelta, así que su inicio es mayor que su fin.
+func (a *anilloConsistente) recorrer(n int, visitar func(inicio, fin uint64, replicas []string)) {
+	for i, virtual := range a.virtuales {
+		anterior := a.virtuales[(i+len(a.virtuales)-1)%len(a.virtuales)]
+		visitar(anterior.posicion, virtual.posicion, a.replicasDesde(i, n))
+	}
+}
+
+// rangosCompartidos retorna los tramos que x e y replican juntos, uniendo los
+// contiguos. todo indica que comparten el anillo completo y no hace falta
+// filtrar.
+func (a *anilloConsistente) rangosCompartidos(x, y string, n int) (rangos []*pb.RangoAnillo, todo bool) {
+	todo = len(a.virtuales) > 0
+	a.recorrer(n, func(inicio, fin uint64, replicas []string) {
+		if !slices.Contains(replicas, x) || !slices.Contains(replicas, y) {
+			todo = false
+			return
+		}
+		if ultimo := len(rangos) - 1; ultimo >= 0 && rangos[ultimo].GetFin() == inicio {
+			rangos[ultimo].Fin = fin
+			return
+		}
+		rangos = append(rangos, &pb.RangoAnillo{Inicio: inicio, Fin: fin})
+	})
+	if todo {
+		return nil, true
+	}
+	return rangos, false
+}
+
+// tramosSinQuorum cuenta los tramos en que respondieron menos de r réplicas.
+func (a *anilloConsistente) tramosSinQuorum(n, r int, respondieron map[string]bool) int {
+	sinQuorum := 0
+	a.recorrer(n, func(inicio, fin uint64, replicas []string) {
+		cantidad := 0
+		for _, nodo := range replicas {
+			if respondieron[nodo] {
+				cantidad++
+			}
+		}
+		if cantidad < r {
+			sinQuorum++
+		}
+	})
+	return sinQuorum
+}
+
+// proporciones retorna qué fracción del anillo tiene cada nodo como dueño.
+func (a *anilloConsistente) proporciones() map[string]float64 {
+	proporciones := make(map[string]float64)
+	a.recorrer(1, func(inicio, fin uint64, replicas []string) {
+		// La resta sin signo también mide bien el tramo que da la vuelta
+		proporciones[replicas[0]] += float64(fin-inicio) / (1 << 64)
+	})
+	if len(a.virtuales) == 1 {
+		proporciones[a.virtuales[0].nodo] = 1
+	}
+	return proporciones
+}
@@ -0,0 +1,152 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	pb "lab2/broker/proto"
+)
+
+// iniciarAntiEntropia compara periódicamente cada par de réplicas usando sus
+// árboles de Merkle. Solo se piden las ofertas de los buckets cuyo hash difiere,
+// así el costo depende de cuánto divergen las réplicas y no del total de ofertas.
+// Cada par compara solo los tramos del anillo que ambos replican.
+func (b *Broker) iniciarAntiEntropia(intervalo time.Duration) {
+	go func() {
+		ticker := time.NewTicker(intervalo)
+		defer ticker.Stop()
+
+		for range ticker.C {
+			if !b.sistemaActivo.Load() {
+				return
+			}
+			b.rondaAntiEntropia()
+		}
+	}()
+}
+
+func (b *Broker) rondaAntiEntropia() {
+	var nodos []*NodoInfo
+	for _, nodo := range b.listarNodos() {
+		if activo, _ := nodo.obtenerEstado(); activo {
+			nodos = append(nodos, nodo)
+		}
+	}
+
+	for i := 0; i < len(nodos); i++ {
+		for j := i + 1; j < len(nodos); j++ {
+			rangos, todo := b.rangosCompartidos(nodos[i], nodos[j])
+			if !todo && len(rangos) == 0 {
+				continue
+			}
+			if err := b.sincronizarPar(nodos[i], nodos[j], rangos); err != nil {
+				log.Printf("Anti-entropía %s-%s interrumpida: %v", nodos[i].nombre, nodos[j].nombre, err)
+			}
+		}
+	}
+	b.rondasAntiEntropia.Add(1)
+}
+
+func (b *Broker) rangosCompartidos(x, y *NodoInfo) ([]*pb.RangoAnillo, bool) {
+	b.registroMu.RLock()
+	defer b.registroMu.RUnlock()
+
+	return b.anillo.rangosCompartidos(x.nombre, y.nombre, b.quorum.N)
+}
+
+// sincronizarPar deja a ambos nodos con la versión más nueva de cada oferta de
+// los buckets en que difieren. Sin rangos se compara todo lo que guardan.
+func (b *Broker) sincronizarPar(x, y *NodoInfo, rangos []*pb.RangoAnillo) error {
+	buckets, err := compararArboles(x, y, rangos)
+	if err != nil {
+		return err
+	}
+	if len(buckets) == 0 {
+		return nil
+	}
+	b.bucketsDivergentes.Add(int64(len(buckets)))
+
+	var lecturas []lecturaNodo
+	for _, nodo := range []*NodoInfo{x, y} {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		resp, err := nodo.client.LeerBuckets(ctx, &pb.LecturaBucketsRequest{Buckets: buckets, Rangos: rangos})
+		cancel()
+		if err != nil {
+			return err
+		}
+		if !resp.GetExito() {
+			return fmt.Errorf("%s no entregó sus buckets", nodo.nombre)
+		}
+		lecturas = append(lecturas, lecturaNodo{nodo: nodo, ofertas: resp.GetOfertas()})
+	}
+
+	historial := b.fusionarLecturas(lecturas)
+	transferidas := 0
+	for _, lectura := range lecturas {
+		for _, oferta := range ofertasPendientes(historial, lectura.ofertas) {
+			if !b.enviarOfertaANodo(lectura.nodo, oferta) {
+				return fmt.Errorf("%s rechazó la oferta %s", lectura.nodo.nombre, oferta.GetOfertaId())
+			}
+			transferidas++
+			b.ofertasAntiEntropia.Add(1)
+		}
+	}
+
+	log.Printf("Anti-entropía %s-%s: %d buckets distintos, %d ofertas transferidas",
+		x.nombre, y.nombre, len(buckets), transferidas)
+	return nil
+}
+
+// compararArboles recorre ambos árboles desde la raíz y solo baja por los
+// subárboles cuyo hash difiere.
+func compararArboles(x, y *NodoInfo, rangos []*pb.RangoAnillo) ([]int32, error) {
+	var buckets []int32
+	posiciones := []int32{1}
+
+	for len(posiciones) > 0 {
+		hashesX, err := pedirHashes(x, posiciones, rangos)
+		if err != nil {
+			return nil, err
+		}
+		hashesY, err := pedirHashes(y, posiciones, rangos)
+		if err != nil {
+			return nil, err
+		}
+		if hashesX.GetHojas() != hashesY.GetHojas() {
+			return nil, fmt.Errorf("árboles incompatibles (%d vs %d hojas)", hashesX.GetHojas(), hashesY.GetHojas())
+		}
+
+		hojas := hashesX.GetHojas()
+		var siguientes []int32
+		for i, posicion := range posiciones {
+			if bytes.Equal(hashesX.GetHashes()[i], hashesY.GetHashes()[i]) {
+				continue
+			}
+			if posicion >= hojas {
+				buckets = append(buckets, posicion-hojas)
+			} else {
+				siguientes = append(siguientes, 2*posicion, 2*posicion+1)
+			}
+		}
+		posiciones = siguientes
+	}
+
+	return buckets, nil
+}
+
+func pedirHashes(nodo *NodoInfo, posiciones []int32, rangos []*pb.RangoAnillo) (*pb.HashesMerkleResponse, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	resp, err := nodo.client.ObtenerHashesMerkle(ctx, &pb.HashesMerkleRequest{Posiciones: posiciones, Rangos: rangos})
+	if err != nil {
+		return nil, err
+	}
+	if !resp.GetExito() || len(resp.GetHashes()) != len(posiciones) {
+		return nil, fmt.Errorf("%s no entregó sus hashes", nodo.nombre)
+	}
+	return resp, nil
+}
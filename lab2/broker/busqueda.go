@@ -0,0 +1,199 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"log"
+	"slices"
+	"time"
+
+	"google.golang.org/protobuf/proto"
+
+	"lab2/broker/filtros"
+	pb "lab2/broker/proto"
+)
+
+const (
+	limiteBusquedaPorDefecto = 100
+	limiteBusquedaMaximo     = 1000
+	// Con un orden distinto de POR_ID se leen a lo más 20 páginas de 500 ofertas
+	// por nodo
+	tamanoPaginaBusqueda  = 500
+	paginasBusquedaMaximo = 20
+)
+
+// BuscarOfertas responde qué ofertas hay en el CyberDay sin necesidad de estar
+// registrado. Lee con quorum R como cualquier lectura del historial, así que
+// sin quorum la búsqueda falla en vez de entregar un resultado incompleto. No
+// repara réplicas: una búsqueda anónima no debe provocar escrituras en los
+// nodos, de eso se encargan las lecturas internas y la anti-entropía.
+//
+// Los nodos entregan sus páginas en orden de ID, así que con orden POR_ID se
+// deja de leer apenas hay limite coincidencias seguras. Los demás órdenes
+// necesitan recorrer todo; si no alcanzan paginasBusquedaMaximo por nodo, el
+// resultado se marca como parcial.
+func (b *Broker) BuscarOfertas(ctx context.Context, req *pb.BusquedaRequest) (*pb.BusquedaResponse, error) {
+	filtro, err := filtros.Compilar(req.GetFiltro())
+	if err != nil {
+		log.Printf("Búsqueda rechazada - filtro inválido: %v", err)
+		return &pb.BusquedaResponse{Exito: false}, nil
+	}
+
+	limite := int(req.GetLimite())
+	if limite <= 0 {
+		limite = limiteBusquedaPorDefecto
+	}
+	limite = min(limite, limiteBusquedaMaximo)
+
+	lectura := filtro.Lectura()
+	porID := req.GetOrden() == pb.OrdenBusqueda_POR_ID
+	lectura.TamanoPagina = tamanoPaginaBusqueda
+	if porID {
+		lectura.TamanoPagina = int32(limite)
+	}
+
+	var nodos []*busquedaNodo
+	for _, nodo := range b.listarNodos() {
+		if activo, _ := nodo.obtenerEstado(); activo {
+			nodos = append(nodos, &busquedaNodo{nodo: nodo})
+		}
+	}
+
+	ahora := time.Now().Unix()
+	var ofertas []*pb.OfertaRequest
+	var corte string
+	for pagina := 0; pagina < paginasBusquedaMaximo; pagina++ {
+		// Después de la primera página, con orden POR_ID solo hace falta leer
+		// más de los nodos que fijan el corte
+		for _, n := range nodos {
+			if !n.agotado() && (!porID || n.paginas == 0 || n.token == corte) {
+				b.leerPaginaBusqueda(n, lectura)
+			}
+		}
+		nodos = slices.DeleteFunc(nodos, func(n *busquedaNodo) bool { return n.err != nil })
+		if !b.quorumBusqueda(nodos) {
+			log.Printf("Búsqueda fallida - No se alcanzó quorum R=%d", b.quorum.R)
+			return &pb.BusquedaResponse{Exito: false}, nil
+		}
+
+		corte = corteBusqueda(nodos)
+		ofertas = b.coincidenciasBusqueda(nodos, corte, filtro, req.GetIncluirNoVigentes(), ahora)
+		if corte == "" || (porID && len(ofertas) >= limite) {
+			break
+		}
+	}
+	ordenarBusqueda(ofertas, req.GetOrden())
+
+	total := len(ofertas)
+	ofertas = ofertas[:min(limite, total)]
+	log.Printf("Búsqueda (%s, %s): %d ofertas coinciden, se entregan %d (parcial: %v)",
+		filtro, req.GetOrden(), total, len(ofertas), corte != "")
+	return &pb.BusquedaResponse{
+		Ofertas: ofertas,
+		Exito:   true,
+		Total:   int32(total),
+		Parcial: corte != "",
+	}, nil
+}
+
+// busquedaNodo son las páginas que se llevan leídas de un nodo en una
+// búsqueda. El token de la última página es el ID hasta el que el nodo ya
+// entregó todo lo que tiene.
+type busquedaNodo struct {
+	nodo    *NodoInfo
+	ofertas []*pb.OfertaRequest
+	token   string
+	paginas int
+	err     error
+}
+
+func (n *busquedaNodo) agotado() bool {
+	return n.paginas > 0 && n.token == ""
+}
+
+func (b *Broker) leerPaginaBusqueda(n *busquedaNodo, lectura *pb.LecturaRequest) {
+	pagina := proto.Clone(lectura).(*pb.LecturaRequest)
+	pagina.Token = n.token
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	resp, err := n.nodo.client.LeerOfertas(ctx, pagina)
+	if err == nil && !resp.GetExito() {
+		err = fmt.Errorf("%s rechazó la lectura", n.nodo.nombre)
+	}
+	if err != nil {
+		n.nodo.registrarFallo()
+		metricaErroresNodo.WithLabelValues(n.nodo.nombre, "lectura").Inc()
+		log.Printf("Error leyendo %s: %v", n.nodo.nombre, err)
+		n.err = err
+		return
+	}
+	n.ofertas = append(n.ofertas, resp.GetOfertas()...)
+	n.token = resp.GetSiguienteToken()
+	n.paginas++
+}
+
+func lecturasBusqueda(nodos []*busquedaNodo) []lecturaNodo {
+	lecturas := make([]lecturaNodo, len(nodos))
+	for i, n := range nodos {
+		lecturas[i] = lecturaNodo{nodo: n.nodo, ofertas: n.ofertas}
+	}
+	return lecturas
+}
+
+// quorumBusqueda exige, como leerConQuorum, R nodos en cada tramo del anillo.
+func (b *Broker) quorumBusqueda(nodos []*busquedaNodo) bool {
+	return len(nodos) >= b.quorum.R && b.tramosSinQuorum(lecturasBusqueda(nodos)) == 0
+}
+
+// corteBusqueda es el mayor ID hasta el que todos los nodos entregaron todo, o
+// "" si todos terminaron. Solo las ofertas hasta el corte se conocen con todas
+// sus réplicas.
+func corteBusqueda(nodos []*busquedaNodo) string {
+	var corte string
+	for _, n := range nodos {
+		if !n.agotado() && (corte == "" || n.token < corte) {
+			corte = n.token
+		}
+	}
+	return corte
+}
+
+// coincidenciasBusqueda fusiona lo leído y retorna las ofertas hasta el corte
+// que coinciden con el filtro.
+func (b *Broker) coincidenciasBusqueda(nodos []*busquedaNodo, corte string, filtro *filtros.Filtro, incluirNoVigentes bool, ahora int64) []*pb.OfertaRequest {
+	var ofertas []*pb.OfertaRequest
+	for _, oferta := range b.fusionarLecturas(lecturasBusqueda(nodos)) {
+		if corte != "" && oferta.GetOfertaId() > corte {
+			continue
+		}
+		// Una oferta vencida puede seguir vigente hasta la próxima ronda de expiración
+		vigente := oferta.GetEstado() == pb.EstadoOferta_VIGENTE &&
+			(oferta.GetExpira() == 0 || oferta.GetExpira() > ahora)
+		if !vigente && !incluirNoVigentes {
+			continue
+		}
+		if filtro.Coincide(oferta) {
+			ofertas = append(ofertas, oferta)
+		}
+	}
+	return ofertas
+}
+
+// ordenarBusqueda deja las ofertas en el orden pedido. Los empates se
+// resuelven por ID para que el resultado no cambie entre búsquedas iguales.
+func ordenarBusqueda(ofertas []*pb.OfertaRequest, orden pb.OrdenBusqueda) {
+	slices.SortFunc(ofertas, func(a, b *pb.OfertaRequest) int {
+		var c int
+		switch orden {
+		case pb.OrdenBusqueda_PRECIO_ASC:
+			c = cmp.Compare(a.GetPrecio(), b.GetPrecio())
+		case pb.OrdenBusqueda_PRECIO_DESC:
+			c = cmp.Compare(b.GetPrecio(), a.GetPrecio())
+		case pb.OrdenBusqueda_DESCUENTO_DESC:
+			c = cmp.Compare(b.GetDescuento(), a.GetDescuento())
+		}
+		return cmp.Or(c, cmp.Compare(a.GetOfertaId(), b.GetOfertaId()))
+	})
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	pb "lab2/broker/proto"
+)
+
+// Con orden POR_ID la búsqueda deja de leer apenas tiene el límite; con otro
+// orden recorre todo, salvo que supere el máximo de páginas por nodo.
+func TestBuscarOfertasAcotaLaLectura(t *testing.T) {
+	b, nodos := brokerPrueba(t, 0, 0, 0)
+	expira := time.Now().Add(time.Hour).Unix()
+	for i := range 10500 {
+		oferta := &pb.OfertaRequest{OfertaId: fmt.Sprintf("Riploy-%05d", i), Tienda: "Riploy", Categoria: "Moda",
+			Precio: int32(1000 + i%500), Stock: 1, Expira: expira, Version: 1, Secuencia: int64(i + 1)}
+		// Una de cada tres está retirada y solo el broker la descarta
+		if i%3 == 0 {
+			oferta.Estado = pb.EstadoOferta_RETIRADA
+		}
+		for _, nodo := range nodos {
+			nodo.ofertas[oferta.GetOfertaId()] = oferta
+		}
+	}
+
+	buscar := func(nombre string, req *pb.BusquedaRequest, primera string, total int32, parcial bool, paginas int) {
+		t.Helper()
+		for _, nodo := range nodos {
+			nodo.mu.Lock()
+			nodo.paginas = 0
+			nodo.mu.Unlock()
+		}
+		resp, err := b.BuscarOfertas(context.Background(), req)
+		if err != nil || !resp.GetExito() || len(resp.GetOfertas()) == 0 {
+			t.Fatalf("%s: búsqueda fallida: %v", nombre, err)
+		}
+		if resp.GetOfertas()[0].GetOfertaId() != primera || resp.GetTotal() != total || resp.GetParcial() != parcial {
+			t.Fatalf("%s: primera %s, total %d, parcial %v", nombre, resp.GetOfertas()[0].GetOfertaId(), resp.GetTotal(), resp.GetParcial())
+		}
+		leidas := 0
+		for _, nodo := range nodos {
+			nodo.mu.Lock()
+			leidas += nodo.paginas
+			nodo.mu.Unlock()
+		}
+		if leidas != paginas {
+			t.Fatalf("%s: se leyeron %d páginas, se esperaban %d", nombre, leidas, paginas)
+		}
+	}
+
+	// Dos páginas de 10 por nodo bastan para 10 vigentes
+	buscar("por ID", &pb.BusquedaRequest{Limite: 10}, "Riploy-00001", 13, true, 6)
+	// Las coincidencias escasean y se llega al máximo de páginas
+	buscar("por ID con filtro", &pb.BusquedaRequest{Limite: 5, Filtro: &pb.FiltroOferta{PrecioMax: 1001}},
+		"Riploy-00001", 1, true, 3*paginasBusquedaMaximo)
+	buscar("por precio", &pb.BusquedaRequest{Limite: 10, Orden: pb.OrdenBusqueda_PRECIO_ASC},
+		"Riploy-00500", 6666, true, 3*paginasBusquedaMaximo)
+
+	for _, nodo := range nodos {
+		for i := 6000; i < 10500; i++ {
+			delete(nodo.ofertas, fmt.Sprintf("Riploy-%05d", i))
+		}
+	}
+	buscar("por precio completa", &pb.BusquedaRequest{Limite: 10, Orden: pb.OrdenBusqueda_PRECIO_ASC},
+		"Riploy-00500", 4000, false, 3*12)
+}
@@ -0,0 +1,334 @@
+package main
+
+import (
+	"container/heap"
+	"context"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"log"
+	"math"
+	"sync"
+	"time"
+
+	"google.golang.org/protobuf/proto"
+
+	pb "lab2/broker/proto"
+)
+
+const (
+	intervaloExpiracion = 5 * time.Second
+
+	// Una expiración que falla se reintenta con backoff hasta este máximo de
+	// veces. Si se abandona, vuelve a agendarse con la próxima versión de la
+	// oferta
+	esperaMaximaExpiracion = 5 * time.Minute
+	maxIntentosExpiracion  = 10
+)
+
+var errOfertaNoEncontrada = errors.New("oferta no encontrada")
+
+// bloqueosOferta serializa los cambios de una misma oferta, que leen la
+// versión actual y escriben la siguiente. Ofertas distintas comparten un lock
+// solo si caen en la misma franja.
+type bloqueosOferta struct {
+	franjas [64]sync.Mutex
+}
+
+func (l *bloqueosOferta) de(ofertaID string) *sync.Mutex {
+	h := fnv.New32a()
+	h.Write([]byte(ofertaID))
+	return &l.franjas[h.Sum32()%uint32(len(l.franjas))]
+}
+
+func esLapida(oferta *pb.OfertaRequest) bool {
+	switch oferta.GetEstado() {
+	case pb.EstadoOferta_RETIRADA, pb.EstadoOferta_EXPIRADA:
+		return true
+	}
+	return false
+}
+
+func (b *Broker) ActualizarStock(ctx context.Context, req *pb.ActualizacionStockRequest) (*pb.CambioOfertaResponse, error) {
+	quien := req.GetTienda()
+	if quien == "" {
+		quien = req.GetConsumidorId()
+	}
+	if err := b.validarCambioStock(req); err != nil {
+		log.Printf("Stock de %s no actualizado (%+d, %s): %v", req.GetOfertaId(), req.GetDelta(), quien, err)
+		return &pb.CambioOfertaResponse{Exito: false, Motivo: err.Error()}, nil
+	}
+
+	oferta, err := b.cambiarOferta(req.GetOfertaId(), func(oferta *pb.OfertaRequest) error {
+		if req.GetTienda() != "" && oferta.GetTienda() != req.GetTienda() {
+			return fmt.Errorf("la oferta es de %s", oferta.GetTienda())
+		}
+		// En int64 para que un delta enorme no dé la vuelta
+		stock := int64(oferta.GetStock()) + int64(req.GetDelta())
+		if stock < 0 {
+			return fmt.Errorf("stock insuficiente (quedan %d)", oferta.GetStock())
+		}
+		if stock > math.MaxInt32 {
+			return fmt.Errorf("stock sobre el máximo")
+		}
+		oferta.Stock = int32(stock)
+		if stock == 0 {
+			oferta.Estado = pb.EstadoOferta_AGOTADA
+		} else {
+			oferta.Estado = pb.EstadoOferta_VIGENTE
+		}
+		return nil
+	})
+	if err != nil {
+		log.Printf("Stock de %s no actualizado (%+d, %s): %v", req.GetOfertaId(), req.GetDelta(), quien, err)
+		return &pb.CambioOfertaResponse{Exito: false, Motivo: err.Error()}, nil
+	}
+
+	b.stockActualizado.Add(1)
+	if oferta.GetEstado() == pb.EstadoOferta_AGOTADA {
+		b.ofertasAgotadas.Add(1)
+	}
+	log.Printf("Stock de %s actualizado (%+d, %s): quedan %d", oferta.GetOfertaId(), req.GetDelta(), quien, oferta.GetStock())
+	return &pb.CambioOfertaResponse{Exito: true, Oferta: oferta}, nil
+}
+
+// validarCambioStock revisa lo que no depende de la oferta: una tienda debe
+// estar registrada y un consumidor, además de estar registrado, solo puede
+// comprar. Que la tienda sea la dueña se revisa con la oferta leída.
+func (b *Broker) validarCambioStock(req *pb.ActualizacionStockRequest) error {
+	if req.GetDelta() == 0 {
+		return fmt.Errorf("delta debe ser distinto de 0")
+	}
+
+	b.registroMu.RLock()
+	defer b.registroMu.RUnlock()
+
+	if tienda := req.GetTienda(); tienda != "" {
+		if _, existe := b.productores[tienda]; !existe {
+			return fmt.Errorf("tienda %s no registrada", tienda)
+		}
+		return nil
+	}
+	if _, existe := b.consumidores[req.GetConsumidorId()]; !existe {
+		return fmt.Errorf("solo la tienda de la oferta o un consumidor registrado pueden cambiar el stock")
+	}
+	if req.GetDelta() > 0 {
+		return fmt.Errorf("solo la tienda de la oferta puede reponer stock")
+	}
+	return nil
+}
+
+func (b *Broker) RetirarOferta(ctx context.Context, req *pb.RetiroOfertaRequest) (*pb.CambioOfertaResponse, error) {
+	if err := b.validarRetiro(req); err != nil {
+		log.Printf("%s no pudo retirar la oferta %s: %v", req.GetTienda(), req.GetOfertaId(), err)
+		return &pb.CambioOfertaResponse{Exito: false, Motivo: err.Error()}, nil
+	}
+
+	oferta, err := b.cambiarOferta(req.GetOfertaId(), func(oferta *pb.OfertaRequest) error {
+		if oferta.GetTienda() != req.GetTienda() {
+			return fmt.Errorf("la oferta es de %s", oferta.GetTienda())
+		}
+		oferta.Estado = pb.EstadoOferta_RETIRADA
+		return nil
+	})
+	if err != nil {
+		log.Printf("%s no pudo retirar la oferta %s: %v", req.GetTienda(), req.GetOfertaId(), err)
+		return &pb.CambioOfertaResponse{Exito: false, Motivo: err.Error()}, nil
+	}
+
+	b.ofertasRetiradas.Add(1)
+	log.Printf("%s retiró la oferta %s", req.GetTienda(), oferta.GetOfertaId())
+	return &pb.CambioOfertaResponse{Exito: true, Oferta: oferta}, nil
+}
+
+// validarRetiro exige, como validarCambioStock, que quien retira sea una tienda
+// registrada. Que sea la dueña se revisa con la oferta leída.
+func (b *Broker) validarRetiro(req *pb.RetiroOfertaRequest) error {
+	b.registroMu.RLock()
+	defer b.registroMu.RUnlock()
+
+	if _, existe := b.productores[req.GetTienda()]; !existe {
+		return fmt.Errorf("tienda %s no registrada", req.GetTienda())
+	}
+	return nil
+}
+
+// cambiarOferta lee la oferta con quorum R, le aplica el cambio como una
+// versión nueva y la escribe con quorum W en sus réplicas. Los consumidores
+// reciben la versión nueva igual que una oferta nueva.
+func (b *Broker) cambiarOferta(ofertaID string, cambiar func(*pb.OfertaRequest) error) (*pb.OfertaRequest, error) {
+	bloqueo := b.bloqueos.de(ofertaID)
+	bloqueo.Lock()
+	defer bloqueo.Unlock()
+
+	actual, err := b.leerOferta(ofertaID)
+	if err != nil {
+		return nil, err
+	}
+	if esLapida(actual) {
+		return nil, fmt.Errorf("la oferta está %s", actual.GetEstado())
+	}
+
+	oferta := proto.Clone(actual).(*pb.OfertaRequest)
+	if err := cambiar(oferta); err != nil {
+		return nil, err
+	}
+	oferta.Version = b.reloj.recibir(actual.GetVersion())
+	oferta.Secuencia = b.secuencias.recibir(0)
+
+	if !b.almacenarOfertaEnNodos(oferta) {
+		b.escriturasFallidas.Add(1)
+		return nil, fmt.Errorf("no se alcanzó quorum W=%d", b.quorum.W)
+	}
+	b.escriturasExitosas.Add(1)
+	if err := b.publicarOferta(oferta, true); err != nil {
+		return nil, err
+	}
+	return oferta, nil
+}
+
+// leerOferta lee la oferta solo en sus réplicas del anillo y se queda con la
+// versión más nueva entre las R o más que respondan.
+func (b *Broker) leerOferta(ofertaID string) (*pb.OfertaRequest, error) {
+	var lecturas []lecturaNodo
+	for _, nodo := range b.nodosParaOferta(ofertaID) {
+		if activo, _ := nodo.obtenerEstado(); !activo {
+			continue
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+		resp, err := nodo.client.LeerOferta(ctx, &pb.LecturaOfertaRequest{OfertaId: ofertaID})
+		cancel()
+		if err != nil || !resp.GetExito() {
+			nodo.registrarFallo()
+			metricaErroresNodo.WithLabelValues(nodo.nombre, "lectura").Inc()
+			continue
+		}
+		lecturas = append(lecturas, lecturaNodo{nodo: nodo, ofertas: resp.GetOfertas()})
+	}
+
+	if len(lecturas) < b.quorum.R {
+		return nil, fmt.Errorf("no se alcanzó quorum R=%d", b.quorum.R)
+	}
+	historial := b.fusionarLecturas(lecturas)
+	if len(historial) == 0 {
+		return nil, errOfertaNoEncontrada
+	}
+	go b.repararLecturas(lecturas, historial)
+	return historial[0], nil
+}
+
+// expiracion es una versión publicada de una oferta que vence en el instante
+// indicado, o que entonces se vuelve a intentar expirar.
+type expiracion struct {
+	oferta   *pb.OfertaRequest
+	vence    int64
+	intentos int
+}
+
+// colaExpiracion es un heap de mínimos por vencimiento: cada ronda solo mira
+// las ofertas que ya vencieron, sin recorrer todo el log.
+type colaExpiracion []*expiracion
+
+func (c colaExpiracion) Len() int           { return len(c) }
+func (c colaExpiracion) Less(i, j int) bool { return c[i].vence < c[j].vence }
+func (c colaExpiracion) Swap(i, j int)      { c[i], c[j] = c[j], c[i] }
+
+func (c *colaExpiracion) Push(x any) { *c = append(*c, x.(*expiracion)) }
+
+func (c *colaExpiracion) Pop() any {
+	anterior := *c
+	e := anterior[len(anterior)-1]
+	anterior[len(anterior)-1] = nil
+	*c = anterior[:len(anterior)-1]
+	return e
+}
+
+// agendarLocked anota la última versión de la oferta y, si tiene plazo y sigue
+// abierta, la agenda para expirar. Las versiones anteriores agendadas quedan
+// obsoletas y se descartan al salir de la cola.
+func (l *logOfertas) agendarLocked(oferta *pb.OfertaRequest) {
+	if version, existe := l.versiones[oferta.GetOfertaId()]; existe && version > oferta.GetVersion() {
+		return
+	}
+	l.versiones[oferta.GetOfertaId()] = oferta.GetVersion()
+	if !esLapida(oferta) && oferta.GetExpira() != 0 {
+		heap.Push(&l.expiraciones, &expiracion{oferta: oferta, vence: oferta.GetExpira()})
+	}
+}
+
+// vencidas saca de la cola las ofertas vencidas hasta ahora que siguen siendo
+// la última versión publicada.
+func (l *logOfertas) vencidas(ahora int64) []*expiracion {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	var vencidas []*expiracion
+	for len(l.expiraciones) > 0 && l.expiraciones[0].vence <= ahora {
+		e := heap.Pop(&l.expiraciones).(*expiracion)
+		if e.oferta.GetVersion() >= l.versiones[e.oferta.GetOfertaId()] {
+			vencidas = append(vencidas, e)
+		}
+	}
+	return vencidas
+}
+
+// reintentarExpiracion vuelve a agendar una expiración fallida con backoff
+// exponencial. Retorna false si ya se agotaron los intentos.
+func (l *logOfertas) reintentarExpiracion(e *expiracion, ahora int64) bool {
+	e.intentos++
+	if e.intentos >= maxIntentosExpiracion {
+		return false
+	}
+	espera := min(intervaloExpiracion<<(e.intentos-1), esperaMaximaExpiracion)
+	e.vence = ahora + int64(espera/time.Second)
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	heap.Push(&l.expiraciones, e)
+	return true
+}
+
+// iniciarExpiracion marca como expiradas las ofertas cuyo plazo venció. Las
+// candidatas salen de la cola de expiración del log de ofertas, y cada una se
+// confirma con una lectura de quorum.
+func (b *Broker) iniciarExpiracion() {
+	go func() {
+		ticker := time.NewTicker(intervaloExpiracion)
+		defer ticker.Stop()
+
+		for range ticker.C {
+			if !b.sistemaActivo.Load() {
+				return
+			}
+			if b.raft != nil && !b.raft.atiendeClientes() {
+				continue
+			}
+			b.expirarOfertas()
+		}
+	}()
+}
+
+func (b *Broker) expirarOfertas() {
+	ahora := time.Now().Unix()
+	for _, e := range b.logOfertas.vencidas(ahora) {
+		oferta := e.oferta
+		_, err := b.cambiarOferta(oferta.GetOfertaId(), func(oferta *pb.OfertaRequest) error {
+			oferta.Estado = pb.EstadoOferta_EXPIRADA
+			return nil
+		})
+		if errors.Is(err, errOfertaNoEncontrada) {
+			// Nunca alcanzó a guardarse en los nodos
+			continue
+		}
+		if err != nil {
+			if b.logOfertas.reintentarExpiracion(e, ahora) {
+				log.Printf("No se pudo expirar la oferta %s (intento %d): %v", oferta.GetOfertaId(), e.intentos, err)
+			} else {
+				log.Printf("No se pudo expirar la oferta %s tras %d intentos: %v - se abandona", oferta.GetOfertaId(), e.intentos, err)
+			}
+			continue
+		}
+		b.ofertasExpiradas.Add(1)
+		log.Printf("Oferta %s expirada", oferta.GetOfertaId())
+	}
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"context"
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	pb "lab2/broker/proto"
+)
+
+// Solo la tienda de la oferta cambia su stock libremente; un consumidor
+// registrado solo compra, y ningún cambio deja el stock negativo.
+func TestActualizarStockExigeDuenoYStockNoNegativo(t *testing.T) {
+	b, _ := brokerPrueba(t, 0, 0, 0)
+	ctx := context.Background()
+	for _, tienda := range []string{"Riploy", "Parisio"} {
+		b.RegistrarProductor(ctx, &pb.RegistroProductorRequest{Nombre: tienda})
+	}
+	resp, err := b.RegistrarConsumidor(ctx, &pb.RegistroConsumidorRequest{ConsumidorId: "C1"})
+	if err != nil || !resp.GetExito() {
+		t.Fatalf("registro de C1: %v", err)
+	}
+	oferta := &pb.OfertaRequest{OfertaId: "Riploy-1", Tienda: "Riploy", Categoria: "Moda", Precio: 1000, Stock: 2,
+		Expira: time.Now().Add(time.Hour).Unix()}
+	if resp, err := b.EnviarOferta(ctx, oferta); err != nil || !resp.GetExito() {
+		t.Fatalf("oferta no aceptada: %v", err)
+	}
+
+	casos := []struct {
+		nombre string
+		req    *pb.ActualizacionStockRequest
+		motivo string
+		stock  int32
+	}{
+		{"otra tienda", &pb.ActualizacionStockRequest{Tienda: "Parisio", Delta: 5}, "la oferta es de Riploy", 2},
+		{"tienda no registrada", &pb.ActualizacionStockRequest{Tienda: "Falabellox", Delta: -1}, "no registrada", 2},
+		{"sin quien", &pb.ActualizacionStockRequest{Delta: -1}, "consumidor registrado", 2},
+		{"consumidor no registrado", &pb.ActualizacionStockRequest{ConsumidorId: "C9", Delta: -1}, "consumidor registrado", 2},
+		{"consumidor repone", &pb.ActualizacionStockRequest{ConsumidorId: "C1", Delta: 3}, "reponer", 2},
+		{"consumidor compra", &pb.ActualizacionStockRequest{ConsumidorId: "C1", Delta: -1}, "", 1},
+		{"compra sin stock", &pb.ActualizacionStockRequest{ConsumidorId: "C1", Delta: -2}, "stock insuficiente", 1},
+		{"tienda deja negativo", &pb.ActualizacionStockRequest{Tienda: "Riploy", Delta: -2}, "stock insuficiente", 1},
+		{"tienda desborda", &pb.ActualizacionStockRequest{Tienda: "Riploy", Delta: math.MaxInt32}, "máximo", 1},
+		{"tienda repone", &pb.ActualizacionStockRequest{Tienda: "Riploy", Delta: 4}, "", 5},
+	}
+	for _, c := range casos {
+		c.req.OfertaId = "Riploy-1"
+		resp, err := b.ActualizarStock(ctx, c.req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.GetExito() != (c.motivo == "") || !strings.Contains(resp.GetMotivo(), c.motivo) {
+			t.Fatalf("%s: exito = %v, motivo %q", c.nombre, resp.GetExito(), resp.GetMotivo())
+		}
+		actual, err := b.leerOferta("Riploy-1")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if actual.GetStock() != c.stock {
+			t.Fatalf("%s: stock = %d, se esperaba %d", c.nombre, actual.GetStock(), c.stock)
+		}
+	}
+}
+
+// Solo la tienda dueña y registrada retira una oferta.
+func TestRetirarOfertaExigeTiendaRegistrada(t *testing.T) {
+	b, _ := brokerPrueba(t, 0, 0, 0)
+	ctx := context.Background()
+	for _, tienda := range []string{"Riploy", "Parisio"} {
+		b.RegistrarProductor(ctx, &pb.RegistroProductorRequest{Nombre: tienda})
+	}
+	oferta := &pb.OfertaRequest{OfertaId: "Riploy-1", Tienda: "Riploy", Categoria: "Moda", Precio: 1000, Stock: 2,
+		Expira: time.Now().Add(time.Hour).Unix()}
+	if resp, err := b.EnviarOferta(ctx, oferta); err != nil || !resp.GetExito() {
+		t.Fatalf("oferta no aceptada: %v", err)
+	}
+	b.AbandonarCluster(ctx, &pb.SalidaRequest{EntidadId: "Riploy", Tipo: "productor"})
+
+	casos := []struct {
+		tienda string
+		motivo string
+	}{
+		{"Falabellox", "no registrada"},
+		{"", "no registrada"},
+		{"Riploy", "no registrada"},
+		{"Parisio", "la oferta es de Riploy"},
+	}
+	for _, c := range casos {
+		resp, err := b.RetirarOferta(ctx, &pb.RetiroOfertaRequest{OfertaId: "Riploy-1", Tienda: c.tienda})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.GetExito() || !strings.Contains(resp.GetMotivo(), c.motivo) {
+			t.Fatalf("tienda %q: exito = %v, motivo %q", c.tienda, resp.GetExito(), resp.GetMotivo())
+		}
+	}
+
+	b.RegistrarProductor(ctx, &pb.RegistroProductorRequest{Nombre: "Riploy"})
+	resp, err := b.RetirarOferta(ctx, &pb.RetiroOfertaRequest{OfertaId: "Riploy-1", Tienda: "Riploy"})
+	if err != nil || !resp.GetExito() {
+		t.Fatalf("la tienda dueña no pudo retirar: %v %q", err, resp.GetMotivo())
+	}
+}
@@ -0,0 +1,291 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"net"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+
+	pb "lab2/broker/proto"
+)
+
+// nodoPrueba es un nodo DB en memoria. demora simula un nodo lento.
+type nodoPrueba struct {
+	pb.UnimplementedCyberDayServiceServer
+	demora time.Duration
+
+	mu                sync.Mutex
+	ofertas           map[string]*pb.OfertaRequest
+	lecturasCompletas int // lecturas sin DesdeSecuencia
+	paginas           int // páginas de esas lecturas
+}
+
+func (n *nodoPrueba) EnviarOferta(ctx context.Context, req *pb.OfertaRequest) (*pb.OfertaResponse, error) {
+	time.Sleep(n.demora)
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if actual, existe := n.ofertas[req.GetOfertaId()]; !existe || actual.GetVersion() < req.GetVersion() {
+		n.ofertas[req.GetOfertaId()] = req
+	}
+	return &pb.OfertaResponse{Exito: true}, nil
+}
+
+// LeerOfertas pagina en orden de ID como un nodo real. Sin tamaño de página
+// entrega todo de una vez.
+func (n *nodoPrueba) LeerOfertas(ctx context.Context, req *pb.LecturaRequest) (*pb.LecturaResponse, error) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if req.GetDesdeSecuencia() == 0 {
+		n.paginas++
+		if req.GetToken() == "" {
+			n.lecturasCompletas++
+		}
+	}
+
+	ids := slices.Sorted(maps.Keys(n.ofertas))
+	var ofertas []*pb.OfertaRequest
+	var ultima int64
+	var siguiente string
+	for i, id := range ids {
+		oferta := n.ofertas[id]
+		ultima = max(ultima, oferta.GetSecuencia())
+		if id <= req.GetToken() || siguiente != "" || oferta.GetSecuencia() <= req.GetDesdeSecuencia() {
+			continue
+		}
+		ofertas = append(ofertas, oferta)
+		if len(ofertas) == int(req.GetTamanoPagina()) && i+1 < len(ids) {
+			siguiente = id
+		}
+	}
+	return &pb.LecturaResponse{Ofertas: ofertas, Exito: true, SiguienteToken: siguiente, UltimaSecuencia: ultima}, nil
+}
+
+func (n *nodoPrueba) LeerOferta(ctx context.Context, req *pb.LecturaOfertaRequest) (*pb.LecturaResponse, error) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	var ofertas []*pb.OfertaRequest
+	if oferta, existe := n.ofertas[req.GetOfertaId()]; existe {
+		ofertas = append(ofertas, oferta)
+	}
+	return &pb.LecturaResponse{Ofertas: ofertas, Exito: true}, nil
+}
+
+func (n *nodoPrueba) cantidad() int {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return len(n.ofertas)
+}
+
+func servirPrueba(t testing.TB, servidor pb.CyberDayServiceServer) string {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := grpc.NewServer()
+	pb.RegisterCyberDayServiceServer(s, servidor)
+	go s.Serve(listener)
+	t.Cleanup(s.Stop)
+	return listener.Addr().String()
+}
+
+// brokerPrueba levanta un broker sin réplicas con un nodo en memoria por cada
+// demora indicada.
+func brokerPrueba(t testing.TB, demoras ...time.Duration) (*Broker, []*nodoPrueba) {
+	t.Helper()
+
+	config := configuracionPorDefecto()
+	config.DirDatos = t.TempDir()
+	config.Membresia.MinNodos = 2
+	b, err := NewBroker(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var nodos []*nodoPrueba
+	for i, demora := range demoras {
+		nodo := &nodoPrueba{demora: demora, ofertas: make(map[string]*pb.OfertaRequest)}
+		resp, err := b.RegistrarNodo(context.Background(), &pb.RegistroNodoRequest{
+			Nombre:    fmt.Sprintf("DB%d", i+1),
+			Direccion: servirPrueba(t, nodo),
+		})
+		if err != nil || !resp.GetExito() {
+			t.Fatalf("registro de DB%d: %v", i+1, err)
+		}
+		nodos = append(nodos, nodo)
+	}
+	return b, nodos
+}
+
+func esperarCondicion(t *testing.T, descripcion string, cumple func() bool) {
+	t.Helper()
+	limite := time.Now().Add(5 * time.Second)
+	for !cumple() {
+		if time.Now().After(limite) {
+			t.Fatalf("no se cumplió a tiempo: %s", descripcion)
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
+
+// Productores, sincronizaciones y consultas de estado al mismo tiempo. Con
+// -race detecta accesos sin sincronizar al registro, los contadores y el log.
+func TestConcurrenciaProductoresSincronizacionesYEstado(t *testing.T) {
+	b, nodos := brokerPrueba(t, 0, 5*time.Millisecond, 20*time.Millisecond)
+	ctx := context.Background()
+
+	tiendas := []string{"Riploy", "Falabellox", "Parisio"}
+	for _, tienda := range tiendas {
+		b.RegistrarProductor(ctx, &pb.RegistroProductorRequest{Nombre: tienda})
+	}
+	suscripcion, cancelar := context.WithCancel(ctx)
+	defer cancelar()
+	cliente := pb.NewCyberDayServiceClient(conectarPrueba(t, servirPrueba(t, b)))
+	stream, err := cliente.Suscribir(suscripcion, &pb.SuscripcionRequest{ConsumidorId: "C1", Categorias: []string{"Moda"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var recibidas atomic.Int64
+	go func() {
+		for {
+			notificacion, err := stream.Recv()
+			if err != nil {
+				return
+			}
+			if !notificacion.GetLatido() {
+				recibidas.Add(1)
+			}
+		}
+	}()
+
+	const porTienda = 30
+	var wg sync.WaitGroup
+	for _, tienda := range tiendas {
+		wg.Add(1)
+		go func(tienda string) {
+			defer wg.Done()
+			for i := 0; i < porTienda; i++ {
+				resp, err := b.EnviarOferta(ctx, &pb.OfertaRequest{
+					OfertaId:  fmt.Sprintf("%s-%d", tienda, i),
+					Tienda:    tienda,
+					Categoria: "Moda",
+					Producto:  "Polera",
+					Precio:    1000,
+					Stock:     5,
+				})
+				if err != nil || !resp.GetExito() {
+					t.Errorf("oferta %s-%d no aceptada: %v", tienda, i, err)
+				}
+			}
+		}(tienda)
+	}
+
+	var consultas atomic.Bool
+	consultas.Store(true)
+	var wgConsultas sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wgConsultas.Add(1)
+		go func(i int) {
+			defer wgConsultas.Done()
+			for consultas.Load() {
+				b.ConsultarEstado(ctx, &pb.ConsultarEstadoRequest{})
+				b.SolicitarInicio(ctx, &pb.InicioRequest{})
+				b.SincronizarEntidad(ctx, &pb.SincronizacionRequest{EntidadId: fmt.Sprintf("DB%d", i%3+1), Tipo: "nodo"})
+				b.SincronizarEntidad(ctx, &pb.SincronizacionRequest{EntidadId: "C1", Tipo: "consumidor"})
+				b.RegistrarProductor(ctx, &pb.RegistroProductorRequest{Nombre: fmt.Sprintf("Tienda%d", i)})
+			}
+		}(i)
+	}
+
+	wg.Wait()
+	consultas.Store(false)
+	wgConsultas.Wait()
+
+	total := int64(len(tiendas) * porTienda)
+	if got := b.escriturasExitosas.Load(); got != total {
+		t.Fatalf("escrituras exitosas = %d, se esperaban %d", got, total)
+	}
+	// Una oferta aceptada ya está en el log cuando el productor recibe la
+	// respuesta
+	if _, ultimo := b.logOfertas.leerDesde(0); ultimo != total {
+		t.Fatalf("ofertas en el log = %d, se esperaban %d", ultimo, total)
+	}
+	esperarCondicion(t, "el suscriptor recibe todas las ofertas", func() bool { return recibidas.Load() == total })
+	// Con N=3 y tres nodos cada uno es réplica de todo; los lentos terminan
+	// después de que se alcanza W
+	for i, nodo := range nodos {
+		esperarCondicion(t, fmt.Sprintf("DB%d con todas las ofertas", i+1), func() bool { return int64(nodo.cantidad()) == total })
+	}
+}
+
+// Los reintentos simultáneos de una misma oferta se escriben una sola vez.
+func TestConcurrenciaReintentosDeUnaOferta(t *testing.T) {
+	b, _ := brokerPrueba(t, 10*time.Millisecond, 10*time.Millisecond, 10*time.Millisecond)
+	ctx := context.Background()
+	b.RegistrarProductor(ctx, &pb.RegistroProductorRequest{Nombre: "Riploy"})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			b.EnviarOferta(ctx, &pb.OfertaRequest{OfertaId: "Riploy-1", Tienda: "Riploy", Categoria: "Moda", Precio: 1000})
+		}()
+	}
+	wg.Wait()
+
+	if got := b.escriturasExitosas.Load(); got != 1 {
+		t.Fatalf("la oferta se escribió %d veces", got)
+	}
+	resp, err := b.EnviarOferta(ctx, &pb.OfertaRequest{OfertaId: "Riploy-1", Tienda: "Riploy", Categoria: "Moda", Precio: 1000})
+	if err != nil || !resp.GetExito() {
+		t.Fatalf("el reintento de una oferta confirmada debe responder éxito: %v", err)
+	}
+	if _, ultimo := b.logOfertas.leerDesde(0); ultimo != 1 {
+		t.Fatalf("la oferta se publicó %d veces", ultimo)
+	}
+}
+
+// Una escritura que espera a nodos lentos no bloquea las consultas de estado
+// ni los registros: ninguna llamada de red se hace con el estado compartido
+// tomado.
+func TestConcurrenciaNodosLentosNoBloqueanElEstado(t *testing.T) {
+	const demora = time.Second
+	b, _ := brokerPrueba(t, demora, demora, demora)
+	ctx := context.Background()
+	b.RegistrarProductor(ctx, &pb.RegistroProductorRequest{Nombre: "Riploy"})
+
+	escribiendo := make(chan struct{})
+	go func() {
+		defer close(escribiendo)
+		b.EnviarOferta(ctx, &pb.OfertaRequest{OfertaId: "Riploy-1", Tienda: "Riploy", Categoria: "Moda"})
+	}()
+	time.Sleep(100 * time.Millisecond)
+
+	inicio := time.Now()
+	b.ConsultarEstado(ctx, &pb.ConsultarEstadoRequest{})
+	b.RegistrarProductor(ctx, &pb.RegistroProductorRequest{Nombre: "Parisio"})
+	b.SolicitarInicio(ctx, &pb.InicioRequest{})
+	if espera := time.Since(inicio); espera > demora/2 {
+		t.Fatalf("las consultas esperaron %v a la escritura en curso", espera)
+	}
+	<-escribiendo
+}
+
+func conectarPrueba(t *testing.T, direccion string) *grpc.ClientConn {
+	t.Helper()
+	conn, err := grpc.Dial(direccion, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
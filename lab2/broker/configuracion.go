@@ -0,0 +1,335 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ConfigQuorum define la cantidad de réplicas (N) y cuántas confirmaciones se
+// exigen para escribir (W) y para leer (R).
+type ConfigQuorum struct {
+	N int `json:"n"`
+	W int `json:"w"`
+	R int `json:"r"`
+}
+
+// ConfigMembresia define cuántas entidades deben estar registradas para dar
+// inicio al envío de ofertas. Si MinNodos es 0 se usa max(W, R).
+type ConfigMembresia struct {
+	MinNodos        int `json:"min_nodos"`
+	MinProductores  int `json:"min_productores"`
+	MinConsumidores int `json:"min_consumidores"`
+}
+
+// ConfigLatidos define cada cuánto se envían latidos y cuánto silencio se
+// tolera antes de sospechar de una entidad o darla por caída.
+type ConfigLatidos struct {
+	IntervaloMs int `json:"intervalo_ms"`
+	SospechaMs  int `json:"sospecha_ms"`
+	CaidaMs     int `json:"caida_ms"`
+}
+
+// ConfigReplicacion define las réplicas del broker. Pares asocia el ID de cada
+// réplica (incluida esta) con la dirección en que la ven las demás y los
+// clientes. Con menos de dos pares el broker funciona solo, sin Raft.
+type ConfigReplicacion struct {
+	ID    string            `json:"id"`
+	Pares map[string]string `json:"pares"`
+}
+
+// Configuracion agrupa los parámetros del broker. Se arma en este orden, donde
+// cada fuente sobrescribe a la anterior: valores por defecto, archivo JSON
+// (--config o BROKER_CONFIG), variables de entorno y flags.
+type Configuracion struct {
+	Quorum    ConfigQuorum    `json:"quorum"`
+	Membresia ConfigMembresia `json:"membresia"`
+	Latidos   ConfigLatidos   `json:"latidos"`
+	DirDatos  string          `json:"datos"`
+	Puerto    int             `json:"puerto"`
+	// Puerto de la API HTTP/JSON y de las métricas; 0 los desactiva
+	PuertoHTTP int `json:"puerto_http"`
+	// Réplicas del broker para alta disponibilidad
+	Replicacion ConfigReplicacion `json:"replicacion"`
+	// Posiciones de cada nodo en el anillo de hashing consistente
+	NodosVirtuales int `json:"nodos_virtuales"`
+	// Cantidad de IDs de oferta recientes que se recuerdan para detectar reintentos
+	VentanaDedup int `json:"ventana_dedup"`
+	// Segundos que dura vigente una oferta; 0 si no expiran
+	TTLOfertasSeg int `json:"ttl_ofertas_seg"`
+	// Segundos entre rondas de anti-entropía; 0 la desactiva
+	AntiEntropiaSeg int `json:"anti_entropia_seg"`
+}
+
+func configuracionPorDefecto() Configuracion {
+	return Configuracion{
+		Quorum:          ConfigQuorum{N: 3, W: 2, R: 2},
+		Latidos:         ConfigLatidos{IntervaloMs: 1000, SospechaMs: 2000, CaidaMs: 4000},
+		DirDatos:        "datos/broker",
+		Puerto:          50051,
+		PuertoHTTP:      8080,
+		AntiEntropiaSeg: 15,
+		VentanaDedup:    10000,
+		NodosVirtuales:  128,
+	}
+}
+
+func cargarConfiguracion(args []string) (Configuracion, error) {
+	config := configuracionPorDefecto()
+
+	fs := flag.NewFlagSet("broker", flag.ContinueOnError)
+	archivo := fs.String("config", os.Getenv("BROKER_CONFIG"), "Archivo JSON de configuración")
+	n := fs.Int("n", 0, "Cantidad de réplicas por oferta (N)")
+	w := fs.Int("w", 0, "Confirmaciones necesarias para una escritura (W)")
+	r := fs.Int("r", 0, "Respuestas necesarias para una lectura (R)")
+	minNodos := fs.Int("min-nodos", 0, "Nodos necesarios para dar inicio (0 = max(W, R))")
+	minProductores := fs.Int("min-productores", 0, "Productores necesarios para dar inicio")
+	minConsumidores := fs.Int("min-consumidores", 0, "Consumidores necesarios para dar inicio")
+	dirDatos := fs.String("datos", "", "Directorio donde el broker persiste su estado")
+	puerto := fs.Int("puerto", 0, "Puerto en que escucha el broker")
+	puertoHTTP := fs.Int("puerto-http", 0, "Puerto de la API HTTP/JSON y de /metrics (0 = desactivada)")
+	id := fs.String("id", "", "ID de esta réplica del broker")
+	pares := fs.String("pares", "", "Réplicas del broker como ID=host:puerto separadas por coma")
+	latidoIntervalo := fs.Int("latido-intervalo", 0, "Milisegundos entre latidos")
+	latidoSospecha := fs.Int("latido-sospecha", 0, "Milisegundos sin latidos para sospechar de una entidad")
+	latidoCaida := fs.Int("latido-caida", 0, "Milisegundos sin latidos para darla por caída")
+	ventanaDedup := fs.Int("dedup-ventana", 0, "IDs de oferta recientes que se recuerdan para detectar reintentos")
+	nodosVirtuales := fs.Int("nodos-virtuales", 0, "Posiciones de cada nodo en el anillo de hashing consistente")
+	ttlOfertas := fs.Int("ttl-ofertas", 0, "Segundos que dura vigente una oferta (0 = no expiran)")
+	antiEntropia := fs.Int("anti-entropia", 0, "Segundos entre rondas de anti-entropía (0 = desactivada)")
+	if err := fs.Parse(args); err != nil {
+		return config, err
+	}
+
+	if *archivo != "" {
+		datos, err := os.ReadFile(*archivo)
+		if err != nil {
+			return config, fmt.Errorf("no se pudo leer %s: %v", *archivo, err)
+		}
+		if err := json.Unmarshal(datos, &config); err != nil {
+			return config, fmt.Errorf("archivo de configuración inválido %s: %v", *archivo, err)
+		}
+	}
+
+	for variable, destino := range map[string]*int{
+		"QUORUM_N":            &config.Quorum.N,
+		"QUORUM_W":            &config.Quorum.W,
+		"QUORUM_R":            &config.Quorum.R,
+		"MIN_NODOS":           &config.Membresia.MinNodos,
+		"MIN_PRODUCTORES":     &config.Membresia.MinProductores,
+		"MIN_CONSUMIDORES":    &config.Membresia.MinConsumidores,
+		"ANTI_ENTROPIA_SEG":   &config.AntiEntropiaSeg,
+		"DEDUP_VENTANA":       &config.VentanaDedup,
+		"LATIDO_INTERVALO_MS": &config.Latidos.IntervaloMs,
+		"LATIDO_SOSPECHA_MS":  &config.Latidos.SospechaMs,
+		"LATIDO_CAIDA_MS":     &config.Latidos.CaidaMs,
+		"BROKER_PUERTO":       &config.Puerto,
+		"BROKER_PUERTO_HTTP":  &config.PuertoHTTP,
+		"NODOS_VIRTUALES":     &config.NodosVirtuales,
+		"TTL_OFERTAS_SEG":     &config.TTLOfertasSeg,
+	} {
+		valor := os.Getenv(variable)
+		if valor == "" {
+			continue
+		}
+		entero, err := strconv.Atoi(valor)
+		if err != nil {
+			return config, fmt.Errorf("%s inválido: %s", variable, valor)
+		}
+		*destino = entero
+	}
+	if valor := os.Getenv("BROKER_DATOS"); valor != "" {
+		config.DirDatos = valor
+	}
+	if valor := os.Getenv("BROKER_ID"); valor != "" {
+		config.Replicacion.ID = valor
+	}
+	if valor := os.Getenv("BROKER_PARES"); valor != "" {
+		mapa, err := parsearPares(valor)
+		if err != nil {
+			return config, fmt.Errorf("BROKER_PARES inválido: %v", err)
+		}
+		config.Replicacion.Pares = mapa
+	}
+
+	// Solo se aplican los flags que fueron entregados explícitamente
+	fs.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "n":
+			config.Quorum.N = *n
+		case "w":
+			config.Quorum.W = *w
+		case "r":
+			config.Quorum.R = *r
+		case "min-nodos":
+			config.Membresia.MinNodos = *minNodos
+		case "min-productores":
+			config.Membresia.MinProductores = *minProductores
+		case "min-consumidores":
+			config.Membresia.MinConsumidores = *minConsumidores
+		case "datos":
+			config.DirDatos = *dirDatos
+		case "puerto":
+			config.Puerto = *puerto
+		case "puerto-http":
+			config.PuertoHTTP = *puertoHTTP
+		case "id":
+			config.Replicacion.ID = *id
+		case "dedup-ventana":
+			config.VentanaDedup = *ventanaDedup
+		case "nodos-virtuales":
+			config.NodosVirtuales = *nodosVirtuales
+		case "ttl-ofertas":
+			config.TTLOfertasSeg = *ttlOfertas
+		case "anti-entropia":
+			config.AntiEntropiaSeg = *antiEntropia
+		case "latido-intervalo":
+			config.Latidos.IntervaloMs = *latidoIntervalo
+		case "latido-sospecha":
+			config.Latidos.SospechaMs = *latidoSospecha
+		case "latido-caida":
+			config.Latidos.CaidaMs = *latidoCaida
+		}
+	})
+	if *pares != "" {
+		mapa, err := parsearPares(*pares)
+		if err != nil {
+			return config, fmt.Errorf("--pares inválido: %v", err)
+		}
+		config.Replicacion.Pares = mapa
+	}
+
+	if err := config.Quorum.validar(); err != nil {
+		return config, err
+	}
+	if err := config.Membresia.validar(config.Quorum); err != nil {
+		return config, err
+	}
+	if err := config.Latidos.validar(); err != nil {
+		return config, err
+	}
+	if config.VentanaDedup < 1 {
+		return config, fmt.Errorf("DEDUP_VENTANA debe ser al menos 1 (%d)", config.VentanaDedup)
+	}
+	if config.NodosVirtuales < 1 {
+		return config, fmt.Errorf("NODOS_VIRTUALES debe ser al menos 1 (%d)", config.NodosVirtuales)
+	}
+	if config.TTLOfertasSeg < 0 {
+		return config, fmt.Errorf("TTL_OFERTAS_SEG no puede ser negativo (%d)", config.TTLOfertasSeg)
+	}
+	if config.AntiEntropiaSeg < 0 {
+		return config, fmt.Errorf("ANTI_ENTROPIA_SEG no puede ser negativo (%d)", config.AntiEntropiaSeg)
+	}
+	if config.Puerto < 1 || config.Puerto > 65535 {
+		return config, fmt.Errorf("puerto inválido (%d)", config.Puerto)
+	}
+	if config.PuertoHTTP < 0 || config.PuertoHTTP > 65535 {
+		return config, fmt.Errorf("puerto HTTP inválido (%d)", config.PuertoHTTP)
+	}
+	if err := config.Replicacion.validar(); err != nil {
+		return config, err
+	}
+	if config.Membresia.MinNodos == 0 {
+		config.Membresia.MinNodos = max(config.Quorum.W, config.Quorum.R)
+	}
+	return config, nil
+}
+
+// validar rechaza combinaciones imposibles y advierte sobre las que no
+// garantizan consistencia.
+func (q ConfigQuorum) validar() error {
+	if q.N < 1 {
+		return fmt.Errorf("N debe ser al menos 1 (N=%d)", q.N)
+	}
+	if q.W < 1 || q.W > q.N {
+		return fmt.Errorf("W debe estar entre 1 y N (N=%d, W=%d)", q.N, q.W)
+	}
+	if q.R < 1 || q.R > q.N {
+		return fmt.Errorf("R debe estar entre 1 y N (N=%d, R=%d)", q.N, q.R)
+	}
+
+	if !q.lecturaConsistente() {
+		log.Printf("ADVERTENCIA: W+R=%d no supera N=%d - una lectura puede no ver la última escritura", q.W+q.R, q.N)
+	}
+	if 2*q.W <= q.N {
+		log.Printf("ADVERTENCIA: 2W=%d no supera N=%d - dos escrituras pueden confirmarse en réplicas disjuntas", 2*q.W, q.N)
+	}
+	return nil
+}
+
+func (q ConfigQuorum) lecturaConsistente() bool {
+	return q.W+q.R > q.N
+}
+
+func (q ConfigQuorum) String() string {
+	return fmt.Sprintf("N=%d, W=%d, R=%d", q.N, q.W, q.R)
+}
+
+func (m ConfigMembresia) validar(q ConfigQuorum) error {
+	if m.MinNodos < 0 || m.MinProductores < 0 || m.MinConsumidores < 0 {
+		return fmt.Errorf("los mínimos de membresía no pueden ser negativos")
+	}
+	if m.MinNodos > 0 && m.MinNodos < q.W {
+		log.Printf("ADVERTENCIA: min_nodos=%d es menor que W=%d - las primeras escrituras fallarán", m.MinNodos, q.W)
+	}
+	return nil
+}
+
+// parsearPares lee una lista "B1=host:puerto,B2=host:puerto".
+func parsearPares(valor string) (map[string]string, error) {
+	pares := make(map[string]string)
+	for _, par := range strings.Split(valor, ",") {
+		id, direccion, ok := strings.Cut(strings.TrimSpace(par), "=")
+		if !ok || id == "" || direccion == "" {
+			return nil, fmt.Errorf("se esperaba ID=host:puerto y se recibió %q", par)
+		}
+		pares[id] = direccion
+	}
+	return pares, nil
+}
+
+func (r ConfigReplicacion) validar() error {
+	if !r.habilitada() {
+		return nil
+	}
+	if _, existe := r.Pares[r.ID]; !existe {
+		return fmt.Errorf("el ID de la réplica (%q) debe estar entre los pares", r.ID)
+	}
+	if len(r.Pares)%2 == 0 {
+		log.Printf("ADVERTENCIA: %d réplicas del broker toleran las mismas caídas que %d", len(r.Pares), len(r.Pares)-1)
+	}
+	return nil
+}
+
+// habilitada indica si hay más de una réplica, y por lo tanto elección de líder.
+func (r ConfigReplicacion) habilitada() bool {
+	return len(r.Pares) > 1
+}
+
+func (l ConfigLatidos) validar() error {
+	if l.IntervaloMs <= 0 {
+		return fmt.Errorf("el intervalo de latidos debe ser positivo (%d ms)", l.IntervaloMs)
+	}
+	if l.SospechaMs < l.IntervaloMs || l.CaidaMs <= l.SospechaMs {
+		return fmt.Errorf("se requiere intervalo <= sospecha < caída (%d, %d, %d ms)",
+			l.IntervaloMs, l.SospechaMs, l.CaidaMs)
+	}
+	return nil
+}
+
+func (l ConfigLatidos) intervalo() time.Duration {
+	return time.Duration(l.IntervaloMs) * time.Millisecond
+}
+
+func (l ConfigLatidos) sospecha() time.Duration {
+	return time.Duration(l.SospechaMs) * time.Millisecond
+}
+
+func (l ConfigLatidos) caida() time.Duration {
+	return time.Duration(l.CaidaMs) * time.Millisecond
+}
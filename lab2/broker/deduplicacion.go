@@ -0,0 +1,150 @@
+package main
+
+import (
+	"sync"
+)
+
+type resultadoDedup int
+
+const (
+	ofertaNueva resultadoDedup = iota
+	ofertaReintento
+	ofertaEnProceso
+	ofertaConfirmada
+)
+
+type entradaDedup struct {
+	enProceso  bool
+	confirmada bool
+	notificada bool
+}
+
+// ventanaDedup recuerda las últimas ofertas recibidas por ID para reconocer los
+// reintentos de los productores. Un reintento de una oferta ya confirmada se
+// responde con éxito sin volver a escribirla ni notificarla; uno de una oferta
+// que no alcanzó quorum se procesa de nuevo. Cuando se supera la capacidad se
+// olvidan las más antiguas.
+type ventanaDedup struct {
+	mu        sync.Mutex
+	entradas  map[string]*entradaDedup
+	orden     []string
+	capacidad int
+}
+
+func nuevaVentanaDedup(capacidad int) *ventanaDedup {
+	return &ventanaDedup{
+		entradas:  make(map[string]*entradaDedup),
+		capacidad: capacidad,
+	}
+}
+
+// iniciar registra que llegó la oferta e indica si hay que procesarla.
+func (v *ventanaDedup) iniciar(ofertaID string) resultadoDedup {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	if entrada, existe := v.entradas[ofertaID]; existe {
+		switch {
+		case entrada.confirmada:
+			return ofertaConfirmada
+		case entrada.enProceso:
+			return ofertaEnProceso
+		}
+		entrada.enProceso = true
+		return ofertaReintento
+	}
+
+	v.agregarLocked(ofertaID).enProceso = true
+	return ofertaNueva
+}
+
+// agregarLocked crea la entrada de la oferta y olvida la más antigua si se
+// supera la capacidad.
+func (v *ventanaDedup) agregarLocked(ofertaID string) *entradaDedup {
+	entrada := &entradaDedup{}
+	v.entradas[ofertaID] = entrada
+	v.orden = append(v.orden, ofertaID)
+	if len(v.orden) > v.capacidad {
+		delete(v.entradas, v.orden[0])
+		v.orden = v.orden[1:]
+	}
+	return entrada
+}
+
+func (v *ventanaDedup) terminar(ofertaID string, exito bool) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	if entrada, existe := v.entradas[ofertaID]; existe {
+		entrada.enProceso = false
+		entrada.confirmada = exito
+	}
+}
+
+// confirmadas retorna, de la más antigua a la más nueva, las ofertas ya
+// confirmadas, que es lo que se persiste de la ventana.
+func (v *ventanaDedup) confirmadas() []string {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	ids := make([]string, 0, len(v.orden))
+	for _, ofertaID := range v.orden {
+		if v.entradas[ofertaID].confirmada {
+			ids = append(ids, ofertaID)
+		}
+	}
+	return ids
+}
+
+// restaurar recuerda como confirmadas y notificadas las ofertas guardadas
+// antes de un reinicio.
+func (v *ventanaDedup) restaurar(ids []string) {
+	for _, ofertaID := range ids {
+		v.iniciar(ofertaID)
+		v.terminar(ofertaID, true)
+		v.marcarNotificada(ofertaID)
+	}
+}
+
+// registrarPublicada recuerda una oferta que el líder publicó a los
+// consumidores, como confirmada solo si alcanzó quorum W. Así una réplica que
+// asume como líder responde los reintentos igual que el anterior.
+func (v *ventanaDedup) registrarPublicada(ofertaID string, confirmada bool) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	entrada, existe := v.entradas[ofertaID]
+	if !existe {
+		entrada = v.agregarLocked(ofertaID)
+	}
+	entrada.notificada = true
+	entrada.confirmada = entrada.confirmada || confirmada
+}
+
+// marcarNotificada retorna true solo la primera vez, para que los reintentos
+// no notifiquen dos veces a los consumidores.
+func (v *ventanaDedup) marcarNotificada(ofertaID string) bool {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	entrada, existe := v.entradas[ofertaID]
+	if !existe {
+		return true
+	}
+	if entrada.notificada {
+		return false
+	}
+	entrada.notificada = true
+	return true
+}
+
+// desmarcarNotificada deshace marcarNotificada cuando la oferta no se alcanzó
+// a publicar, para que el reintento la publique.
+func (v *ventanaDedup) desmarcarNotificada(ofertaID string) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	if entrada, existe := v.entradas[ofertaID]; existe {
+		entrada.notificada = false
+	}
+}
@@ -0,0 +1,243 @@
+// Package filtros evalúa los filtros de suscripción de los consumidores sobre
+// las ofertas que distribuye el broker.
+package filtros
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	pb "lab2/broker/proto"
+)
+
+// Filtro es un FiltroOferta ya validado, con su expresión regular compilada.
+// Un Filtro nil coincide con cualquier oferta.
+type Filtro struct {
+	categorias       map[string]bool
+	tiendas          map[string]bool
+	precioMin        int32
+	precioMax        int32
+	descuentoMin     int32
+	stockMin         int32
+	productoContiene string
+	productoRegex    *regexp.Regexp
+	operador         pb.OperadorFiltro
+	subfiltros       []*Filtro
+	original         *pb.FiltroOferta
+}
+
+// Compilar valida el filtro recibido por gRPC y lo prepara para evaluarlo.
+func Compilar(f *pb.FiltroOferta) (*Filtro, error) {
+	if f == nil {
+		return nil, nil
+	}
+
+	if f.GetPrecioMin() < 0 || f.GetPrecioMax() < 0 || f.GetDescuentoMin() < 0 || f.GetStockMin() < 0 {
+		return nil, fmt.Errorf("los límites del filtro no pueden ser negativos")
+	}
+	if f.GetPrecioMax() > 0 && f.GetPrecioMin() > f.GetPrecioMax() {
+		return nil, fmt.Errorf("rango de precio vacío: %d > %d", f.GetPrecioMin(), f.GetPrecioMax())
+	}
+	if f.GetDescuentoMin() > 100 {
+		return nil, fmt.Errorf("descuento mínimo inválido: %d%%", f.GetDescuentoMin())
+	}
+	if _, existe := pb.OperadorFiltro_name[int32(f.GetOperador())]; !existe {
+		return nil, fmt.Errorf("operador desconocido: %d", f.GetOperador())
+	}
+
+	filtro := &Filtro{
+		categorias:       conjunto(f.GetCategorias()),
+		tiendas:          conjunto(f.GetTiendas()),
+		precioMin:        f.GetPrecioMin(),
+		precioMax:        f.GetPrecioMax(),
+		descuentoMin:     f.GetDescuentoMin(),
+		stockMin:         f.GetStockMin(),
+		productoContiene: strings.ToLower(f.GetProductoContiene()),
+		operador:         f.GetOperador(),
+		original:         f,
+	}
+
+	if f.GetProductoRegex() != "" {
+		regex, err := regexp.Compile(f.GetProductoRegex())
+		if err != nil {
+			return nil, fmt.Errorf("expresión regular inválida %q: %v", f.GetProductoRegex(), err)
+		}
+		filtro.productoRegex = regex
+	}
+
+	for _, sub := range f.GetSubfiltros() {
+		compilado, err := Compilar(sub)
+		if err != nil {
+			return nil, err
+		}
+		filtro.subfiltros = append(filtro.subfiltros, compilado)
+	}
+
+	return filtro, nil
+}
+
+// DesdePreferencias arma el filtro equivalente a las preferencias simples de
+// un consumidor: categorías, tiendas y precio máximo. Las listas vacías y un
+// precio máximo no positivo no restringen.
+func DesdePreferencias(categorias, tiendas []string, precioMax int32) *pb.FiltroOferta {
+	return &pb.FiltroOferta{
+		Categorias: categorias,
+		Tiendas:    tiendas,
+		PrecioMax:  max(precioMax, 0),
+	}
+}
+
+// Coincide indica si la oferta cumple el filtro.
+func (f *Filtro) Coincide(oferta *pb.OfertaRequest) bool {
+	if f == nil {
+		return true
+	}
+	return f.cumpleCondiciones(oferta) && f.cumpleSubfiltros(oferta)
+}
+
+func (f *Filtro) cumpleCondiciones(oferta *pb.OfertaRequest) bool {
+	if len(f.categorias) > 0 && !f.categorias[oferta.GetCategoria()] {
+		return false
+	}
+	if len(f.tiendas) > 0 && !f.tiendas[oferta.GetTienda()] {
+		return false
+	}
+	if oferta.GetPrecio() < f.precioMin {
+		return false
+	}
+	if f.precioMax > 0 && oferta.GetPrecio() > f.precioMax {
+		return false
+	}
+	if oferta.GetDescuento() < f.descuentoMin {
+		return false
+	}
+	// El stock mínimo solo decide si interesa una oferta vigente: los cambios
+	// de estado deben llegar a quien ya la recibió
+	if oferta.GetEstado() == pb.EstadoOferta_VIGENTE && oferta.GetStock() < f.stockMin {
+		return false
+	}
+	if f.productoContiene != "" && !strings.Contains(strings.ToLower(oferta.GetProducto()), f.productoContiene) {
+		return false
+	}
+	if f.productoRegex != nil && !f.productoRegex.MatchString(oferta.GetProducto()) {
+		return false
+	}
+	return true
+}
+
+func (f *Filtro) cumpleSubfiltros(oferta *pb.OfertaRequest) bool {
+	if len(f.subfiltros) == 0 {
+		return true
+	}
+
+	switch f.operador {
+	case pb.OperadorFiltro_O:
+		for _, sub := range f.subfiltros {
+			if sub.Coincide(oferta) {
+				return true
+			}
+		}
+		return false
+	case pb.OperadorFiltro_NO:
+		for _, sub := range f.subfiltros {
+			if sub.Coincide(oferta) {
+				return false
+			}
+		}
+		return true
+	default:
+		for _, sub := range f.subfiltros {
+			if !sub.Coincide(oferta) {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+// Proto retorna el filtro tal como se recibió.
+func (f *Filtro) Proto() *pb.FiltroOferta {
+	if f == nil {
+		return nil
+	}
+	return f.original
+}
+
+// Lectura retorna las condiciones del filtro que los nodos pueden aplicar al
+// leer. Solo se usan las del nivel superior, que se cumplen junto con las de
+// los subfiltros, así que lo que los nodos descartan tampoco coincidiría aquí.
+func (f *Filtro) Lectura() *pb.LecturaRequest {
+	if f == nil {
+		return &pb.LecturaRequest{}
+	}
+	return &pb.LecturaRequest{
+		Categorias: f.original.GetCategorias(),
+		Tiendas:    f.original.GetTiendas(),
+		PrecioMin:  f.precioMin,
+		PrecioMax:  f.precioMax,
+	}
+}
+
+// String describe el filtro para los logs y el reporte.
+func (f *Filtro) String() string {
+	if f == nil {
+		return "cualquier oferta"
+	}
+
+	var condiciones []string
+	if len(f.original.GetCategorias()) > 0 {
+		condiciones = append(condiciones, fmt.Sprintf("categoría en %v", f.original.GetCategorias()))
+	}
+	if len(f.original.GetTiendas()) > 0 {
+		condiciones = append(condiciones, fmt.Sprintf("tienda en %v", f.original.GetTiendas()))
+	}
+	if f.precioMin > 0 {
+		condiciones = append(condiciones, fmt.Sprintf("precio >= %d", f.precioMin))
+	}
+	if f.precioMax > 0 {
+		condiciones = append(condiciones, fmt.Sprintf("precio <= %d", f.precioMax))
+	}
+	if f.descuentoMin > 0 {
+		condiciones = append(condiciones, fmt.Sprintf("descuento >= %d%%", f.descuentoMin))
+	}
+	if f.stockMin > 0 {
+		condiciones = append(condiciones, fmt.Sprintf("stock >= %d", f.stockMin))
+	}
+	if f.productoContiene != "" {
+		condiciones = append(condiciones, fmt.Sprintf("producto contiene %q", f.original.GetProductoContiene()))
+	}
+	if f.productoRegex != nil {
+		condiciones = append(condiciones, fmt.Sprintf("producto ~ /%s/", f.productoRegex))
+	}
+
+	if len(f.subfiltros) > 0 {
+		partes := make([]string, len(f.subfiltros))
+		for i, sub := range f.subfiltros {
+			partes[i] = "(" + sub.String() + ")"
+		}
+		switch f.operador {
+		case pb.OperadorFiltro_O:
+			condiciones = append(condiciones, strings.Join(partes, " O "))
+		case pb.OperadorFiltro_NO:
+			condiciones = append(condiciones, "NO "+strings.Join(partes, " NI "))
+		default:
+			condiciones = append(condiciones, partes...)
+		}
+	}
+
+	if len(condiciones) == 0 {
+		return "cualquier oferta"
+	}
+	return strings.Join(condiciones, " Y ")
+}
+
+func conjunto(valores []string) map[string]bool {
+	if len(valores) == 0 {
+		return nil
+	}
+	c := make(map[string]bool, len(valores))
+	for _, valor := range valores {
+		c[valor] = true
+	}
+	return c
+}
@@ -0,0 +1,153 @@
+package filtros
+
+import (
+	"strings"
+	"testing"
+
+	pb "lab2/broker/proto"
+)
+
+func oferta(producto string, precio, descuento, stock int32) *pb.OfertaRequest {
+	return &pb.OfertaRequest{
+		OfertaId:  "Riploy-1",
+		Tienda:    "Riploy",
+		Categoria: "Electrónica",
+		Producto:  producto,
+		Precio:    precio,
+		Descuento: descuento,
+		Stock:     stock,
+	}
+}
+
+func TestCoincide(t *testing.T) {
+	agotada := oferta("Notebook Lenovo", 500000, 20, 0)
+	agotada.Estado = pb.EstadoOferta_AGOTADA
+
+	casos := []struct {
+		nombre string
+		filtro *pb.FiltroOferta
+		oferta *pb.OfertaRequest
+		quiere bool
+	}{
+		{"sin filtro", nil, oferta("Notebook", 500000, 0, 1), true},
+		{"filtro vacío", &pb.FiltroOferta{}, oferta("Notebook", 500000, 0, 1), true},
+
+		{"categoría", &pb.FiltroOferta{Categorias: []string{"Electrónica", "Moda"}}, oferta("Notebook", 1, 0, 1), true},
+		{"otra categoría", &pb.FiltroOferta{Categorias: []string{"Moda"}}, oferta("Notebook", 1, 0, 1), false},
+		{"tienda", &pb.FiltroOferta{Tiendas: []string{"Riploy"}}, oferta("Notebook", 1, 0, 1), true},
+		{"otra tienda", &pb.FiltroOferta{Tiendas: []string{"Parisio"}}, oferta("Notebook", 1, 0, 1), false},
+
+		{"precio dentro del rango", &pb.FiltroOferta{PrecioMin: 100, PrecioMax: 200}, oferta("Notebook", 150, 0, 1), true},
+		{"precio en el mínimo", &pb.FiltroOferta{PrecioMin: 100, PrecioMax: 200}, oferta("Notebook", 100, 0, 1), true},
+		{"precio en el máximo", &pb.FiltroOferta{PrecioMin: 100, PrecioMax: 200}, oferta("Notebook", 200, 0, 1), true},
+		{"precio bajo el mínimo", &pb.FiltroOferta{PrecioMin: 100, PrecioMax: 200}, oferta("Notebook", 99, 0, 1), false},
+		{"precio sobre el máximo", &pb.FiltroOferta{PrecioMin: 100, PrecioMax: 200}, oferta("Notebook", 201, 0, 1), false},
+		{"solo precio mínimo", &pb.FiltroOferta{PrecioMin: 100}, oferta("Notebook", 1000000, 0, 1), true},
+
+		{"descuento suficiente", &pb.FiltroOferta{DescuentoMin: 30}, oferta("Notebook", 1, 30, 1), true},
+		{"descuento insuficiente", &pb.FiltroOferta{DescuentoMin: 30}, oferta("Notebook", 1, 29, 1), false},
+
+		{"stock suficiente", &pb.FiltroOferta{StockMin: 5}, oferta("Notebook", 1, 0, 5), true},
+		{"stock insuficiente", &pb.FiltroOferta{StockMin: 5}, oferta("Notebook", 1, 0, 4), false},
+		{"stock mínimo no aplica a una agotada", &pb.FiltroOferta{StockMin: 5}, agotada, true},
+
+		{"contiene", &pb.FiltroOferta{ProductoContiene: "lenovo"}, oferta("Notebook Lenovo", 1, 0, 1), true},
+		{"contiene sin distinguir mayúsculas", &pb.FiltroOferta{ProductoContiene: "NOTEBOOK"}, oferta("notebook lenovo", 1, 0, 1), true},
+		{"no contiene", &pb.FiltroOferta{ProductoContiene: "HP"}, oferta("Notebook Lenovo", 1, 0, 1), false},
+		{"regex", &pb.FiltroOferta{ProductoRegex: `^Notebook (Lenovo|HP)$`}, oferta("Notebook HP", 1, 0, 1), true},
+		{"regex no coincide", &pb.FiltroOferta{ProductoRegex: `^Notebook (Lenovo|HP)$`}, oferta("Notebook Asus", 1, 0, 1), false},
+		{"regex distingue mayúsculas", &pb.FiltroOferta{ProductoRegex: `^notebook`}, oferta("Notebook HP", 1, 0, 1), false},
+
+		{"todas las condiciones", &pb.FiltroOferta{
+			Tiendas: []string{"Riploy"}, PrecioMax: 600000, DescuentoMin: 10, StockMin: 1, ProductoContiene: "lenovo",
+		}, oferta("Notebook Lenovo", 500000, 20, 3), true},
+		{"falla una condición", &pb.FiltroOferta{
+			Tiendas: []string{"Riploy"}, PrecioMax: 600000, DescuentoMin: 30, StockMin: 1, ProductoContiene: "lenovo",
+		}, oferta("Notebook Lenovo", 500000, 20, 3), false},
+	}
+
+	for _, c := range casos {
+		t.Run(c.nombre, func(t *testing.T) {
+			filtro, err := Compilar(c.filtro)
+			if err != nil {
+				t.Fatalf("Compilar: %v", err)
+			}
+			if got := filtro.Coincide(c.oferta); got != c.quiere {
+				t.Fatalf("Coincide(%s) con %q = %v, se esperaba %v", filtro, c.oferta.GetProducto(), got, c.quiere)
+			}
+		})
+	}
+}
+
+func TestCoincideCombinaciones(t *testing.T) {
+	barato := &pb.FiltroOferta{PrecioMax: 1000}
+	lenovo := &pb.FiltroOferta{ProductoContiene: "lenovo"}
+	moda := &pb.FiltroOferta{Categorias: []string{"Moda"}}
+
+	casos := []struct {
+		nombre string
+		filtro *pb.FiltroOferta
+		oferta *pb.OfertaRequest
+		quiere bool
+	}{
+		{"Y se cumplen todos", &pb.FiltroOferta{Operador: pb.OperadorFiltro_Y, Subfiltros: []*pb.FiltroOferta{barato, lenovo}}, oferta("Mouse Lenovo", 900, 0, 1), true},
+		{"Y falla uno", &pb.FiltroOferta{Operador: pb.OperadorFiltro_Y, Subfiltros: []*pb.FiltroOferta{barato, lenovo}}, oferta("Notebook Lenovo", 500000, 0, 1), false},
+		{"O se cumple uno", &pb.FiltroOferta{Operador: pb.OperadorFiltro_O, Subfiltros: []*pb.FiltroOferta{barato, lenovo}}, oferta("Notebook Lenovo", 500000, 0, 1), true},
+		{"O no se cumple ninguno", &pb.FiltroOferta{Operador: pb.OperadorFiltro_O, Subfiltros: []*pb.FiltroOferta{barato, lenovo}}, oferta("Notebook HP", 500000, 0, 1), false},
+		{"NO sin coincidencias", &pb.FiltroOferta{Operador: pb.OperadorFiltro_NO, Subfiltros: []*pb.FiltroOferta{moda, lenovo}}, oferta("Notebook HP", 1, 0, 1), true},
+		{"NO con una coincidencia", &pb.FiltroOferta{Operador: pb.OperadorFiltro_NO, Subfiltros: []*pb.FiltroOferta{moda, lenovo}}, oferta("Notebook Lenovo", 1, 0, 1), false},
+		{"condiciones propias junto a O", &pb.FiltroOferta{
+			Tiendas: []string{"Parisio"}, Operador: pb.OperadorFiltro_O, Subfiltros: []*pb.FiltroOferta{barato, lenovo},
+		}, oferta("Mouse Lenovo", 900, 0, 1), false},
+		{"anidados", &pb.FiltroOferta{Operador: pb.OperadorFiltro_Y, Subfiltros: []*pb.FiltroOferta{
+			{Operador: pb.OperadorFiltro_O, Subfiltros: []*pb.FiltroOferta{barato, lenovo}},
+			{Operador: pb.OperadorFiltro_NO, Subfiltros: []*pb.FiltroOferta{moda}},
+		}}, oferta("Notebook Lenovo", 500000, 0, 1), true},
+		{"anidados excluidos por NO", &pb.FiltroOferta{Operador: pb.OperadorFiltro_Y, Subfiltros: []*pb.FiltroOferta{
+			{Operador: pb.OperadorFiltro_O, Subfiltros: []*pb.FiltroOferta{barato, lenovo}},
+			{Operador: pb.OperadorFiltro_NO, Subfiltros: []*pb.FiltroOferta{{ProductoRegex: `^Notebook`}}},
+		}}, oferta("Notebook Lenovo", 500000, 0, 1), false},
+	}
+
+	for _, c := range casos {
+		t.Run(c.nombre, func(t *testing.T) {
+			filtro, err := Compilar(c.filtro)
+			if err != nil {
+				t.Fatalf("Compilar: %v", err)
+			}
+			if got := filtro.Coincide(c.oferta); got != c.quiere {
+				t.Fatalf("Coincide(%s) con %q = %v, se esperaba %v", filtro, c.oferta.GetProducto(), got, c.quiere)
+			}
+		})
+	}
+}
+
+func TestCompilarRechazaFiltrosInvalidos(t *testing.T) {
+	casos := []struct {
+		nombre string
+		filtro *pb.FiltroOferta
+		error  string
+	}{
+		{"regex inválida", &pb.FiltroOferta{ProductoRegex: `Notebook (Lenovo`}, "expresión regular inválida"},
+		{"regex inválida en un subfiltro", &pb.FiltroOferta{Operador: pb.OperadorFiltro_O, Subfiltros: []*pb.FiltroOferta{
+			{ProductoContiene: "lenovo"}, {ProductoRegex: `[a-`}},
+		}, "expresión regular inválida"},
+		{"precio negativo", &pb.FiltroOferta{PrecioMin: -1}, "negativos"},
+		{"stock negativo", &pb.FiltroOferta{StockMin: -1}, "negativos"},
+		{"rango de precio vacío", &pb.FiltroOferta{PrecioMin: 200, PrecioMax: 100}, "rango de precio vacío"},
+		{"descuento sobre 100", &pb.FiltroOferta{DescuentoMin: 101}, "descuento mínimo inválido"},
+		{"operador desconocido", &pb.FiltroOferta{Operador: pb.OperadorFiltro(7)}, "operador desconocido"},
+	}
+
+	for _, c := range casos {
+		t.Run(c.nombre, func(t *testing.T) {
+			filtro, err := Compilar(c.filtro)
+			if err == nil {
+				t.Fatalf("se aceptó el filtro inválido: %s", filtro)
+			}
+			if !strings.Contains(err.Error(), c.error) {
+				t.Fatalf("error %q, se esperaba que mencionara %q", err, c.error)
+			}
+		})
+	}
+}
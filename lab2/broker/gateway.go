@@ -0,0 +1,152 @@
+package main
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"regexp"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+
+	pb "lab2/broker/proto"
+)
+
+const maxCuerpoHTTP = 1 << 20
+
+var parametroRuta = regexp.MustCompile(`\{(\w+)\}`)
+
+// rutaHTTP asocia una ruta de la API con la RPC que atiende. Los mensajes de
+// entrada y salida son los de la RPC, y de ellos se arma el documento OpenAPI.
+type rutaHTTP struct {
+	metodo  string
+	ruta    string
+	rpc     string
+	resumen string
+	entrada protoreflect.MessageDescriptor
+	salida  protoreflect.MessageDescriptor
+	// Parámetros de consulta, para las rutas GET que no reciben cuerpo
+	consulta []protoreflect.FieldDescriptor
+	atender  http.HandlerFunc
+}
+
+// rutasHTTP es la API HTTP/JSON del broker: registro, envío y ciclo de vida de
+// ofertas, búsqueda y estado. Las RPCs entre réplicas, nodos y la suscripción
+// por stream quedan solo en gRPC.
+func (b *Broker) rutasHTTP() []rutaHTTP {
+	filtro := (&pb.FiltroOferta{}).ProtoReflect().Descriptor().Fields()
+	busqueda := (&pb.BusquedaRequest{}).ProtoReflect().Descriptor().Fields()
+
+	return []rutaHTTP{
+		rutaRPC(b, "POST", "/productores", pb.CyberDayService_RegistrarProductor_FullMethodName,
+			"Registra una tienda como productor", b.RegistrarProductor),
+		rutaRPC(b, "POST", "/consumidores", pb.CyberDayService_RegistrarConsumidor_FullMethodName,
+			"Registra un consumidor; las ofertas le llegan al abrir su suscripción", b.RegistrarConsumidor),
+		rutaRPC(b, "PUT", "/consumidores/{consumidor_id}/preferencias", pb.CyberDayService_ActualizarPreferencias_FullMethodName,
+			"Cambia el filtro del consumidor y entrega las ofertas del historial que ahora coinciden", b.ActualizarPreferencias),
+		rutaRPC(b, "DELETE", "/consumidores/{consumidor_id}", pb.CyberDayService_DarDeBajaConsumidor_FullMethodName,
+			"Da de baja al consumidor", b.DarDeBajaConsumidor),
+		rutaRPC(b, "POST", "/ofertas", pb.CyberDayService_EnviarOferta_FullMethodName,
+			"Publica una oferta de un productor registrado", b.EnviarOferta),
+		{
+			metodo:  "GET",
+			ruta:    "/ofertas",
+			rpc:     pb.CyberDayService_BuscarOfertas_FullMethodName,
+			resumen: "Busca ofertas con lectura de quorum R",
+			salida:  (&pb.BusquedaResponse{}).ProtoReflect().Descriptor(),
+			consulta: []protoreflect.FieldDescriptor{
+				filtro.ByName("tiendas"),
+				filtro.ByName("categorias"),
+				filtro.ByName("precio_min"),
+				filtro.ByName("precio_max"),
+				filtro.ByName("descuento_min"),
+				filtro.ByName("stock_min"),
+				filtro.ByName("producto_contiene"),
+				busqueda.ByName("orden"),
+				busqueda.ByName("limite"),
+				busqueda.ByName("incluir_no_vigentes"),
+			},
+			atender: b.buscarOfertasHTTP,
+		},
+		rutaRPC(b, "POST", "/ofertas/{oferta_id}/stock", pb.CyberDayService_ActualizarStock_FullMethodName,
+			"Suma delta al stock de la oferta; solo la tienda que la publicó puede reponerlo y una compra usa delta -1", b.ActualizarStock),
+		rutaRPC(b, "POST", "/ofertas/{oferta_id}/retiro", pb.CyberDayService_RetirarOferta_FullMethodName,
+			"Retira la oferta; solo la tienda que la publicó puede hacerlo", b.RetirarOferta),
+		rutaRPC(b, "GET", "/estado", pb.CyberDayService_ConsultarEstado_FullMethodName,
+			"Indica si el CyberDay sigue activo", b.ConsultarEstado),
+		rutaRPC(b, "GET", "/inicio", pb.CyberDayService_SolicitarInicio_FullMethodName,
+			"Indica si ya se dio inicio al envío de ofertas", b.SolicitarInicio),
+	}
+}
+
+// rutaRPC arma una ruta que decodifica el cuerpo JSON como el mensaje de
+// entrada de la RPC, completa los parámetros de la ruta y responde con el
+// mensaje de salida. Igual que por gRPC, un rechazo del broker llega como
+// exito=false en una respuesta 200.
+func rutaRPC[E, S proto.Message](b *Broker, metodo, ruta, rpc, resumen string, llamar func(context.Context, E) (S, error)) rutaHTTP {
+	var entrada E
+	var salida S
+	r := rutaHTTP{
+		metodo:  metodo,
+		ruta:    ruta,
+		rpc:     rpc,
+		resumen: resumen,
+		entrada: entrada.ProtoReflect().Descriptor(),
+		salida:  salida.ProtoReflect().Descriptor(),
+	}
+
+	r.atender = func(w http.ResponseWriter, req *http.Request) {
+		if !b.verificarLiderHTTP(w, rpc) {
+			return
+		}
+
+		mensaje := entrada.ProtoReflect().Type().New().Interface().(E)
+		if metodo == "POST" || metodo == "PUT" {
+			cuerpo, err := io.ReadAll(http.MaxBytesReader(w, req.Body, maxCuerpoHTTP))
+			if err != nil {
+				responderErrorHTTP(w, http.StatusBadRequest, err.Error())
+				return
+			}
+			if err := protojson.Unmarshal(cuerpo, mensaje); err != nil {
+				responderErrorHTTP(w, http.StatusBadRequest, "cuerpo inválido: "+err.Error())
+				return
+			}
+		}
+		// Los parámetros de la ruta prevalecen sobre el cuerpo
+		for _, nombre := range parametrosDeRuta(ruta) {
+			campo := r.entrada.Fields().ByName(protoreflect.Name(nombre))
+			mensaje.ProtoReflect().Set(campo, protoreflect.ValueOfString(req.PathValue(nombre)))
+		}
+
+		resp, err := llamar(req.Context(), mensaje)
+		if err != nil {
+			responderErrorHTTP(w, codigoHTTP(err), status.Convert(err).Message())
+			return
+		}
+		responderJSON(w, http.StatusOK, resp)
+	}
+	return r
+}
+
+func parametrosDeRuta(ruta string) []string {
+	var nombres []string
+	for _, coincidencia := range parametroRuta.FindAllStringSubmatch(ruta, -1) {
+		nombres = append(nombres, coincidencia[1])
+	}
+	return nombres
+}
+
+func codigoHTTP(err error) int {
+	switch status.Code(err) {
+	case codes.InvalidArgument:
+		return http.StatusBadRequest
+	case codes.NotFound:
+		return http.StatusNotFound
+	case codes.FailedPrecondition, codes.Unavailable:
+		return http.StatusServiceUnavailable
+	}
+	return http.StatusInternalServerError
+}
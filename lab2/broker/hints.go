@@ -0,0 +1,310 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"google.golang.org/protobuf/proto"
+	pb "lab2/broker/proto"
+	"lab2/registros"
+)
+
+const extensionHints = ".hints"
+
+// colaHints guarda, por nodo, las ofertas que no se le pudieron escribir
+// (hinted handoff). Cada cola se persiste en <dir>/<nodo>.hints para no
+// perderla si el broker se reinicia, y se reenvía cuando el nodo vuelve.
+type colaHints struct {
+	dir           string
+	mu            sync.Mutex
+	pendientes    map[string]map[string]*pb.OfertaRequest
+	reproduciendo map[string]bool
+}
+
+func abrirColaHints(dir string) (*colaHints, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("no se pudo crear directorio %s: %v", dir, err)
+	}
+
+	c := &colaHints{
+		dir:           dir,
+		pendientes:    make(map[string]map[string]*pb.OfertaRequest),
+		reproduciendo: make(map[string]bool),
+	}
+
+	archivos, err := filepath.Glob(filepath.Join(dir, "*"+extensionHints))
+	if err != nil {
+		return nil, err
+	}
+	for _, archivo := range archivos {
+		nodoID := strings.TrimSuffix(filepath.Base(archivo), extensionHints)
+		ofertas, err := leerRegistros(archivo)
+		if err != nil {
+			return nil, fmt.Errorf("error leyendo hints de %s: %v", nodoID, err)
+		}
+		for _, oferta := range ofertas {
+			c.aplicar(nodoID, oferta)
+		}
+		// Se reescribe el archivo para descartar una posible cola corrupta
+		if err := c.reescribirLocked(nodoID); err != nil {
+			return nil, fmt.Errorf("error compactando hints de %s: %v", nodoID, err)
+		}
+		if len(ofertas) > 0 {
+			log.Printf("Hints recuperados para %s: %d ofertas", nodoID, len(c.pendientes[nodoID]))
+		}
+	}
+
+	return c, nil
+}
+
+// aplicar deja en la cola la versión más nueva de la oferta.
+func (c *colaHints) aplicar(nodoID string, oferta *pb.OfertaRequest) bool {
+	cola := c.pendientes[nodoID]
+	if cola == nil {
+		cola = make(map[string]*pb.OfertaRequest)
+		c.pendientes[nodoID] = cola
+	}
+	if actual, existe := cola[oferta.GetOfertaId()]; existe && actual.GetVersion() >= oferta.GetVersion() {
+		return false
+	}
+	cola[oferta.GetOfertaId()] = oferta
+	return true
+}
+
+// agregar persiste la oferta en la cola del nodo antes de dejarla en memoria.
+func (c *colaHints) agregar(nodoID string, oferta *pb.OfertaRequest) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !c.aplicar(nodoID, oferta) {
+		return nil
+	}
+
+	file, err := os.OpenFile(c.ruta(nodoID), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	if err := registros.Escribir(file, oferta); err != nil {
+		return err
+	}
+	return file.Sync()
+}
+
+func (c *colaHints) ofertas(nodoID string) []*pb.OfertaRequest {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	ofertas := make([]*pb.OfertaRequest, 0, len(c.pendientes[nodoID]))
+	for _, oferta := range c.pendientes[nodoID] {
+		ofertas = append(ofertas, oferta)
+	}
+	return ofertas
+}
+
+func (c *colaHints) cantidad(nodoID string) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return len(c.pendientes[nodoID])
+}
+
+// confirmar quita de la cola las ofertas entregadas, salvo que mientras tanto
+// haya llegado una versión más nueva, y reescribe el archivo del nodo.
+func (c *colaHints) confirmar(nodoID string, entregadas []*pb.OfertaRequest) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	// Sin cola no hay archivo que reescribir
+	cola := c.pendientes[nodoID]
+	if cola == nil {
+		return nil
+	}
+	for _, oferta := range entregadas {
+		if actual, existe := cola[oferta.GetOfertaId()]; existe && actual.GetVersion() <= oferta.GetVersion() {
+			delete(cola, oferta.GetOfertaId())
+		}
+	}
+	return c.reescribirLocked(nodoID)
+}
+
+// reescribirLocked deja en disco solo lo que queda pendiente, usando un archivo
+// temporal para que una caída a mitad de camino no pierda la cola.
+func (c *colaHints) reescribirLocked(nodoID string) error {
+	cola := c.pendientes[nodoID]
+	if len(cola) == 0 {
+		delete(c.pendientes, nodoID)
+		err := os.Remove(c.ruta(nodoID))
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		return err
+	}
+
+	rutaTmp := c.ruta(nodoID) + ".tmp"
+	tmp, err := os.Create(rutaTmp)
+	if err != nil {
+		return err
+	}
+	writer := bufio.NewWriter(tmp)
+	for _, oferta := range cola {
+		if err := registros.Escribir(writer, oferta); err != nil {
+			tmp.Close()
+			return err
+		}
+	}
+	if err := writer.Flush(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(rutaTmp, c.ruta(nodoID))
+}
+
+// iniciarReproduccion evita que dos goroutines reenvíen la misma cola a la vez.
+func (c *colaHints) iniciarReproduccion(nodoID string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.reproduciendo[nodoID] || len(c.pendientes[nodoID]) == 0 {
+		return false
+	}
+	c.reproduciendo[nodoID] = true
+	return true
+}
+
+func (c *colaHints) terminarReproduccion(nodoID string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	delete(c.reproduciendo, nodoID)
+}
+
+// nodoIDValido indica si el nombre sirve para un nodo. Se usa como nombre del
+// archivo de su cola de hints, así que solo se aceptan letras, dígitos, '-',
+// '_' y '.', sin que pueda salir del directorio de datos.
+func nodoIDValido(nodoID string) bool {
+	if nodoID == "" || nodoID == "." || nodoID == ".." || filepath.Base(nodoID) != nodoID {
+		return false
+	}
+	for _, r := range nodoID {
+		valido := (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') ||
+			r == '-' || r == '_' || r == '.'
+		if !valido {
+			return false
+		}
+	}
+	return true
+}
+
+func (c *colaHints) ruta(nodoID string) string {
+	return filepath.Join(c.dir, nodoID+extensionHints)
+}
+
+// guardarHint encola la oferta para un nodo al que no se le pudo escribir.
+func (b *Broker) guardarHint(nodo *NodoInfo, oferta *pb.OfertaRequest) {
+	if err := b.hints.agregar(nodo.nombre, oferta); err != nil {
+		log.Printf("Error guardando hint de %s para %s: %v", oferta.GetOfertaId(), nodo.nombre, err)
+		return
+	}
+	b.hintsGuardados.Add(1)
+	log.Printf("Hint guardado para %s: %s (%d pendientes)", nodo.nombre, oferta.GetOfertaId(), b.hints.cantidad(nodo.nombre))
+}
+
+// reproducirHints reenvía al nodo las ofertas de su cola. Se detiene en el
+// primer error: el nodo sigue sin estar disponible y se reintentará la próxima
+// vez que vuelva. Retorna las ofertas que alcanzó a entregar.
+func (b *Broker) reproducirHints(nodo *NodoInfo) []*pb.OfertaRequest {
+	if !b.hints.iniciarReproduccion(nodo.nombre) {
+		return nil
+	}
+	defer b.hints.terminarReproduccion(nodo.nombre)
+
+	pendientes := b.hints.ofertas(nodo.nombre)
+	log.Printf("Reenviando %d hints a %s", len(pendientes), nodo.nombre)
+
+	var entregadas []*pb.OfertaRequest
+	for _, oferta := range pendientes {
+		if !b.enviarOfertaANodo(nodo, oferta) {
+			break
+		}
+		entregadas = append(entregadas, oferta)
+	}
+
+	b.confirmarHints(nodo.nombre, entregadas)
+	log.Printf("Hints entregados a %s: %d/%d", nodo.nombre, len(entregadas), len(pendientes))
+	return entregadas
+}
+
+func (b *Broker) confirmarHints(nodoID string, entregadas []*pb.OfertaRequest) bool {
+	if len(entregadas) == 0 {
+		return true
+	}
+	if err := b.hints.confirmar(nodoID, entregadas); err != nil {
+		log.Printf("Error actualizando hints de %s: %v", nodoID, err)
+		return false
+	}
+	b.hintsEntregados.Add(int64(len(entregadas)))
+	return true
+}
+
+// leerRegistros lee las ofertas de un archivo de hints o del log de ofertas.
+// Las ofertas llegan por el servidor gRPC del broker, que acepta mensajes de
+// hasta maxMensajeRaft, así que un registro más largo solo puede venir de un
+// archivo dañado y se descarta desde ahí como una cola corrupta.
+func leerRegistros(ruta string) ([]*pb.OfertaRequest, error) {
+	ofertas, err := leerMensajes(ruta, maxMensajeRaft, func() *pb.OfertaRequest { return &pb.OfertaRequest{} })
+	if errors.Is(err, registros.ErrLargoExcedido) {
+		log.Printf("Archivo %s con %v - descartando", ruta, err)
+		return ofertas, nil
+	}
+	return ofertas, err
+}
+
+// leerMensajes lee los registros de un archivo. Una cola incompleta o corrupta
+// corresponde a una escritura interrumpida y se descarta. Un registro de más
+// de maximo bytes no lo deja una escritura interrumpida: se retorna un error
+// que envuelve registros.ErrLargoExcedido junto con los mensajes anteriores, y
+// quien llama decide qué hacer.
+func leerMensajes[T proto.Message](ruta string, maximo int, nuevo func() T) ([]T, error) {
+	file, err := os.Open(ruta)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	reader := bufio.NewReader(file)
+	var mensajes []T
+	for {
+		mensaje := nuevo()
+		_, err := registros.Leer(reader, mensaje, maximo)
+		if err == io.EOF {
+			return mensajes, nil
+		}
+		if errors.Is(err, registros.ErrLargoExcedido) {
+			return mensajes, fmt.Errorf("archivo %s: %w", ruta, err)
+		}
+		if err != nil {
+			log.Printf("Archivo %s con cola corrupta (%v) - descartando", ruta, err)
+			return mensajes, nil
+		}
+		mensajes = append(mensajes, mensaje)
+	}
+}
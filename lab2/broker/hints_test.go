@@ -0,0 +1,39 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	pb "lab2/broker/proto"
+)
+
+// El nombre de un nodo se usa como archivo de su cola de hints: uno que sale
+// del directorio de datos no se registra ni permite tocar archivos ajenos.
+func TestHintsRechazaNombresDeNodoFueraDelDirectorio(t *testing.T) {
+	b, _ := brokerPrueba(t)
+	ctx := context.Background()
+
+	victima := filepath.Join(t.TempDir(), "victima"+extensionHints)
+	if err := os.WriteFile(victima, []byte("datos"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	relativa, err := filepath.Rel(b.hints.dir, victima)
+	if err != nil {
+		t.Fatal(err)
+	}
+	nodoID := relativa[:len(relativa)-len(extensionHints)]
+
+	for _, nombre := range []string{nodoID, "..", "DB1/DB2", "", "DB 1"} {
+		resp, err := b.RegistrarNodo(ctx, &pb.RegistroNodoRequest{Nombre: nombre, Direccion: "127.0.0.1:1"})
+		if err != nil || resp.GetExito() {
+			t.Fatalf("se registró el nodo %q", nombre)
+		}
+	}
+
+	b.ConfirmarHints(ctx, &pb.ConfirmacionHintsRequest{NodoId: nodoID})
+	if _, err := os.Stat(victima); err != nil {
+		t.Fatalf("la confirmación de hints tocó un archivo fuera del directorio: %v", err)
+	}
+}
@@ -0,0 +1,180 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+
+	"lab2/broker/filtros"
+	pb "lab2/broker/proto"
+)
+
+// formatoJSON usa los nombres del .proto y emite los campos en cero, así las
+// respuestas tienen siempre la misma forma.
+var formatoJSON = protojson.MarshalOptions{UseProtoNames: true, EmitUnpopulated: true}
+
+// iniciarHTTP atiende la API HTTP/JSON del broker en el puerto indicado. Cada
+// ruta llama al mismo método que la RPC equivalente y, como ella, solo se
+// atiende en el líder. El documento OpenAPI queda en /openapi.json y las
+// métricas de Prometheus en /metrics.
+func (b *Broker) iniciarHTTP(puerto int) {
+	rutas := b.rutasHTTP()
+	documento, err := documentoOpenAPI(rutas)
+	if err != nil {
+		log.Fatalf("Error generando el documento OpenAPI: %v", err)
+	}
+
+	mux := http.NewServeMux()
+	for _, ruta := range rutas {
+		mux.HandleFunc(ruta.metodo+" "+ruta.ruta, ruta.atender)
+	}
+	// Cada réplica expone sus propias métricas, sea o no el líder
+	mux.Handle("GET /metrics", promhttp.Handler())
+	mux.HandleFunc("GET /openapi.json", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(documento)
+	})
+
+	servidor := &http.Server{
+		Addr:              fmt.Sprintf(":%d", puerto),
+		Handler:           mux,
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+	go func() {
+		if err := servidor.ListenAndServe(); err != nil {
+			log.Fatalf("Error al iniciar la API HTTP en puerto %d: %v", puerto, err)
+		}
+	}()
+	log.Printf("API HTTP iniciada en puerto :%d", puerto)
+}
+
+// buscarOfertasHTTP atiende GET /ofertas. Los parámetros tienen los nombres de
+// los campos de FiltroOferta y BusquedaRequest; las listas se repiten o van
+// separadas por coma: /ofertas?tiendas=Riploy&categorias=Hogar,Deportes
+// &precio_max=50000&orden=PRECIO_ASC&limite=20
+func (b *Broker) buscarOfertasHTTP(w http.ResponseWriter, r *http.Request) {
+	if !b.verificarLiderHTTP(w, pb.CyberDayService_BuscarOfertas_FullMethodName) {
+		return
+	}
+
+	req, err := busquedaDesdeConsulta(r.URL.Query())
+	if err == nil {
+		_, err = filtros.Compilar(req.GetFiltro())
+	}
+	if err != nil {
+		responderErrorHTTP(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	resp, err := b.BuscarOfertas(r.Context(), req)
+	if err != nil {
+		responderErrorHTTP(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if !resp.GetExito() {
+		responderErrorHTTP(w, http.StatusServiceUnavailable, "no se alcanzó quorum de lectura")
+		return
+	}
+	responderJSON(w, http.StatusOK, resp)
+}
+
+func busquedaDesdeConsulta(consulta url.Values) (*pb.BusquedaRequest, error) {
+	filtro := &pb.FiltroOferta{
+		Tiendas:          listaDeConsulta(consulta, "tiendas"),
+		Categorias:       listaDeConsulta(consulta, "categorias"),
+		ProductoContiene: consulta.Get("producto_contiene"),
+	}
+	req := &pb.BusquedaRequest{Filtro: filtro}
+
+	enteros := []struct {
+		nombre  string
+		destino *int32
+	}{
+		{"precio_min", &filtro.PrecioMin},
+		{"precio_max", &filtro.PrecioMax},
+		{"descuento_min", &filtro.DescuentoMin},
+		{"stock_min", &filtro.StockMin},
+		{"limite", &req.Limite},
+	}
+	for _, entero := range enteros {
+		valor := consulta.Get(entero.nombre)
+		if valor == "" {
+			continue
+		}
+		numero, err := strconv.ParseInt(valor, 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("%s inválido: %s", entero.nombre, valor)
+		}
+		*entero.destino = int32(numero)
+	}
+
+	if valor := consulta.Get("orden"); valor != "" {
+		orden, existe := pb.OrdenBusqueda_value[strings.ToUpper(valor)]
+		if !existe {
+			return nil, fmt.Errorf("orden desconocido: %s", valor)
+		}
+		req.Orden = pb.OrdenBusqueda(orden)
+	}
+	if valor := consulta.Get("incluir_no_vigentes"); valor != "" {
+		incluir, err := strconv.ParseBool(valor)
+		if err != nil {
+			return nil, fmt.Errorf("incluir_no_vigentes inválido: %s", valor)
+		}
+		req.IncluirNoVigentes = incluir
+	}
+	return req, nil
+}
+
+func listaDeConsulta(consulta url.Values, nombre string) []string {
+	var lista []string
+	for _, valor := range consulta[nombre] {
+		for _, elemento := range strings.Split(valor, ",") {
+			if elemento = strings.TrimSpace(elemento); elemento != "" {
+				lista = append(lista, elemento)
+			}
+		}
+	}
+	return lista
+}
+
+// verificarLiderHTTP responde 503 en una réplica que no es el líder, con la
+// dirección gRPC del líder en el encabezado Lider.
+func (b *Broker) verificarLiderHTTP(w http.ResponseWriter, metodo string) bool {
+	trailer, err := b.verificarLider(metodo)
+	if err == nil {
+		return true
+	}
+	if lider := trailer.Get(claveLider); len(lider) > 0 {
+		w.Header().Set("Lider", lider[0])
+	}
+	responderErrorHTTP(w, http.StatusServiceUnavailable, "esta réplica del broker no es el líder")
+	return false
+}
+
+func responderJSON(w http.ResponseWriter, codigo int, mensaje proto.Message) {
+	datos, err := formatoJSON.Marshal(mensaje)
+	if err != nil {
+		responderErrorHTTP(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(codigo)
+	w.Write(datos)
+}
+
+func responderErrorHTTP(w http.ResponseWriter, codigo int, mensaje string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(codigo)
+	codificador := json.NewEncoder(w)
+	codificador.SetEscapeHTML(false)
+	codificador.Encode(map[string]string{"error": mensaje})
+}
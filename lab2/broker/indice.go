@@ -0,0 +1,180 @@
+package main
+
+import (
+	"slices"
+	"sort"
+
+	pb "lab2/broker/proto"
+)
+
+// cualquiera es la clave del índice para un consumidor que no restringe la
+// categoría o la tienda. No choca con nombres reales porque es vacía.
+const cualquiera = ""
+
+type claveIndice struct {
+	categoria string
+	tienda    string
+}
+
+// grupoIndice son los consumidores de una misma clave: los que tienen precio
+// máximo ordenados por ese precio y los que no tienen límite aparte.
+type grupoIndice struct {
+	porPrecio []*ConsumidorInfo
+	sinPrecio map[string]*ConsumidorInfo
+}
+
+// indiceSuscripciones permite encontrar los consumidores que pueden interesarse
+// en una oferta sin recorrerlos todos. Indexa las condiciones de primer nivel
+// del filtro, que siempre deben cumplirse: cada consumidor queda en un grupo
+// por cada par (categoría, tienda) que acepta, usando cualquiera cuando no
+// restringe. Para una oferta basta mirar cuatro grupos y, dentro de cada uno,
+// solo los consumidores cuyo precio máximo alcanza el precio de la oferta. Al
+// resto de las condiciones se le aplica el filtro completo. Lo protege
+// registroMu.
+type indiceSuscripciones struct {
+	grupos       map[claveIndice]*grupoIndice
+	consumidores map[string]*ConsumidorInfo
+}
+
+func nuevoIndiceSuscripciones() *indiceSuscripciones {
+	return &indiceSuscripciones{
+		grupos:       make(map[claveIndice]*grupoIndice),
+		consumidores: make(map[string]*ConsumidorInfo),
+	}
+}
+
+// agregar indexa al consumidor, reemplazando una versión anterior con el mismo ID.
+func (i *indiceSuscripciones) agregar(consumidor *ConsumidorInfo) {
+	i.quitar(consumidor.id_consumidor)
+	i.consumidores[consumidor.id_consumidor] = consumidor
+
+	for _, clave := range clavesConsumidor(consumidor) {
+		grupo := i.grupos[clave]
+		if grupo == nil {
+			grupo = &grupoIndice{sinPrecio: make(map[string]*ConsumidorInfo)}
+			i.grupos[clave] = grupo
+		}
+		grupo.agregar(consumidor)
+	}
+}
+
+func (i *indiceSuscripciones) quitar(consumidorID string) {
+	consumidor, existe := i.consumidores[consumidorID]
+	if !existe {
+		return
+	}
+	delete(i.consumidores, consumidorID)
+
+	for _, clave := range clavesConsumidor(consumidor) {
+		grupo := i.grupos[clave]
+		if grupo == nil {
+			continue
+		}
+		grupo.quitar(consumidor)
+		if len(grupo.porPrecio) == 0 && len(grupo.sinPrecio) == 0 {
+			delete(i.grupos, clave)
+		}
+	}
+}
+
+// candidatos retorna los consumidores cuyo filtro acepta la oferta. Cada
+// consumidor aparece en a lo más uno de los cuatro grupos consultados, así que
+// no hay repetidos. Si la oferta viene sin categoría o tienda algunas claves
+// coinciden y se consultan una sola vez.
+func (i *indiceSuscripciones) candidatos(oferta *pb.OfertaRequest) []*ConsumidorInfo {
+	claves := []claveIndice{
+		{oferta.GetCategoria(), oferta.GetTienda()},
+		{oferta.GetCategoria(), cualquiera},
+		{cualquiera, oferta.GetTienda()},
+		{cualquiera, cualquiera},
+	}
+
+	var resultado []*ConsumidorInfo
+	for k, clave := range claves {
+		grupo := i.grupos[clave]
+		if grupo == nil || slices.Contains(claves[:k], clave) {
+			continue
+		}
+
+		// Los que aceptan el precio de la oferta son un sufijo de la lista ordenada
+		desde := sort.Search(len(grupo.porPrecio), func(k int) bool {
+			return grupo.porPrecio[k].obtenerFiltro().Proto().GetPrecioMax() >= oferta.GetPrecio()
+		})
+		for _, consumidor := range grupo.porPrecio[desde:] {
+			if consumidor.obtenerFiltro().Coincide(oferta) {
+				resultado = append(resultado, consumidor)
+			}
+		}
+		for _, consumidor := range grupo.sinPrecio {
+			if consumidor.obtenerFiltro().Coincide(oferta) {
+				resultado = append(resultado, consumidor)
+			}
+		}
+	}
+	return resultado
+}
+
+func (g *grupoIndice) agregar(consumidor *ConsumidorInfo) {
+	if consumidor.obtenerFiltro().Proto().GetPrecioMax() <= 0 {
+		g.sinPrecio[consumidor.id_consumidor] = consumidor
+		return
+	}
+
+	pos := g.posicion(consumidor)
+	g.porPrecio = append(g.porPrecio, nil)
+	copy(g.porPrecio[pos+1:], g.porPrecio[pos:])
+	g.porPrecio[pos] = consumidor
+}
+
+func (g *grupoIndice) quitar(consumidor *ConsumidorInfo) {
+	if consumidor.obtenerFiltro().Proto().GetPrecioMax() <= 0 {
+		delete(g.sinPrecio, consumidor.id_consumidor)
+		return
+	}
+
+	pos := g.posicion(consumidor)
+	if pos < len(g.porPrecio) && g.porPrecio[pos] == consumidor {
+		g.porPrecio = append(g.porPrecio[:pos], g.porPrecio[pos+1:]...)
+	}
+}
+
+// posicion busca dónde va el consumidor en porPrecio, ordenado por precio
+// máximo y luego por ID.
+func (g *grupoIndice) posicion(consumidor *ConsumidorInfo) int {
+	precioMax := consumidor.obtenerFiltro().Proto().GetPrecioMax()
+	return sort.Search(len(g.porPrecio), func(k int) bool {
+		otro := g.porPrecio[k]
+		otroPrecio := otro.obtenerFiltro().Proto().GetPrecioMax()
+		if otroPrecio != precioMax {
+			return otroPrecio > precioMax
+		}
+		return otro.id_consumidor >= consumidor.id_consumidor
+	})
+}
+
+// clavesConsumidor retorna los pares (categoría, tienda) en que se indexa el
+// consumidor, sin repetidos.
+func clavesConsumidor(consumidor *ConsumidorInfo) []claveIndice {
+	filtro := consumidor.obtenerFiltro().Proto()
+	categorias := filtro.GetCategorias()
+	if len(categorias) == 0 {
+		categorias = []string{cualquiera}
+	}
+	tiendas := filtro.GetTiendas()
+	if len(tiendas) == 0 {
+		tiendas = []string{cualquiera}
+	}
+
+	vistas := make(map[claveIndice]bool)
+	var claves []claveIndice
+	for _, categoria := range categorias {
+		for _, tienda := range tiendas {
+			clave := claveIndice{categoria, tienda}
+			if !vistas[clave] {
+				vistas[clave] = true
+				claves = append(claves, clave)
+			}
+		}
+	}
+	return claves
+}
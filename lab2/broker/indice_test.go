@@ -0,0 +1,284 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"math/rand"
+	"os"
+	"slices"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+
+	"lab2/broker/filtros"
+	pb "lab2/broker/proto"
+)
+
+var (
+	categoriasPrueba = []string{"Automotriz", "Belleza", "Computación", "Deportes", "Electrodomésticos", "Electrónica",
+		"Herramientas", "Hogar", "Infantil", "Juguetes", "Mascotas", "Moda"}
+	tiendasPrueba = []string{"Riploy", "Falabellox", "Parisio"}
+)
+
+// elegirAlgunos retorna de 1 a hasta valores al azar, o ninguno (que no
+// restringe) una de cada cuatro veces.
+func elegirAlgunos(r *rand.Rand, valores []string, hasta int) []string {
+	if r.Intn(4) == 0 {
+		return nil
+	}
+	var elegidos []string
+	for range 1 + r.Intn(hasta) {
+		if valor := valores[r.Intn(len(valores))]; !slices.Contains(elegidos, valor) {
+			elegidos = append(elegidos, valor)
+		}
+	}
+	return elegidos
+}
+
+// consumidoresSinteticos arma consumidores con filtros al azar, parecidos a los
+// de consumidores.csv: la mayoría restringe categoría, tienda y precio máximo,
+// y algunos agregan descuento o subfiltros.
+func consumidoresSinteticos(tb testing.TB, cantidad int) []*ConsumidorInfo {
+	tb.Helper()
+
+	r := rand.New(rand.NewSource(1))
+	consumidores := make([]*ConsumidorInfo, cantidad)
+	for i := range consumidores {
+		f := &pb.FiltroOferta{
+			Categorias: elegirAlgunos(r, categoriasPrueba, 3),
+			Tiendas:    elegirAlgunos(r, tiendasPrueba, 2),
+		}
+		if r.Intn(5) > 0 {
+			f.PrecioMax = []int32{20000, 50000, 100000, 200000, 300000}[r.Intn(5)]
+		}
+		if r.Intn(4) == 0 {
+			f.DescuentoMin = int32(r.Intn(50))
+		}
+		if r.Intn(10) == 0 {
+			f.Operador = pb.OperadorFiltro_O
+			f.Subfiltros = []*pb.FiltroOferta{{ProductoContiene: "eco"}, {StockMin: 100}}
+		}
+
+		filtro, err := filtros.Compilar(f)
+		if err != nil {
+			tb.Fatal(err)
+		}
+		consumidores[i] = &ConsumidorInfo{id_consumidor: fmt.Sprintf("C%d", i)}
+		consumidores[i].filtro.Store(filtro)
+	}
+	return consumidores
+}
+
+func ofertasSinteticas(cantidad int) []*pb.OfertaRequest {
+	r := rand.New(rand.NewSource(2))
+	ofertas := make([]*pb.OfertaRequest, cantidad)
+	for i := range ofertas {
+		categoria := categoriasPrueba[r.Intn(len(categoriasPrueba))]
+		ofertas[i] = &pb.OfertaRequest{
+			OfertaId:  fmt.Sprintf("oferta-%d", i),
+			Tienda:    tiendasPrueba[r.Intn(len(tiendasPrueba))],
+			Categoria: categoria,
+			Producto:  categoria + []string{" Eco", " XL", " Pro"}[r.Intn(3)],
+			Precio:    int32(5000 + r.Intn(1000000)),
+			Descuento: int32(r.Intn(60)),
+			Stock:     int32(r.Intn(200)),
+		}
+	}
+	return ofertas
+}
+
+func indicePrueba(consumidores []*ConsumidorInfo) *indiceSuscripciones {
+	indice := nuevoIndiceSuscripciones()
+	for _, consumidor := range consumidores {
+		indice.agregar(consumidor)
+	}
+	return indice
+}
+
+// recorridoLineal es la forma de elegir destinatarios anterior al índice.
+func recorridoLineal(b *Broker, consumidores []*ConsumidorInfo, oferta *pb.OfertaRequest) []*ConsumidorInfo {
+	var resultado []*ConsumidorInfo
+	for _, consumidor := range consumidores {
+		if b.coincideConPreferencias(oferta, consumidor) {
+			resultado = append(resultado, consumidor)
+		}
+	}
+	return resultado
+}
+
+func idsOrdenados(consumidores []*ConsumidorInfo) []string {
+	ids := make([]string, len(consumidores))
+	for i, consumidor := range consumidores {
+		ids[i] = consumidor.id_consumidor
+	}
+	slices.Sort(ids)
+	return ids
+}
+
+func TestIndiceCoincideConRecorridoLineal(t *testing.T) {
+	b := &Broker{}
+	consumidores := consumidoresSinteticos(t, 5000)
+	indice := indicePrueba(consumidores)
+
+	// Quitar y volver a agregar no debe dejar restos en los grupos
+	for _, consumidor := range consumidores[:500] {
+		indice.quitar(consumidor.id_consumidor)
+	}
+	for _, consumidor := range consumidores[:250] {
+		indice.agregar(consumidor)
+	}
+	vigentes := append(slices.Clone(consumidores[:250]), consumidores[500:]...)
+
+	for _, oferta := range append(ofertasSinteticas(200), &pb.OfertaRequest{OfertaId: "sin-categoria", Tienda: "Riploy", Precio: 1000}) {
+		esperados := idsOrdenados(recorridoLineal(b, vigentes, oferta))
+		if got := idsOrdenados(indice.candidatos(oferta)); !slices.Equal(got, esperados) {
+			t.Fatalf("oferta %s: el índice entregó %d consumidores, el recorrido %d", oferta.GetOfertaId(), len(got), len(esperados))
+		}
+	}
+}
+
+func BenchmarkDestinatarios(b *testing.B) {
+	ofertas := ofertasSinteticas(1000)
+	for _, cantidad := range []int{10000, 50000} {
+		consumidores := consumidoresSinteticos(b, cantidad)
+		indice := indicePrueba(consumidores)
+		broker := &Broker{}
+
+		b.Run(fmt.Sprintf("indice/%d", cantidad), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				indice.candidatos(ofertas[i%len(ofertas)])
+			}
+		})
+		b.Run(fmt.Sprintf("lineal/%d", cantidad), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				recorridoLineal(broker, consumidores, ofertas[i%len(ofertas)])
+			}
+		})
+	}
+}
+
+// streamPrueba es el lado del broker de un stream de Suscribir: solo cuenta
+// las ofertas que se le envían.
+type streamPrueba struct {
+	grpc.ServerStream
+	ctx       context.Context
+	recibidas atomic.Int64
+}
+
+func (s *streamPrueba) Send(notificacion *pb.NotificacionOferta) error {
+	if !notificacion.GetLatido() {
+		s.recibidas.Add(1)
+	}
+	return nil
+}
+
+func (s *streamPrueba) Context() context.Context {
+	return s.ctx
+}
+
+// suscribirSinteticos abre un stream de Suscribir por consumidor, con su
+// filtro, y espera a que todos queden registrados.
+func suscribirSinteticos(tb testing.TB, b *Broker, consumidores []*ConsumidorInfo) []*streamPrueba {
+	tb.Helper()
+
+	ctx, cancelar := context.WithCancel(context.Background())
+	tb.Cleanup(cancelar)
+	streams := make([]*streamPrueba, len(consumidores))
+	for i, consumidor := range consumidores {
+		streams[i] = &streamPrueba{ctx: ctx}
+		req := &pb.SuscripcionRequest{ConsumidorId: consumidor.id_consumidor, Filtro: consumidor.obtenerFiltro().Proto()}
+		go b.Suscribir(req, streams[i])
+	}
+
+	limite := time.Now().Add(30 * time.Second)
+	for {
+		b.registroMu.RLock()
+		registrados := len(b.consumidores)
+		b.registroMu.RUnlock()
+		if registrados == len(consumidores) {
+			return streams
+		}
+		if time.Now().After(limite) {
+			tb.Fatalf("solo %d de %d streams se registraron", registrados, len(consumidores))
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+// Cada stream recibe exactamente las ofertas que acepta su filtro, tanto las
+// del log anteriores a la suscripción como las que se le encolan después.
+func TestSuscribirEntregaLoQueCoincide(t *testing.T) {
+	b, _ := brokerPrueba(t)
+	consumidores := consumidoresSinteticos(t, 300)
+	ofertas := ofertasSinteticas(200)
+
+	for _, oferta := range ofertas[:100] {
+		b.agregarAlLog(oferta)
+	}
+	streams := suscribirSinteticos(t, b, consumidores)
+	for _, oferta := range ofertas[100:] {
+		b.agregarAlLog(oferta)
+	}
+
+	for i, consumidor := range consumidores {
+		esperadas := int64(len(recorridoLinealOfertas(consumidor, ofertas)))
+		esperarCondicion(t, fmt.Sprintf("%s recibe %d ofertas", consumidor.id_consumidor, esperadas), func() bool {
+			return streams[i].recibidas.Load() >= esperadas
+		})
+		if got := streams[i].recibidas.Load(); got != esperadas {
+			t.Fatalf("%s recibió %d ofertas, se esperaban %d", consumidor.id_consumidor, got, esperadas)
+		}
+	}
+}
+
+func recorridoLinealOfertas(consumidor *ConsumidorInfo, ofertas []*pb.OfertaRequest) []*pb.OfertaRequest {
+	var resultado []*pb.OfertaRequest
+	for _, oferta := range ofertas {
+		if consumidor.obtenerFiltro().Coincide(oferta) {
+			resultado = append(resultado, oferta)
+		}
+	}
+	return resultado
+}
+
+// BenchmarkSuscribirEntrega mide el camino real de una oferta: publicarla en
+// el log y entregarla por Suscribir a cada stream cuyo filtro la acepta. Cada
+// iteración es una oferta, y el tiempo incluye esperar todas las entregas.
+func BenchmarkSuscribirEntrega(b *testing.B) {
+	log.SetOutput(io.Discard)
+	defer log.SetOutput(os.Stderr)
+
+	ofertas := ofertasSinteticas(1000)
+	for _, cantidad := range []int{1000, 10000} {
+		b.Run(fmt.Sprint(cantidad), func(b *testing.B) {
+			broker, _ := brokerPrueba(b)
+			consumidores := consumidoresSinteticos(b, cantidad)
+			destinatarios := make([]int64, len(ofertas))
+			for i, oferta := range ofertas {
+				destinatarios[i] = int64(len(recorridoLineal(broker, consumidores, oferta)))
+			}
+			streams := suscribirSinteticos(b, broker, consumidores)
+
+			b.ResetTimer()
+			var esperadas int64
+			for i := 0; i < b.N; i++ {
+				broker.agregarAlLog(ofertas[i%len(ofertas)])
+				esperadas += destinatarios[i%len(ofertas)]
+			}
+			for {
+				var recibidas int64
+				for _, stream := range streams {
+					recibidas += stream.recibidas.Load()
+				}
+				if recibidas >= esperadas {
+					break
+				}
+				time.Sleep(100 * time.Microsecond)
+			}
+		})
+	}
+}
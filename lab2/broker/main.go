@@ -8,108 +8,166 @@ import(
 	"time"
 	"fmt"
 	"os"
+	"path/filepath"
 	"bufio"
+	"slices"
 	"strings"
+	"sync/atomic"
 	
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/protobuf/proto"
 
+	"lab2/broker/filtros"
 	pb "lab2/broker/proto"
 )
 
+// Broker no tiene un lock global: registroMu protege solo los mapas de entidades,
+// los contadores son atómicos y cada nodo/consumidor tiene su propio lock de
+// estado. Ninguna llamada de red se hace con registroMu tomado.
 type Broker struct {
 	pb.UnimplementedCyberDayServiceServer
+	registroMu          sync.RWMutex
 	productores  		map[string]*ProductorInfo
 	nodos        		map[string]*NodoInfo
 	consumidores 		map[string]*ConsumidorInfo
-	mu           		sync.Mutex
-	ofertasRecibidas 	int	
-	escriturasExitosas 	int 
-	escriturasFallidas  int
-	inicio 				bool
-	sistemaActivo		bool 
+	indice              *indiceSuscripciones
+	anillo              *anilloConsistente
+	ofertasRecibidas 	atomic.Int64
+	escriturasExitosas 	atomic.Int64
+	escriturasFallidas  atomic.Int64
+	ofertasReparadas    atomic.Int64
+	reparacionesFallidas atomic.Int64
+	latidos             ConfigLatidos
+	dedup               *ventanaDedup
+	hints               *colaHints
+	hintsGuardados      atomic.Int64
+	hintsEntregados     atomic.Int64
+	rondasAntiEntropia  atomic.Int64
+	bucketsDivergentes  atomic.Int64
+	ofertasAntiEntropia atomic.Int64
+	stockActualizado    atomic.Int64
+	ofertasAgotadas     atomic.Int64
+	ofertasRetiradas    atomic.Int64
+	ofertasExpiradas    atomic.Int64
+	bloqueos            bloqueosOferta
+	ttlOfertas          time.Duration
+	inicio 				atomic.Bool
+	sistemaActivo		atomic.Bool
+	quorum              ConfigQuorum
+	membresia           ConfigMembresia
+	logOfertas          *logOfertas
+	publicacionMu       sync.Mutex
+	estado              *almacenEstado
+	raft                *nodoRaft
+	replicadoMu         sync.Mutex
+	estadoReplicado     []byte
+	ultimoEstadoPropuesto []byte
+	reloj               relojLamport
+	secuencias          relojLamport
 }
 
 type ProductorInfo struct {
     nombre 				string
-    ofertasEnviadas 	int
-    ofertasAceptadas 	int
+    ofertasEnviadas 	atomic.Int64
+    ofertasAceptadas 	atomic.Int64
+    ofertasDuplicadas 	atomic.Int64
+    reintentos 			atomic.Int64
 }
 
 type NodoInfo struct {
+	estadoEntidad
 	nombre            string
 	direccion         string
-	estado         	  bool
-	cantCaidas   	  int
+	conn              *grpc.ClientConn
 	client            pb.CyberDayServiceClient 
+	salud             healthpb.HealthClient
+	ofertasReparadas  atomic.Int64
+}
+
+type resultadoEscritura struct {
+	nodoID string
+	exito  bool
 }
 
 type ConsumidorInfo struct {
+	estadoEntidad
 	id_consumidor 		string
-	categorias    		[]string
-	tiendas       		[]string
-	precio_max    		int32
-	direccion 	  		string
-	estado 				bool
-	ofertasRecibidas 	int 
+	filtro        		atomic.Pointer[filtros.Filtro]
+	ofertasRecibidas 	atomic.Int64
     archivoCSV 			string
-    cantCaidas 			int
-	client            pb.CyberDayServiceClient 
+	cancelar            context.CancelFunc
+	// Solo los suscritos por stream tienen cola
+	cola                *colaSuscriptor
 }
 
-const (
-	N = 3
-	W = 2 
-	R = 2 
-)
-
 var categoriasValidas = []string{
 	"Electrónica", "Moda", "Hogar", "Deportes", "Belleza", "Infantil",
 	"Computación", "Electrodomésticos", "Herramientas", "Juguetes", 
 	"Automotriz", "Mascotas",
 }
 
-var tiendasValidas = []string{
-	"Riploy", "Falabellox", "Parisio",
-}
-
-var nodosValidos = []string{
-	"DB1", "DB2", "DB3",
-}
+func NewBroker(config Configuracion) (*Broker, error) {
+	hints, err := abrirColaHints(filepath.Join(config.DirDatos, "hints"))
+	if err != nil {
+		return nil, err
+	}
+	// Con réplicas, el log de ofertas y el registro se reconstruyen desde Raft
+	dirLog := config.DirDatos
+	if config.Replicacion.habilitada() {
+		dirLog = ""
+	}
+	logOfertas, err := abrirLogOfertas(dirLog)
+	if err != nil {
+		return nil, err
+	}
 
-func NewBroker() *Broker {
-	return &Broker{
+	b := &Broker{
 		productores: 		make(map[string]*ProductorInfo),
 		nodos:      		make(map[string]*NodoInfo),
 		consumidores: 		make(map[string]*ConsumidorInfo),
-		ofertasRecibidas: 	0,
-		escriturasExitosas: 0,
-		escriturasFallidas: 0,
-		inicio: 			false,
-		sistemaActivo:		true,
+		indice:             nuevoIndiceSuscripciones(),
+		anillo:             nuevoAnilloConsistente(config.NodosVirtuales),
+		quorum:             config.Quorum,
+		membresia:          config.Membresia,
+		latidos:            config.Latidos,
+		ttlOfertas:         time.Duration(config.TTLOfertasSeg) * time.Second,
+		dedup:              nuevaVentanaDedup(config.VentanaDedup),
+		logOfertas:         logOfertas,
+		hints:              hints,
+		estado:             nuevoAlmacenEstado(config.DirDatos),
+	}
+	if config.Replicacion.habilitada() {
+		if err := b.iniciarReplicacion(config); err != nil {
+			return nil, err
+		}
+	} else if err := b.restaurarEstado(); err != nil {
+		return nil, err
 	}
+	b.sistemaActivo.Store(true)
+	return b, nil
 }
 
 func (b *Broker) RegistrarProductor(ctx context.Context, req *pb.RegistroProductorRequest) (*pb.RegistroResponse, error) {
-	b.mu.Lock()
-	defer b.mu.Unlock()
+	b.registroMu.Lock()
+	defer b.registroMu.Unlock()
 
 	nombre := req.GetNombre()
 
-	if !esValido(nombre, tiendasValidas) {
-		log.Printf("Productor no válido: %s", nombre)
+	if nombre == "" {
+		log.Printf("Productor sin nombre")
 		return &pb.RegistroResponse{Exito: false}, nil
 	}
 
+	// Un productor que ya estaba registrado se reincorpora con sus contadores
 	if _, existe := b.productores[nombre]; existe {
-		log.Printf("Productor %s ya registrado", nombre)
-		return &pb.RegistroResponse{Exito: false}, nil
+		log.Printf("Productor %s se reincorporó", nombre)
+		return &pb.RegistroResponse{Exito: true}, nil
 	}
 
 	b.productores[nombre] = &ProductorInfo{
 		nombre: 			nombre,
-		ofertasEnviadas: 	0,
-		ofertasAceptadas: 	0,
 	}
 
 	log.Printf("Productor registrado: %s", nombre)
@@ -118,127 +176,151 @@ func (b *Broker) RegistrarProductor(ctx context.Context, req *pb.RegistroProduct
 }
 
 func (b *Broker) RegistrarNodo(ctx context.Context, req *pb.RegistroNodoRequest) (*pb.RegistroResponse, error) {
-	b.mu.Lock()
-	defer b.mu.Unlock()
-
 	nodoID := req.GetNombre()
 
-	if !esValido(nodoID, nodosValidos) {
-		log.Printf("Nodo %s no válido", nodoID)
-		return &pb.RegistroResponse{Exito: false}, nil
-	}
-
-	if _, existe := b.nodos[nodoID]; existe {
-		log.Printf("Nodo %s ya registrado", nodoID)
+	if !nodoIDValido(nodoID) {
+		log.Printf("Nombre de nodo inválido: %q", nodoID)
 		return &pb.RegistroResponse{Exito: false}, nil
 	}
 
+	// La conexión se crea fuera del lock del registro
 	conn, err := grpc.Dial(req.GetDireccion(), grpc.WithTransportCredentials(insecure.NewCredentials()))
 	if err != nil {
 		log.Printf("No se pudo conectar a %s: %v", nodoID, err)
 		return &pb.RegistroResponse{Exito: false}, nil
 	}
 
-	client := pb.NewCyberDayServiceClient(conn)
+	b.registroMu.Lock()
+	defer b.registroMu.Unlock()
 
-	b.nodos[nodoID] = &NodoInfo{
+	nodo := &NodoInfo{
 		nombre:            nodoID,
 		direccion:         req.GetDireccion(),
-		estado:            true,
-		cantCaidas: 	   0,
-		client:            client,
+		conn:              conn,
+		client:            pb.NewCyberDayServiceClient(conn),
+		salud:             healthpb.NewHealthClient(conn),
+	}
+
+	// Un nodo que se reinicia vuelve a registrarse: se reemplaza su conexión y
+	// se conserva su historia para el reporte
+	if anterior, existe := b.nodos[nodoID]; existe {
+		nodo.heredarSalud(&anterior.estadoEntidad)
+		nodo.registrarLatido()
+		nodo.ofertasReparadas.Store(anterior.ofertasReparadas.Load())
+		anterior.conn.Close()
+		log.Printf("Nodo %s se reincorporó en %s", nodoID, req.GetDireccion())
+	} else {
+		nodo.iniciarSalud()
+		log.Printf("Nodo %s registrado en %s", nodoID, req.GetDireccion())
 	}
+	b.nodos[nodoID] = nodo
+	b.anillo.agregar(nodoID)
 
-	log.Printf("Nodo %s registrado en %s", nodoID, req.GetDireccion())
 	b.verificarInicio()
+	b.recuperarNodo(nodo)
 	return &pb.RegistroResponse{Exito: true}, nil
 }
 
+// RegistrarConsumidor da de alta al consumidor con su filtro antes de que abra
+// su stream. El broker no se conecta al consumidor: las ofertas le llegan solo
+// por Suscribir, desde el offset que indique.
 func (b *Broker) RegistrarConsumidor(ctx context.Context, req *pb.RegistroConsumidorRequest) (*pb.RegistroResponse, error) {
-	b.mu.Lock()
-	defer b.mu.Unlock()
-
 	consumidorID := req.GetConsumidorId()
-
-	if _, existe := b.consumidores[consumidorID]; existe {
-		log.Printf("Consumidor %s ya registrado", consumidorID)
+	if consumidorID == "" {
+		log.Printf("Consumidor sin ID")
 		return &pb.RegistroResponse{Exito: false}, nil
 	}
 
-	conn, err := grpc.Dial(req.GetDireccion(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	filtro, err := compilarFiltro(req.GetFiltro(), req.GetCategorias(), req.GetTiendas(), req.GetPrecioMax())
 	if err != nil {
-		log.Printf("No se pudo conectar a consumidor %s: %v", consumidorID, err)
+		log.Printf("Filtro inválido para consumidor %s: %v", consumidorID, err)
 		return &pb.RegistroResponse{Exito: false}, nil
 	}
 
-	client := pb.NewCyberDayServiceClient(conn)
+	b.registroMu.Lock()
+	defer b.registroMu.Unlock()
 
-	b.consumidores[consumidorID] = &ConsumidorInfo{
+	consumidor := &ConsumidorInfo{
 		id_consumidor: 		consumidorID,
-		categorias:    		req.GetCategorias(),
-		tiendas:       		req.GetTiendas(),
-		precio_max:    		req.GetPrecioMax(),
-		direccion:     		req.GetDireccion(),
-		estado:            	true,
-		ofertasRecibidas: 	0,
     	archivoCSV: 		fmt.Sprintf("consumidor_%s.csv", consumidorID),
-    	cantCaidas: 		0,
-		client:          	client,
 	}
+	consumidor.filtro.Store(filtro)
+
+	if anterior, existe := b.consumidores[consumidorID]; existe {
+		consumidor.heredarSalud(&anterior.estadoEntidad)
+		consumidor.registrarLatido()
+		consumidor.ofertasRecibidas.Store(anterior.ofertasRecibidas.Load())
+		anterior.cerrar()
+		log.Printf("Consumidor %s se reincorporó", consumidorID)
+	} else {
+		consumidor.iniciarSalud()
+		log.Printf("Consumidor %s registrado", consumidorID)
+	}
+	b.consumidores[consumidorID] = consumidor
+	b.indice.agregar(consumidor)
 
-	log.Printf("Consumidor %s registrado en %s", consumidorID, req.GetDireccion())
-	log.Printf("-Categorías: %v", req.GetCategorias())
-	log.Printf("-Tiendas: %v", req.GetTiendas())
-	log.Printf("-Precio Máx: %d", req.GetPrecioMax())
+	log.Printf("-Filtro: %s", filtro)
 	b.verificarInicio()
 	return &pb.RegistroResponse{Exito: true}, nil
 }
 
-func (b *Broker) verificarInicio() {
-	registrados := len(b.productores) + len(b.nodos) + len(b.consumidores)
-	
-	if registrados == 18 && !b.inicio {
-		b.inicio = true
-		log.Printf("Sistema listo: %d/18 entidades registradas", registrados)
-	} else {
-		log.Printf("Estado -> Productores: %d, Nodos: %d, Consumidores: %d", 
-		len(b.productores), len(b.nodos), len(b.consumidores))
-		log.Printf("Sistema en proceso: %d/18 entidades registradas", registrados)
-	}
-}
-
 func (b *Broker) SolicitarInicio(ctx context.Context, req *pb.InicioRequest) (*pb.InicioResponse, error) {
-	b.mu.Lock()
-	defer b.mu.Unlock()
-
 	return &pb.InicioResponse{
-		Inicio: b.inicio,
+		Inicio: b.inicio.Load(),
 	}, nil
 }
 
 func (b *Broker) EnviarOferta(ctx context.Context, req *pb.OfertaRequest) (*pb.OfertaResponse, error) {
-	b.mu.Lock()
-	defer b.mu.Unlock()
-
 	tienda := req.GetTienda()
+
+	b.registroMu.RLock()
 	prod, existe := b.productores[tienda]
+	b.registroMu.RUnlock()
 
 	if !existe {
+		// La tienda no va como etiqueta: podría ser cualquier texto
+		metricaOfertasRechazadas.WithLabelValues("", "productor_no_registrado").Inc()
+		return &pb.OfertaResponse{Exito: false}, nil
+	}
+	metricaOfertasRecibidas.WithLabelValues(tienda).Inc()
+
+	// Un reintento de una oferta ya confirmada se reconoce sin volver a
+	// almacenarla ni notificarla
+	switch b.dedup.iniciar(req.GetOfertaId()) {
+	case ofertaConfirmada:
+		prod.ofertasDuplicadas.Add(1)
+		log.Printf("Oferta %s ya confirmada - reintento reconocido", req.GetOfertaId())
+		return &pb.OfertaResponse{Exito: true}, nil
+	case ofertaEnProceso:
+		prod.ofertasDuplicadas.Add(1)
+		log.Printf("Oferta %s todavía en proceso - reintento rechazado", req.GetOfertaId())
+		metricaOfertasRechazadas.WithLabelValues(tienda, "en_proceso").Inc()
 		return &pb.OfertaResponse{Exito: false}, nil
+	case ofertaReintento:
+		prod.reintentos.Add(1)
+		log.Printf("Reintento de oferta %s que no había alcanzado quorum", req.GetOfertaId())
 	}
 
-	prod.ofertasEnviadas++
+	prod.ofertasEnviadas.Add(1)
 
 	categoria := req.GetCategoria()
 	if !esValido(categoria, categoriasValidas) {
 		log.Printf("Oferta rechazada: La categoría es inválida: %s", categoria)
+		metricaOfertasRechazadas.WithLabelValues(tienda, "categoria_invalida").Inc()
+		b.dedup.terminar(req.GetOfertaId(), false)
 		return &pb.OfertaResponse{Exito: false}, nil
 	}
 
-	prod.ofertasAceptadas++
-	b.ofertasRecibidas++
+	prod.ofertasAceptadas.Add(1)
+	numOferta := b.ofertasRecibidas.Add(1)
+	req.Version = b.reloj.recibir(req.GetVersion())
+	req.Secuencia = b.secuencias.recibir(0)
+	req.Estado = pb.EstadoOferta_VIGENTE
+	if b.ttlOfertas > 0 && req.GetExpira() == 0 {
+		req.Expira = time.Now().Add(b.ttlOfertas).Unix()
+	}
 
-	log.Printf("Oferta #%d recibida", b.ofertasRecibidas)
+	log.Printf("Oferta #%d recibida (versión %d)", numOferta, req.GetVersion())
 	log.Printf("-Tienda: %s", tienda)
 	log.Printf("-Producto: %s", req.GetProducto())
 	log.Printf("-Categoría: %s", req.GetCategoria())
@@ -248,43 +330,94 @@ func (b *Broker) EnviarOferta(ctx context.Context, req *pb.OfertaRequest) (*pb.O
 
 	exito := b.almacenarOfertaEnNodos(req)
 
+	// La oferta queda en el log de suscripciones (replicado si hay réplicas)
+	// antes de responder: un éxito no puede perderse si el líder cae después.
+	// Mientras tanto sigue en proceso, así que un reintento no la publica dos
+	// veces
+	if b.dedup.marcarNotificada(req.GetOfertaId()) {
+		if err := b.publicarOferta(req, exito); err != nil {
+			log.Printf("Oferta #%d no se pudo publicar: %v", numOferta, err)
+			b.dedup.desmarcarNotificada(req.GetOfertaId())
+			b.dedup.terminar(req.GetOfertaId(), false)
+			metricaOfertasRechazadas.WithLabelValues(tienda, "sin_publicar").Inc()
+			return &pb.OfertaResponse{Exito: false}, nil
+		}
+	}
+	b.dedup.terminar(req.GetOfertaId(), exito)
+
 	if exito {
-		b.escriturasExitosas++
-		log.Printf("Oferta #%d almacenada exitosamente (W=%d)", b.ofertasRecibidas, W)
-		go b.distribuirAConsumidores(req)
+		b.escriturasExitosas.Add(1)
+		metricaOfertasAceptadas.WithLabelValues(tienda).Inc()
+		log.Printf("Oferta #%d almacenada exitosamente (W=%d)", numOferta, b.quorum.W)
 		return &pb.OfertaResponse{Exito: true}, nil
 	} else {
-		b.escriturasFallidas++
-		log.Printf("Oferta #%d falló - No se alcanzó quorum W=%d", b.ofertasRecibidas, W)
-		go b.distribuirAConsumidores(req)
+		b.escriturasFallidas.Add(1)
+		metricaOfertasRechazadas.WithLabelValues(tienda, "sin_quorum").Inc()
+		log.Printf("Oferta #%d falló - No se alcanzó quorum W=%d", numOferta, b.quorum.W)
 		return &pb.OfertaResponse{Exito: false}, nil
 	}
 }
 
+// almacenarOfertaEnNodos envía la oferta a las N réplicas en paralelo y retorna
+// apenas se juntan W confirmaciones. Los nodos que aún no responden terminan en
+// segundo plano y su resultado igual queda registrado en su NodoInfo.
 func (b *Broker) almacenarOfertaEnNodos(oferta *pb.OfertaRequest) bool {
-	log.Printf("Enviando a %d nodos (necesario W=%d)...", len(b.nodos), W)
-	
+	W := b.quorum.W
+	nodos := b.nodosParaOferta(oferta.GetOfertaId())
+	inicio := time.Now()
+
+	log.Printf("Enviando a %d nodos (necesario W=%d)...", len(nodos), W)
+
+	// Canal con buffer para que los nodos rezagados no queden bloqueados
+	resultados := make(chan resultadoEscritura, len(nodos))
+	for _, nodoInfo := range nodos {
+		go func(nodoInfo *NodoInfo) {
+			// A un nodo que el detector da por caído no se le espera: la
+			// oferta queda directamente como hint
+			if activo, _ := nodoInfo.obtenerEstado(); !activo {
+				log.Printf("%s caído según el detector - guardando hint de %s", nodoInfo.nombre, oferta.GetOfertaId())
+				b.guardarHint(nodoInfo, oferta)
+				resultados <- resultadoEscritura{nodoID: nodoInfo.nombre, exito: false}
+				return
+			}
+
+			exito := b.enviarOfertaANodo(nodoInfo, oferta)
+			if exito {
+				log.Printf("%s confirmó escritura de %s", nodoInfo.nombre, oferta.GetOfertaId())
+			} else {
+				log.Printf("%s falló escritura de %s", nodoInfo.nombre, oferta.GetOfertaId())
+				b.guardarHint(nodoInfo, oferta)
+			}
+			resultados <- resultadoEscritura{nodoID: nodoInfo.nombre, exito: exito}
+		}(nodoInfo)
+	}
+
 	confirmaciones := 0
-	
-	for nodoID, nodoInfo := range b.nodos {
-		exito := b.enviarOfertaANodo(nodoInfo, oferta)
-		if exito {
+	respuestas := 0
+
+	for respuestas < len(nodos) {
+		resultado := <-resultados
+		respuestas++
+		if resultado.exito {
 			confirmaciones++
-			log.Printf("%s confirmó escritura", nodoID)
-		} else {
-			log.Printf("%s falló escritura", nodoID)
 		}
-	}
 
-	log.Printf("Confirmaciones finales: %d/%d (W=%d)", confirmaciones, len(b.nodos), W)
+		if confirmaciones >= W {
+			log.Printf("Quorum W=%d alcanzado: %d/%d confirmaciones (%d nodos pendientes)",
+				W, confirmaciones, len(nodos), len(nodos)-respuestas)
+			metricaEscrituraQuorum.WithLabelValues("exito").Observe(time.Since(inicio).Seconds())
+			return true
+		}
 
-	if confirmaciones >= W {
-		log.Printf("Quorum W=%d alcanzado", W)
-		return true
-	} else {
-		log.Printf("Quorum W=%d no alcanzado", W)
-		return false
+		// Ya no quedan suficientes nodos pendientes para llegar a W
+		if confirmaciones+(len(nodos)-respuestas) < W {
+			break
+		}
 	}
+
+	log.Printf("Quorum W=%d no alcanzado: %d/%d confirmaciones", W, confirmaciones, len(nodos))
+	metricaEscrituraQuorum.WithLabelValues("fallo").Observe(time.Since(inicio).Seconds())
+	return false
 }
 
 func (b *Broker) enviarOfertaANodo(nodoInfo *NodoInfo, oferta *pb.OfertaRequest) bool {
@@ -295,261 +428,280 @@ func (b *Broker) enviarOfertaANodo(nodoInfo *NodoInfo, oferta *pb.OfertaRequest)
 	
 	if err != nil {
 		log.Printf("Error enviando a %s: %v", nodoInfo.nombre, err)
-		if nodoInfo.estado{
-			nodoInfo.estado = false
-			nodoInfo.cantCaidas++
-		}
+		nodoInfo.registrarFallo()
+		metricaErroresNodo.WithLabelValues(nodoInfo.nombre, "escritura").Inc()
 		return false
 	}
 
 	if resp.GetExito() {
-		if !nodoInfo.estado {
+		if nodoInfo.registrarExito() {
 			log.Printf("%s se reconectó", nodoInfo.nombre)
-			nodoInfo.estado = true
+		}
+		if b.hints.cantidad(nodoInfo.nombre) > 0 {
+			go b.reproducirHints(nodoInfo)
 		}
 		return true
 	} else {
 		log.Printf("%s rechazó oferta", nodoInfo.nombre)
-		if nodoInfo.estado {
-			nodoInfo.estado = false
-			nodoInfo.cantCaidas++
-		}
+		nodoInfo.registrarFallo()
+		metricaErroresNodo.WithLabelValues(nodoInfo.nombre, "escritura").Inc()
 		return false
 	}
 }
 
-func (b *Broker) distribuirAConsumidores(oferta *pb.OfertaRequest) {
-	b.mu.Lock()
-	defer b.mu.Unlock()
-	consumidoresNotificados := 0
-	
-	for consumidorID, consumidor := range b.consumidores {
-		if b.coincideConPreferencias(oferta, consumidor) {
-			exito := b.notificarConsumidor(consumidorID, consumidor, oferta)
-			if exito{
-				consumidoresNotificados++
-				consumidor.ofertasRecibidas++
-				log.Printf("%s recibió la notificación", consumidorID)
-			} else {
-				log.Printf("%s no logró recibir la notificación", consumidorID)
-			}
-		}
+// listarNodos retorna una copia de los nodos registrados para poder
+// recorrerlos sin mantener tomado registroMu durante las llamadas de red.
+func (b *Broker) listarNodos() []*NodoInfo {
+	b.registroMu.RLock()
+	defer b.registroMu.RUnlock()
+
+	nodos := make([]*NodoInfo, 0, len(b.nodos))
+	for _, nodoInfo := range b.nodos {
+		nodos = append(nodos, nodoInfo)
 	}
-	
-	log.Printf("Oferta %s distribuida a %d consumidores", oferta.GetOfertaId(), consumidoresNotificados)
+	return nodos
 }
 
-func (b *Broker) notificarConsumidor(consumidorID string, consumidorInfo *ConsumidorInfo, oferta *pb.OfertaRequest) bool{
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
+// nodosParaOferta retorna los N nodos que guardan la oferta según el anillo de
+// hashing consistente.
+func (b *Broker) nodosParaOferta(ofertaID string) []*NodoInfo {
+	b.registroMu.RLock()
+	defer b.registroMu.RUnlock()
 
-	resp, err := consumidorInfo.client.EnviarOferta(ctx, oferta)
-	
-	if err != nil {
-		log.Printf("Error notificando a consumidor %s: %v", consumidorID, err)
-		if consumidorInfo.estado {
-			consumidorInfo.estado = false
-			consumidorInfo.cantCaidas++
-		}
-		return false
+	var nodos []*NodoInfo
+	for _, nombre := range b.anillo.replicas(ofertaID, b.quorum.N) {
+		nodos = append(nodos, b.nodos[nombre])
 	}
+	return nodos
+}
 
-	if resp.GetExito(){
-		if !consumidorInfo.estado{
-			log.Printf("%s se reconectó", consumidorID)
-			consumidorInfo.estado = true
-		}
-		return true
-	} else {
-		log.Printf("%s no recibió la Notificación %s", consumidorID, oferta.GetProducto())
-		if consumidorInfo.estado {
-			consumidorInfo.estado = false
-			consumidorInfo.cantCaidas++
+// ofertasDeNodo filtra las ofertas que el nodo debe replicar. Un nodo puede
+// tener otras que guardó antes de que el anillo cambiara; no se le quitan,
+// pero tampoco se le reparan.
+func (b *Broker) ofertasDeNodo(nombre string, ofertas []*pb.OfertaRequest) []*pb.OfertaRequest {
+	b.registroMu.RLock()
+	defer b.registroMu.RUnlock()
+
+	var propias []*pb.OfertaRequest
+	for _, oferta := range ofertas {
+		if slices.Contains(b.anillo.replicas(oferta.GetOfertaId(), b.quorum.N), nombre) {
+			propias = append(propias, oferta)
 		}
-		return false
 	}
+	return propias
 }
 
-func (b *Broker) coincideConPreferencias(oferta *pb.OfertaRequest, consumidor *ConsumidorInfo) bool {
-	if len(consumidor.categorias) > 0 && consumidor.categorias[0] != "null" {
-		categoriaCoincide := false
-		for _, categoria := range consumidor.categorias {
-			if categoria == oferta.GetCategoria() {
-				categoriaCoincide = true
-				break
-			}
-		}
-		if !categoriaCoincide {
-			return false
-		}
+// tramosSinQuorum cuenta los tramos del anillo en que los nodos que
+// respondieron no alcanzan R réplicas.
+func (b *Broker) tramosSinQuorum(lecturas []lecturaNodo) int {
+	respondieron := make(map[string]bool, len(lecturas))
+	for _, lectura := range lecturas {
+		respondieron[lectura.nodo.nombre] = true
 	}
-	
-	if len(consumidor.tiendas) > 0 && consumidor.tiendas[0] != "null" {
-		tiendaCoincide := false
-		for _, tienda := range consumidor.tiendas {
-			if tienda == oferta.GetTienda() {
-				tiendaCoincide = true
-				break
-			}
-		}
-		if !tiendaCoincide {
-			return false
-		}
+
+	b.registroMu.RLock()
+	defer b.registroMu.RUnlock()
+
+	return b.anillo.tramosSinQuorum(b.quorum.N, b.quorum.R, respondieron)
+}
+
+func (b *Broker) listarConsumidores() []*ConsumidorInfo {
+	b.registroMu.RLock()
+	defer b.registroMu.RUnlock()
+
+	consumidores := make([]*ConsumidorInfo, 0, len(b.consumidores))
+	for _, consumidor := range b.consumidores {
+		consumidores = append(consumidores, consumidor)
 	}
-	
-	if consumidor.precio_max > 0 && oferta.GetPrecio() > consumidor.precio_max {
-		return false
+	return consumidores
+}
+
+func (b *Broker) coincideConPreferencias(oferta *pb.OfertaRequest, consumidor *ConsumidorInfo) bool {
+	return consumidor.obtenerFiltro().Coincide(oferta)
+}
+
+// compilarFiltro usa el filtro explícito si el consumidor lo envió y, si no,
+// arma uno con sus preferencias simples.
+func compilarFiltro(filtro *pb.FiltroOferta, categorias, tiendas []string, precioMax int32) (*filtros.Filtro, error) {
+	if filtro == nil {
+		filtro = filtros.DesdePreferencias(categorias, tiendas, precioMax)
 	}
-	
-	return true
+	return filtros.Compilar(filtro)
 }
 
 func (b *Broker) SincronizarEntidad(ctx context.Context, req *pb.SincronizacionRequest) (*pb.SincronizacionResponse, error) {
-	b.mu.Lock()
-	defer b.mu.Unlock()
-
 	entidadID := req.GetEntidadId()
 	tipo := req.GetTipo()
-	ofertasActuales := req.GetOfertasActuales()
-	log.Printf("Sincronizando %s: %s", tipo, entidadID)
+	desde := req.GetDesdeSecuencia()
+	inicio := time.Now()
+	log.Printf("Sincronizando %s: %s desde secuencia %d", tipo, entidadID, desde)
+
+	b.registroMu.RLock()
+	nodo := b.nodos[entidadID]
+	consumidor := b.consumidores[entidadID]
+	b.registroMu.RUnlock()
 
-	historialOfertas := b.obtenerHistorialOfertas()
+	if tipo == "consumidor" && consumidor == nil {
+		log.Printf("Consumidor %s no encontrado para sincronización", entidadID)
+		return &pb.SincronizacionResponse{Exito: false}, nil
+	}
+
+	// Una secuencia mayor que las asignadas viene de un líder anterior del
+	// broker: se adelanta el contador para no volver a asignarla
+	b.secuencias.observar(desde)
+
+	lectura := &pb.LecturaRequest{}
+	if tipo == "consumidor" {
+		lectura = consumidor.obtenerFiltro().Lectura()
+	}
+	lectura.DesdeSecuencia = desde
+	historialOfertas := b.obtenerHistorialOfertas(lectura)
 	if historialOfertas == nil {
-		log.Printf("No se pudo sincronizar %s - No se alcanzó quorum R=%d", entidadID, R)
+		log.Printf("No se pudo sincronizar %s - No se alcanzó quorum R=%d", entidadID, b.quorum.R)
 		return &pb.SincronizacionResponse{Exito: false}, nil
 	}
 
-	var ofertasFaltantes []*pb.OfertaRequest
+	var ofertasFaltantes, hints []*pb.OfertaRequest
 	if tipo == "consumidor" {
-		consumidor, existe := b.consumidores[entidadID]
-		if !existe {
-			log.Printf("Consumidor %s no encontrado para sincronización", entidadID)
-			return &pb.SincronizacionResponse{Exito: false}, nil
-		}
-		
+		// Van también los cambios de ofertas que el consumidor quizás no
+		// recibió: las que no conoce y no están vigentes las descarta él
 		for _, ofertaHistorial := range historialOfertas {
 			if b.coincideConPreferencias(ofertaHistorial, consumidor) {
-				existeEnActuales := false
-				for _, ofertaActual := range ofertasActuales {
-					if ofertaActual.GetOfertaId() == ofertaHistorial.GetOfertaId() {
-						existeEnActuales = true
-						break
-					}
-				}
-				
-				if !existeEnActuales {
-					ofertasFaltantes = append(ofertasFaltantes, ofertaHistorial)
-				}
+				ofertasFaltantes = append(ofertasFaltantes, ofertaHistorial)
 			}
 		}
 	} else {
-		for _, ofertaHistorial := range historialOfertas {
-			existe := false
-			for _, ofertaActual := range ofertasActuales {
-				if ofertaActual.GetOfertaId() == ofertaHistorial.GetOfertaId() {
-					existe = true
-					break
-				}
-			}
-			if !existe {
-				ofertasFaltantes = append(ofertasFaltantes, ofertaHistorial)
-			}
+		// Los hints del nodo van en la misma respuesta, así no hay que esperar
+		// a que se reenvíen cuando termine de recuperarse. Pueden tener una
+		// secuencia anterior a la del nodo, si se perdieron antes de su caída.
+		// Quedan en la cola hasta que el nodo llama a ConfirmarHints
+		hints = b.hints.ofertas(entidadID)
+		ofertasFaltantes = ofertasPendientes(b.ofertasDeNodo(entidadID, historialOfertas), hints)
+		if len(hints) > 0 {
+			log.Printf("%s recibe %d ofertas desde su cola de hints", entidadID, len(hints))
 		}
 	}
 	
 	log.Printf("%s sincronizado: %d ofertas faltantes", entidadID, len(ofertasFaltantes))
+	// Cualquier tipo que no sea consumidor se sincroniza como nodo
+	etiqueta := "nodo"
+	if tipo == "consumidor" {
+		etiqueta = tipo
+	}
+	metricaResincronizacionDuracion.WithLabelValues(etiqueta).Observe(time.Since(inicio).Seconds())
+	metricaResincronizacionOfertas.WithLabelValues(etiqueta).Observe(float64(len(ofertasFaltantes)))
 	
-	if tipo == "nodo" {
-		if nodo, existe := b.nodos[entidadID]; existe {
-			nodo.estado = true
-			log.Printf("Nodo %s resincronizado", entidadID)
-		}
+	if tipo == "nodo" && nodo != nil {
+		nodo.registrarExito()
+		log.Printf("Nodo %s resincronizado", entidadID)
 	}
 
 	if tipo == "consumidor" {
-		if consumidor, existe := b.consumidores[entidadID]; existe {
-			consumidor.estado = true
-			consumidor.ofertasRecibidas += len(ofertasFaltantes)
-			log.Printf("Consumidor %s resincronizado", entidadID)
-		}
+		consumidor.registrarExito()
+		consumidor.ofertasRecibidas.Add(int64(len(ofertasFaltantes)))
+		log.Printf("Consumidor %s resincronizado", entidadID)
 	}
 	
 	return &pb.SincronizacionResponse{
 		OfertasFaltantes: ofertasFaltantes,
 		Exito: true,
+		Hints: hints,
 	}, nil
 }
 
-func (b *Broker) obtenerHistorialOfertas() []*pb.OfertaRequest {
-    var listasOfertas [][]*pb.OfertaRequest
-    var nodosIDs []string
+// ConfirmarHints recibe del nodo los hints que guardó tras sincronizarse y
+// recién entonces los saca de su cola.
+func (b *Broker) ConfirmarHints(ctx context.Context, req *pb.ConfirmacionHintsRequest) (*pb.RegistroResponse, error) {
+	if !nodoIDValido(req.GetNodoId()) {
+		log.Printf("Confirmación de hints con nombre de nodo inválido: %q", req.GetNodoId())
+		return &pb.RegistroResponse{Exito: false}, nil
+	}
+	entregadas := make([]*pb.OfertaRequest, 0, len(req.GetHints()))
+	for _, hint := range req.GetHints() {
+		entregadas = append(entregadas, &pb.OfertaRequest{OfertaId: hint.GetOfertaId(), Version: hint.GetVersion()})
+	}
+	if !b.confirmarHints(req.GetNodoId(), entregadas) {
+		return &pb.RegistroResponse{Exito: false}, nil
+	}
+	log.Printf("%s confirmó %d hints", req.GetNodoId(), len(entregadas))
+	return &pb.RegistroResponse{Exito: true}, nil
+}
+
+// obtenerHistorialOfertas lee de los nodos las ofertas que cumplen la lectura
+// y repara en segundo plano las réplicas que quedaron atrasadas.
+func (b *Broker) obtenerHistorialOfertas(lectura *pb.LecturaRequest) []*pb.OfertaRequest {
+    lecturas, historial := b.leerConQuorum(lectura)
+    if historial != nil {
+        go b.repararLecturas(lecturas, historial)
+    }
+    return historial
+}
+
+// leerConQuorum lee de los nodos las ofertas que cumplen la lectura, sin
+// reparar nada. Los filtros de la lectura se aplican en los nodos, así que solo
+// viajan las ofertas que el llamador va a usar. Sin quorum retorna nil.
+func (b *Broker) leerConQuorum(lectura *pb.LecturaRequest) ([]lecturaNodo, []*pb.OfertaRequest) {
+    R := b.quorum.R
+    var lecturas []lecturaNodo
     
-    for nodoID, nodoInfo := range b.nodos {
-        ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-        resp, err := nodoInfo.client.LeerOfertas(ctx, &pb.LecturaRequest{})
-        cancel()
-        
+    // Cada nodo guarda solo sus tramos del anillo: se leen todos y se exige
+    // quorum R en cada tramo
+    for _, nodoInfo := range b.listarNodos() {
+        nodoID := nodoInfo.nombre
+        if activo, _ := nodoInfo.obtenerEstado(); !activo {
+            log.Printf("Nodo %s caído según el detector - no se lee", nodoID)
+            continue
+        }
+        ofertas, err := b.leerOfertasNodo(nodoInfo, lectura)
         if err != nil {
-			if nodoInfo.estado {
-				nodoInfo.estado = false
-				nodoInfo.cantCaidas++
-			}
+			nodoInfo.registrarFallo()
+			metricaErroresNodo.WithLabelValues(nodoID, "lectura").Inc()
             log.Printf("Error leyendo %s: %v", nodoID, err)
             continue
         }
         
-        if resp.GetExito() {
-            listasOfertas = append(listasOfertas, resp.GetOfertas())
-            nodosIDs = append(nodosIDs, nodoID)
-            log.Printf("Nodo %s: %d ofertas", nodoID, len(resp.GetOfertas()))
-        } else {
-			if nodoInfo.estado {
-				nodoInfo.estado = false
-				nodoInfo.cantCaidas++
-			}
-		}
+        lecturas = append(lecturas, lecturaNodo{nodo: nodoInfo, ofertas: ofertas})
+        log.Printf("Nodo %s: %d ofertas", nodoID, len(ofertas))
     }
     
-    if len(listasOfertas) < R {
-        log.Printf("No se alcanzó quorum R=%d, solo %d nodos respondieron", R, len(listasOfertas))
-        return nil
+    if len(lecturas) < R {
+        log.Printf("No se alcanzó quorum R=%d, solo %d nodos respondieron", R, len(lecturas))
+        return nil, nil
     }
-    
-    for i := 0; i < len(listasOfertas); i++ {
-        for j := i + 1; j < len(listasOfertas); j++ {
-            if b.sonListasIdenticas(listasOfertas[i], listasOfertas[j]) {
-                log.Printf("Nodos %s y %s tienen ofertas idénticas - Quorum R=2 alcanzado", 
-                    nodosIDs[i], nodosIDs[j])
-                return listasOfertas[i]
-            }
-        }
+    if sinQuorum := b.tramosSinQuorum(lecturas); sinQuorum > 0 {
+        log.Printf("No se alcanzó quorum R=%d en %d tramos del anillo", R, sinQuorum)
+        return nil, nil
     }
     
-    log.Printf("No se encontraron dos nodos con ofertas idénticas")
-    return nil
+    // Las respuestas se fusionan tomando la versión más nueva de cada oferta
+    historial := b.fusionarLecturas(lecturas)
+    log.Printf("Quorum R=%d alcanzado con %d nodos: %d ofertas", R, len(lecturas), len(historial))
+    return lecturas, historial
 }
 
-func (b *Broker) sonListasIdenticas(lista1, lista2 []*pb.OfertaRequest) bool {
-    if len(lista1) != len(lista2) {
-        log.Printf("Listas de ofertas tienen diferente longitud: %d vs %d", len(lista1), len(lista2))
-        return false
-    }
-    
-    for _, oferta1 := range lista1 {
-        encontrada := false
-        for _, oferta2 := range lista2 {
-            if oferta1.GetOfertaId() == oferta2.GetOfertaId() {
-                encontrada = true
-                break
-            }
-        }
-        if !encontrada {
-            log.Printf("Oferta %s no encontrada en segunda lista", oferta1.GetOfertaId())
-            return false
-        }
-    }
-    
-    return true
+// leerOfertasNodo pide al nodo todas las páginas de la lectura. Cada página
+// tiene su propio timeout, así un nodo con muchas ofertas no lo agota.
+func (b *Broker) leerOfertasNodo(nodo *NodoInfo, lectura *pb.LecturaRequest) ([]*pb.OfertaRequest, error) {
+	pagina := proto.Clone(lectura).(*pb.LecturaRequest)
+	pagina.Token = ""
+
+	var ofertas []*pb.OfertaRequest
+	for {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		resp, err := nodo.client.LeerOfertas(ctx, pagina)
+		cancel()
+		if err != nil {
+			return nil, err
+		}
+		if !resp.GetExito() {
+			return nil, fmt.Errorf("%s rechazó la lectura", nodo.nombre)
+		}
+		ofertas = append(ofertas, resp.GetOfertas()...)
+		if resp.GetSiguienteToken() == "" {
+			return ofertas, nil
+		}
+		pagina.Token = resp.GetSiguienteToken()
+	}
 }
 
 func esValido(valor string, listaValidos []string) bool {
@@ -562,8 +714,8 @@ func esValido(valor string, listaValidos []string) bool {
 }
 
 func (b *Broker) generarReporteFinal() {
-    b.mu.Lock()
-    defer b.mu.Unlock()
+    b.registroMu.RLock()
+    defer b.registroMu.RUnlock()
 
     filename := "/output/Reporte.txt"
     file, err := os.Create(filename)
@@ -573,67 +725,128 @@ func (b *Broker) generarReporteFinal() {
     }
     defer file.Close()
 
+    file.WriteString("CONFIGURACIÓN DE QUÓRUM:\n")
+    file.WriteString(fmt.Sprintf("*Réplicas por oferta (N): %d\n", b.quorum.N))
+    file.WriteString(fmt.Sprintf("*Quórum de escritura (W): %d\n", b.quorum.W))
+    file.WriteString(fmt.Sprintf("*Quórum de lectura (R): %d\n", b.quorum.R))
+    file.WriteString(fmt.Sprintf("*Posiciones por nodo en el anillo: %d\n", b.anillo.porNodo))
+    if b.quorum.lecturaConsistente() {
+        file.WriteString("*W+R > N: las lecturas siempre incluyen la última escritura confirmada\n")
+    } else {
+        file.WriteString("*W+R <= N: las lecturas pueden no incluir la última escritura confirmada\n")
+    }
+    file.WriteString("\n")
+
     file.WriteString("RESUMEN DE PRODUCTORES:\n")
     
     for nombre, prod := range b.productores {
         file.WriteString(fmt.Sprintf("*%s:\n", nombre))
-        file.WriteString(fmt.Sprintf("  - Ofertas enviadas: %d\n", prod.ofertasEnviadas))
-        file.WriteString(fmt.Sprintf("  - Ofertas aceptadas: %d\n", prod.ofertasAceptadas))
+        file.WriteString(fmt.Sprintf("  - Ofertas enviadas: %d\n", prod.ofertasEnviadas.Load()))
+        file.WriteString(fmt.Sprintf("  - Ofertas aceptadas: %d\n", prod.ofertasAceptadas.Load()))
+        file.WriteString(fmt.Sprintf("  - Reintentos reprocesados: %d\n", prod.reintentos.Load()))
+        file.WriteString(fmt.Sprintf("  - Duplicados reconocidos: %d\n", prod.ofertasDuplicadas.Load()))
     }
 
 	file.WriteString("\n")
 
     file.WriteString("ESTADO DE NODOS DE BASE DE DATOS:\n")
+    proporciones := b.anillo.proporciones()
     for nombre, nodo := range b.nodos {
-        estado := "ACTIVO"
-        if !nodo.estado {
-            estado = "CAÍDO"
-        }
-        file.WriteString(fmt.Sprintf("*NODO %s: %s\n", nombre, estado))
-		file.WriteString(fmt.Sprintf("  * Caídas simuladas: %d\n", nodo.cantCaidas))
+        _, cantCaidas := nodo.obtenerEstado()
+        salud, _, _ := nodo.obtenerSalud()
+        file.WriteString(fmt.Sprintf("*NODO %s: %s\n", nombre, salud))
+		file.WriteString(fmt.Sprintf("  * Dueño del %.1f%% del anillo\n", 100*proporciones[nombre]))
+		file.WriteString(fmt.Sprintf("  * Caídas simuladas: %d\n", cantCaidas))
+		file.WriteString(fmt.Sprintf("  * Ofertas reparadas por lectura: %d\n", nodo.ofertasReparadas.Load()))
     }
     file.WriteString("\n")
 
 	file.WriteString("MÉTRICAS DE ESCRITURA:\n")
-	file.WriteString(fmt.Sprintf("*Escrituras exitosas: %d\n", b.escriturasExitosas))
-	file.WriteString(fmt.Sprintf("*Escrituras fallidas: %d\n", b.escriturasFallidas))
+	file.WriteString(fmt.Sprintf("*Escrituras exitosas: %d\n", b.escriturasExitosas.Load()))
+	file.WriteString(fmt.Sprintf("*Escrituras fallidas: %d\n", b.escriturasFallidas.Load()))
+	file.WriteString("\n")
+
+	file.WriteString("HINTED HANDOFF:\n")
+	file.WriteString(fmt.Sprintf("*Hints guardados: %d\n", b.hintsGuardados.Load()))
+	file.WriteString(fmt.Sprintf("*Hints entregados: %d\n", b.hintsEntregados.Load()))
+	for nombre := range b.nodos {
+		file.WriteString(fmt.Sprintf("*Pendientes para %s: %d\n", nombre, b.hints.cantidad(nombre)))
+	}
+	file.WriteString("\n")
+
+	file.WriteString("ANTI-ENTROPÍA (ÁRBOLES DE MERKLE):\n")
+	file.WriteString(fmt.Sprintf("*Rondas completadas: %d\n", b.rondasAntiEntropia.Load()))
+	file.WriteString(fmt.Sprintf("*Buckets divergentes: %d\n", b.bucketsDivergentes.Load()))
+	file.WriteString(fmt.Sprintf("*Ofertas transferidas: %d\n", b.ofertasAntiEntropia.Load()))
+	file.WriteString("\n")
+
+	file.WriteString("CICLO DE VIDA DE LAS OFERTAS:\n")
+	file.WriteString(fmt.Sprintf("*Cambios de stock: %d (%d ofertas agotadas)\n", b.stockActualizado.Load(), b.ofertasAgotadas.Load()))
+	file.WriteString(fmt.Sprintf("*Ofertas retiradas por su tienda: %d\n", b.ofertasRetiradas.Load()))
+	if b.ttlOfertas > 0 {
+		file.WriteString(fmt.Sprintf("*Ofertas expiradas (TTL %v): %d\n", b.ttlOfertas, b.ofertasExpiradas.Load()))
+	}
+	file.WriteString("\n")
+
+	file.WriteString("REPARACIÓN EN LECTURA:\n")
+	file.WriteString(fmt.Sprintf("*Ofertas reparadas: %d\n", b.ofertasReparadas.Load()))
+	file.WriteString(fmt.Sprintf("*Reparaciones fallidas: %d\n", b.reparacionesFallidas.Load()))
+	file.WriteString("\n")
+
+	file.WriteString(fmt.Sprintf("DETECTOR DE FALLOS (latido cada %dms, sospecha %dms, caída %dms):\n",
+		b.latidos.IntervaloMs, b.latidos.SospechaMs, b.latidos.CaidaMs))
+	for nombre, nodo := range b.nodos {
+		file.WriteString(describirSalud("NODO "+nombre, &nodo.estadoEntidad))
+	}
+	for id, cons := range b.consumidores {
+		file.WriteString(describirSalud("CONSUMIDOR "+id, &cons.estadoEntidad))
+	}
+	file.WriteString("\n")
+
+	if b.raft != nil {
+		file.WriteString("REPLICACIÓN DEL BROKER (RAFT):\n")
+		file.WriteString(b.raft.describir())
+		file.WriteString("\n")
+	}
 
     file.WriteString("NOTIFICACIONES A CONSUMIDORES:\n")
     for id, cons := range b.consumidores {
+        _, cantCaidas := cons.obtenerEstado()
         file.WriteString(fmt.Sprintf("* %s:\n", id))
-		file.WriteString(fmt.Sprintf("  - Preferencias: Categorías%v, Tiendas%v, PrecioMax:%d\n", 
-            cons.categorias, cons.tiendas, cons.precio_max))
-        file.WriteString(fmt.Sprintf("  - Ofertas recibidas: %d\n", cons.ofertasRecibidas))
+		file.WriteString(fmt.Sprintf("  - Preferencias: %s\n", cons.obtenerFiltro()))
+        file.WriteString(fmt.Sprintf("  - Ofertas recibidas: %d\n", cons.ofertasRecibidas.Load()))
         file.WriteString(fmt.Sprintf("  - Archivo %s generado.\n", cons.archivoCSV))
-        file.WriteString(fmt.Sprintf("  - Caídas simuladas: %d\n", cons.cantCaidas))
+        file.WriteString(fmt.Sprintf("  - Caídas simuladas: %d\n", cantCaidas))
     }
     file.WriteString("\n")
 
     file.WriteString("FALLOS Y RECUPERACIONES: \n")
 	file.WriteString("*Fallo de Nodos: \n")
     for nombre, nodo := range b.nodos {
+        activo, cantCaidas := nodo.obtenerEstado()
         estado := "Pudo recuperarse exitosamente de todas las caídas"
-        if !nodo.estado {
+        if !activo {
             estado = "No logró recuperarse de la última caída"
         }
         file.WriteString(fmt.Sprintf("\n- NODO %s: %s\n", nombre, estado))
-		file.WriteString(fmt.Sprintf("- Caídas simuladas: %d\n", nodo.cantCaidas))
-		cantRecuperaciones := nodo.cantCaidas
-		if !nodo.estado{
+		file.WriteString(fmt.Sprintf("- Caídas simuladas: %d\n", cantCaidas))
+		cantRecuperaciones := cantCaidas
+		if !activo{
 			cantRecuperaciones--
 		}
 		file.WriteString(fmt.Sprintf("- Reconexiones y sincronización: %d\n", cantRecuperaciones))
     }
 	file.WriteString("\n*Fallo de Consumidores: \n")
 	for nombre, cons := range b.consumidores {
+		activo, cantCaidas := cons.obtenerEstado()
 		estado := "Pudo recuperarse exitosamente de todas las caídas"
-        if !cons.estado {
+        if !activo {
             estado = "No logró recuperarse de la última caída"
         }
         file.WriteString(fmt.Sprintf("\n- Consumidor %s: %s\n", nombre, estado))
-		file.WriteString(fmt.Sprintf("- Caídas simuladas: %d\n", cons.cantCaidas))
-		cantRecuperaciones := cons.cantCaidas
-		if !cons.estado{
+		file.WriteString(fmt.Sprintf("- Caídas simuladas: %d\n", cantCaidas))
+		cantRecuperaciones := cantCaidas
+		if !activo{
 			cantRecuperaciones--
 		}
 		file.WriteString(fmt.Sprintf("- Reconexiones y sincronización: %d\n", cantRecuperaciones))
@@ -654,51 +867,56 @@ func (b *Broker) generarConclusion() string {
     var conclusion strings.Builder
     conclusion.WriteString("\n=== CONCLUSIÓN ===\n\n")
 
-    consistenciaEscritura := b.escriturasExitosas == b.ofertasRecibidas
+    ofertasRecibidas := b.ofertasRecibidas.Load()
+    escriturasExitosas := b.escriturasExitosas.Load()
+    escriturasFallidas := b.escriturasFallidas.Load()
+    consistenciaEscritura := escriturasExitosas == ofertasRecibidas
 
     nodosActivos := 0
     nodosCaidas := 0
     for _, nodo := range b.nodos {
-        if nodo.estado {
+        activo, cantCaidas := nodo.obtenerEstado()
+        if activo {
             nodosActivos++
         }
-        nodosCaidas += nodo.cantCaidas
+        nodosCaidas += cantCaidas
     }
 
     consumidoresActivos := 0
     consumidoresCaidas := 0
     for _, consumidor := range b.consumidores {
-        if consumidor.estado {
+        activo, cantCaidas := consumidor.obtenerEstado()
+        if activo {
             consumidoresActivos++
         }
-        consumidoresCaidas += consumidor.cantCaidas
+        consumidoresCaidas += cantCaidas
     }
 
     if nodosCaidas == 0 && consumidoresCaidas == 0 {
         conclusion.WriteString("El sistema se mantuvo completamente estable durante toda la simulación, ")
-        conclusion.WriteString("cumpliendo estrictamente con las reglas de replicación (N=3, W=2, R=2). ")
+        conclusion.WriteString(fmt.Sprintf("cumpliendo estrictamente con las reglas de replicación (%s). ", b.quorum))
         conclusion.WriteString("Todas las ofertas fueron procesadas y distribuidas correctamente.\n\n")
     } else {
-        if nodosActivos == 3 && consumidoresActivos == 12 {
+        if nodosActivos == len(b.nodos) && consumidoresActivos == len(b.consumidores) {
             conclusion.WriteString("El sistema demostró alta tolerancia a fallos durante la simulación. ")
             conclusion.WriteString("A pesar de las caídas temporales, se mantuvo la disponibilidad y consistencia ")
-            conclusion.WriteString("de escritura (W=2). La recuperación y resincronización de todos los nodos ")
+            conclusion.WriteString(fmt.Sprintf("de escritura (W=%d). La recuperación y resincronización de todos los nodos ", b.quorum.W))
             conclusion.WriteString("y consumidores se completaron exitosamente.\n\n")
             
             conclusion.WriteString("El sistema gestionó adecuadamente las desconexiones y reconexiones, ")
             conclusion.WriteString("asegurando la entrega completa de todas las ofertas relevantes sin pérdida ")
             conclusion.WriteString("de datos, gracias a la funcionalidad de recuperación de histórico basada ")
-            conclusion.WriteString("en lecturas distribuidas consistentes (R=2).\n\n")
+            conclusion.WriteString(fmt.Sprintf("en lecturas distribuidas consistentes (R=%d).\n\n", b.quorum.R))
         } else {
             conclusion.WriteString("El sistema operó en condiciones degradadas durante la simulación. ")
-            conclusion.WriteString(fmt.Sprintf("%d nodo(s) se encontraban inactivos al finalizar, ", 3-nodosActivos))
+            conclusion.WriteString(fmt.Sprintf("%d nodo(s) se encontraban inactivos al finalizar, ", len(b.nodos)-nodosActivos))
             conclusion.WriteString("sin lograr recuperarse de su última caída. ")
-            conclusion.WriteString(fmt.Sprintf("%d consumidor(es) permanecían desconectados ", 12-consumidoresActivos))
+            conclusion.WriteString(fmt.Sprintf("%d consumidor(es) permanecían desconectados ", len(b.consumidores)-consumidoresActivos))
             conclusion.WriteString("al término de la ejecución.\n\n")
             
             conclusion.WriteString("A pesar de estos fallos permanentes, el sistema demostró robustez ")
             conclusion.WriteString("al continuar procesando y distribuyendo ofertas a las entidades activas, ")
-            conclusion.WriteString("manteniendo el quórum de escritura requerido (W=2).\n\n")
+            conclusion.WriteString(fmt.Sprintf("manteniendo el quórum de escritura requerido (W=%d).\n\n", b.quorum.W))
         }
     }
 
@@ -710,11 +928,11 @@ func (b *Broker) generarConclusion() string {
         conclusion.WriteString("entre ofertas recibidas y almacenadas. ")
     }
 
-    conclusion.WriteString(fmt.Sprintf("Se procesaron %d ofertas en total, ", b.ofertasRecibidas))
-    conclusion.WriteString(fmt.Sprintf("con %d escrituras exitosas ", b.escriturasExitosas))
+    conclusion.WriteString(fmt.Sprintf("Se procesaron %d ofertas en total, ", ofertasRecibidas))
+    conclusion.WriteString(fmt.Sprintf("con %d escrituras exitosas ", escriturasExitosas))
     
-    if b.escriturasFallidas > 0 {
-        conclusion.WriteString(fmt.Sprintf("y %d escrituras fallidas. ", b.escriturasFallidas))
+    if escriturasFallidas > 0 {
+        conclusion.WriteString(fmt.Sprintf("y %d escrituras fallidas. ", escriturasFallidas))
     } else {
         conclusion.WriteString("sin escrituras fallidas. ")
     }
@@ -722,9 +940,9 @@ func (b *Broker) generarConclusion() string {
     conclusion.WriteString("\n\nMétricas clave del sistema:\n")
     conclusion.WriteString(fmt.Sprintf("• Nodos activos: %d/%d\n", nodosActivos, len(b.nodos)))
     conclusion.WriteString(fmt.Sprintf("• Consumidores activos: %d/%d\n", consumidoresActivos, len(b.consumidores)))
-    conclusion.WriteString(fmt.Sprintf("• Total de ofertas procesadas: %d\n", b.ofertasRecibidas))
-    conclusion.WriteString(fmt.Sprintf("• Escrituras exitosas: %d\n", b.escriturasExitosas))
-    conclusion.WriteString(fmt.Sprintf("• Escrituras fallidas: %d\n", b.escriturasFallidas))
+    conclusion.WriteString(fmt.Sprintf("• Total de ofertas procesadas: %d\n", ofertasRecibidas))
+    conclusion.WriteString(fmt.Sprintf("• Escrituras exitosas: %d\n", escriturasExitosas))
+    conclusion.WriteString(fmt.Sprintf("• Escrituras fallidas: %d\n", escriturasFallidas))
     conclusion.WriteString(fmt.Sprintf("• Consistencia de escritura mantenida: %v\n", consistenciaEscritura))
     
     if nodosCaidas > 0 {
@@ -738,9 +956,7 @@ func (b *Broker) generarConclusion() string {
 }
 
 func (b *Broker) ConsultarEstado(ctx context.Context, req *pb.ConsultarEstadoRequest) (*pb.ConsultarEstadoResponse, error) {
-	b.mu.Lock()
-	defer b.mu.Unlock()
-	return &pb.ConsultarEstadoResponse{Activo: b.sistemaActivo}, nil
+	return &pb.ConsultarEstadoResponse{Activo: b.sistemaActivo.Load()}, nil
 }
 
 func (b *Broker) iniciarInterfazUsuario() {
@@ -750,21 +966,25 @@ func (b *Broker) iniciarInterfazUsuario() {
         reader := bufio.NewReader(os.Stdin)
         for {
             fmt.Print("broker> ")
-            text, _ := reader.ReadString('\n')
+            text, err := reader.ReadString('\n')
+            if err != nil && text == "" {
+                // Sin terminal (por ejemplo, varias réplicas en segundo plano)
+                log.Printf("Entrada estándar cerrada - la interfaz de usuario queda deshabilitada")
+                return
+            }
             text = strings.TrimSpace(text)
             text = strings.ToLower(text)
             
             switch text {
             case "reporte", "fin", "exit", "quit":
                 log.Printf("Generando reporte final por comando de usuario...")
-                b.mu.Lock()
-                b.sistemaActivo = false
-                b.mu.Unlock()
+                b.sistemaActivo.Store(false)
 
 				log.Printf("Esperando 10 segundos para últimas recuperaciones...")
                 time.Sleep(10 * time.Second)
 
 				log.Printf("Generando reporte final...")
+				b.guardarEstado()
 				b.generarReporteFinal()
                 log.Printf("Sistema finalizado")
                 os.Exit(0)              
@@ -778,18 +998,51 @@ func (b *Broker) iniciarInterfazUsuario() {
 }
 
 func main() {
-	broker := NewBroker()
-	grpcServer := grpc.NewServer()
+	config, err := cargarConfiguracion(os.Args[1:])
+	if err != nil {
+		log.Fatalf("Configuración inválida: %v", err)
+	}
+	log.Printf("Quorum configurado: %s", config.Quorum)
+	log.Printf("Mínimos para inicio -> Nodos: %d, Productores: %d, Consumidores: %d",
+		config.Membresia.MinNodos, config.Membresia.MinProductores, config.Membresia.MinConsumidores)
+
+	broker, err := NewBroker(config)
+	if err != nil {
+		log.Fatalf("Error recuperando estado del broker: %v", err)
+	}
+	grpcServer := grpc.NewServer(
+		grpc.MaxRecvMsgSize(maxMensajeRaft),
+		grpc.ChainUnaryInterceptor(broker.interceptorLider),
+		grpc.ChainStreamInterceptor(broker.interceptorLiderStream),
+	)
 	pb.RegisterCyberDayServiceServer(grpcServer, broker)
 
-	listener, err := net.Listen("tcp", ":50051")
+	listener, err := net.Listen("tcp", fmt.Sprintf(":%d", config.Puerto))
 	if err != nil {
-		log.Fatalf("Error al intentar iniciar en puerto 50051: %v", err)
+		log.Fatalf("Error al intentar iniciar en puerto %d: %v", config.Puerto, err)
 	}
 
-	log.Printf("Broker iniciado en puerto :50051")
+	if config.AntiEntropiaSeg > 0 {
+		broker.iniciarAntiEntropia(time.Duration(config.AntiEntropiaSeg) * time.Second)
+		log.Printf("Anti-entropía cada %d segundos", config.AntiEntropiaSeg)
+	}
+	if config.PuertoHTTP > 0 {
+		broker.iniciarHTTP(config.PuertoHTTP)
+	}
+	if config.TTLOfertasSeg > 0 {
+		broker.iniciarExpiracion()
+		log.Printf("Las ofertas expiran a los %d segundos", config.TTLOfertasSeg)
+	}
+
+	log.Printf("Broker iniciado en puerto :%d", config.Puerto)
+	if broker.raft != nil {
+		log.Printf("Réplica %s de %d - esperando elección de líder", config.Replicacion.ID, len(config.Replicacion.Pares))
+		broker.raft.iniciar()
+	}
 	log.Printf("Esperando registros...")
 	
+	broker.iniciarDetectorFallos()
+	broker.iniciarPersistencia()
 	broker.iniciarInterfazUsuario()
 
 	if err := grpcServer.Serve(listener); err != nil {
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"time"
+
+	pb "lab2/broker/proto"
+)
+
+// verificarInicio da inicio al envío de ofertas cuando se cumplen los mínimos
+// de membresía configurados. Una vez iniciado el sistema no se vuelve atrás:
+// si luego salen nodos, las escrituras simplemente no alcanzan quorum.
+// Se llama con registroMu tomado después de cada cambio de membresía, por eso
+// también pide guardar el estado.
+func (b *Broker) verificarInicio() {
+	m := b.membresia
+	b.programarGuardado()
+
+	log.Printf("Estado -> Productores: %d/%d, Nodos: %d/%d, Consumidores: %d/%d",
+		len(b.productores), m.MinProductores, len(b.nodos), m.MinNodos,
+		len(b.consumidores), m.MinConsumidores)
+
+	if b.inicio.Load() {
+		return
+	}
+
+	if len(b.nodos) >= m.MinNodos &&
+		len(b.productores) >= m.MinProductores &&
+		len(b.consumidores) >= m.MinConsumidores {
+		b.inicio.Store(true)
+		log.Printf("Sistema listo: se cumplen los mínimos de membresía")
+	} else {
+		log.Printf("Sistema en proceso: esperando más entidades")
+	}
+}
+
+func (b *Broker) AbandonarCluster(ctx context.Context, req *pb.SalidaRequest) (*pb.RegistroResponse, error) {
+	entidadID := req.GetEntidadId()
+
+	b.registroMu.Lock()
+	defer b.registroMu.Unlock()
+
+	switch req.GetTipo() {
+	case "productor":
+		if _, existe := b.productores[entidadID]; !existe {
+			return &pb.RegistroResponse{Exito: false}, nil
+		}
+		delete(b.productores, entidadID)
+	case "nodo":
+		nodo, existe := b.nodos[entidadID]
+		if !existe {
+			return &pb.RegistroResponse{Exito: false}, nil
+		}
+		delete(b.nodos, entidadID)
+		b.anillo.quitar(entidadID)
+		nodo.conn.Close()
+	case "consumidor":
+		if !b.quitarConsumidor(entidadID) {
+			return &pb.RegistroResponse{Exito: false}, nil
+		}
+	default:
+		log.Printf("Tipo de entidad desconocido: %s", req.GetTipo())
+		return &pb.RegistroResponse{Exito: false}, nil
+	}
+
+	log.Printf("%s %s abandonó el cluster", req.GetTipo(), entidadID)
+	b.verificarInicio()
+	return &pb.RegistroResponse{Exito: true}, nil
+}
+
+// ponerAlDiaNodo envía a un nodo que se une tarde (o vuelve al cluster) las
+// versiones del historial con secuencia mayor a desde que le tocan según el
+// anillo, como hace SincronizarEntidad, salvo las que ya le llegaron como
+// hints. Un nodo nuevo parte de cero y recibe así los tramos que pasó a
+// replicar.
+func (b *Broker) ponerAlDiaNodo(nodo *NodoInfo, desde int64, entregados []*pb.OfertaRequest) {
+	historial := b.obtenerHistorialOfertas(&pb.LecturaRequest{DesdeSecuencia: desde})
+	if historial == nil {
+		log.Printf("No se pudo poner al día a %s - No se alcanzó quorum R=%d", nodo.nombre, b.quorum.R)
+		return
+	}
+
+	enviadas := 0
+	for _, oferta := range ofertasPendientes(b.ofertasDeNodo(nodo.nombre, historial), entregados) {
+		if b.enviarOfertaANodo(nodo, oferta) {
+			enviadas++
+		}
+	}
+
+	log.Printf("Nodo %s puesto al día desde la secuencia %d: +%d ofertas", nodo.nombre, desde, enviadas)
+}
+
+// secuenciaNodo pregunta al nodo la mayor secuencia que guarda. La lectura pide
+// versiones posteriores a cualquier secuencia posible, así que el nodo responde
+// sin ofertas.
+func (b *Broker) secuenciaNodo(nodo *NodoInfo) (int64, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	resp, err := nodo.client.LeerOfertas(ctx, &pb.LecturaRequest{DesdeSecuencia: math.MaxInt64, TamanoPagina: 1})
+	if err != nil {
+		return 0, err
+	}
+	if !resp.GetExito() {
+		return 0, fmt.Errorf("%s rechazó la lectura", nodo.nombre)
+	}
+	return resp.GetUltimaSecuencia(), nil
+}
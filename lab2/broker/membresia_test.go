@@ -0,0 +1,60 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	pb "lab2/broker/proto"
+)
+
+// Un nodo que se reincorpora recibe las versiones posteriores a la mayor
+// secuencia que ya guardaba, sin que el broker lea el historial completo.
+func TestReincorporarNodoLeeSoloLoPosterior(t *testing.T) {
+	b, nodos := brokerPrueba(t, 0, 0, 0)
+	ctx := context.Background()
+	b.RegistrarProductor(ctx, &pb.RegistroProductorRequest{Nombre: "Riploy"})
+
+	enviar := func(desde, hasta int) {
+		for i := desde; i < hasta; i++ {
+			oferta := &pb.OfertaRequest{OfertaId: fmt.Sprintf("Riploy-%d", i), Tienda: "Riploy", Categoria: "Moda",
+				Precio: 1000, Stock: 1, Expira: time.Now().Add(time.Hour).Unix()}
+			if resp, err := b.EnviarOferta(ctx, oferta); err != nil || !resp.GetExito() {
+				t.Fatalf("oferta %d no aceptada: %v", i, err)
+			}
+		}
+	}
+	enviar(0, 3)
+	esperarCondicion(t, "DB3 tiene las primeras ofertas", func() bool { return nodos[2].cantidad() == 3 })
+
+	// DB3 se cae y se pierden sus hints: al volver solo el historial lo pone al día
+	b.registroMu.Lock()
+	b.nodos["DB3"].conn.Close()
+	b.registroMu.Unlock()
+	enviar(3, 5)
+	b.confirmarHints("DB3", b.hints.ofertas("DB3"))
+
+	nodos[2].mu.Lock()
+	vuelve := &nodoPrueba{ofertas: nodos[2].ofertas}
+	nodos[2].mu.Unlock()
+	for _, nodo := range nodos[:2] {
+		nodo.mu.Lock()
+		nodo.lecturasCompletas = 0
+		nodo.mu.Unlock()
+	}
+	resp, err := b.RegistrarNodo(ctx, &pb.RegistroNodoRequest{Nombre: "DB3", Direccion: servirPrueba(t, vuelve)})
+	if err != nil || !resp.GetExito() {
+		t.Fatalf("reincorporación de DB3: %v", err)
+	}
+	esperarCondicion(t, "DB3 queda al día", func() bool { return vuelve.cantidad() == 5 })
+
+	for _, nodo := range append(nodos[:2], vuelve) {
+		nodo.mu.Lock()
+		completas := nodo.lecturasCompletas
+		nodo.mu.Unlock()
+		if completas > 0 {
+			t.Fatalf("el broker leyó el historial completo %d veces", completas)
+		}
+	}
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// Métricas de Prometheus del broker, en /metrics de la API HTTP. Los
+// contadores del reporte siguen aparte: se persisten y replican, y estas se
+// reinician con el proceso.
+var (
+	metricaOfertasRecibidas = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "cyberday_ofertas_recibidas_total",
+		Help: "Ofertas recibidas de cada productor registrado, incluidos los reintentos.",
+	}, []string{"productor"})
+
+	metricaOfertasAceptadas = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "cyberday_ofertas_aceptadas_total",
+		Help: "Ofertas de cada productor guardadas con quorum W.",
+	}, []string{"productor"})
+
+	metricaOfertasRechazadas = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "cyberday_ofertas_rechazadas_total",
+		Help: "Ofertas rechazadas por productor y motivo.",
+	}, []string{"productor", "motivo"})
+
+	metricaEscrituraQuorum = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "cyberday_escritura_quorum_segundos",
+		Help:    "Tiempo hasta alcanzar (o descartar) el quorum W de una escritura.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"resultado"})
+
+	metricaErroresNodo = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "cyberday_nodo_errores_total",
+		Help: "Escrituras y lecturas fallidas o rechazadas por cada nodo.",
+	}, []string{"nodo", "operacion"})
+
+	metricaLatenciaNotificacion = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "cyberday_notificacion_latencia_segundos",
+		Help:    "Tiempo entre la publicación de una oferta y su entrega a un consumidor.",
+		Buckets: prometheus.ExponentialBuckets(0.005, 3, 10),
+	}, []string{"modo"})
+
+	metricaResincronizacionDuracion = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "cyberday_resincronizacion_segundos",
+		Help:    "Duración de SincronizarEntidad por tipo de entidad.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"tipo"})
+
+	metricaResincronizacionOfertas = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "cyberday_resincronizacion_ofertas",
+		Help:    "Ofertas enviadas en cada SincronizarEntidad por tipo de entidad.",
+		Buckets: prometheus.ExponentialBuckets(1, 4, 8),
+	}, []string{"tipo"})
+)
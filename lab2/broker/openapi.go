@@ -0,0 +1,166 @@
+package main
+
+import (
+	"encoding/json"
+	"strings"
+
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// documentoOpenAPI describe la API HTTP en OpenAPI 3. Los esquemas salen de
+// los descriptores compilados de proto/cyberday.proto, así el documento no se
+// desfasa cuando cambian los mensajes. Los campos usan los nombres del .proto,
+// igual que las respuestas, y los int64 van como texto, como en protojson.
+func documentoOpenAPI(rutas []rutaHTTP) ([]byte, error) {
+	esquemas := make(map[string]any)
+	rutasDoc := make(map[string]map[string]any)
+
+	for _, ruta := range rutas {
+		operacion := map[string]any{
+			"operationId": nombreRPC(ruta.rpc),
+			"summary":     ruta.resumen,
+			"responses": map[string]any{
+				"200": respuestaOpenAPI("Respuesta de "+nombreRPC(ruta.rpc), referenciaMensaje(ruta.salida, esquemas)),
+				"400": respuestaOpenAPI("Petición inválida", referencia("Error")),
+				"503": map[string]any{
+					"description": "La réplica no es el líder (ver encabezado Lider) o no se alcanzó quorum",
+					"headers": map[string]any{
+						"Lider": map[string]any{
+							"description": "Dirección gRPC del líder",
+							"schema":      map[string]any{"type": "string"},
+						},
+					},
+					"content": contenidoJSON(referencia("Error")),
+				},
+			},
+		}
+
+		var parametros []any
+		for _, nombre := range parametrosDeRuta(ruta.ruta) {
+			parametros = append(parametros, map[string]any{
+				"name":     nombre,
+				"in":       "path",
+				"required": true,
+				"schema":   map[string]any{"type": "string"},
+			})
+		}
+		for _, campo := range ruta.consulta {
+			parametro := map[string]any{
+				"name":   string(campo.Name()),
+				"in":     "query",
+				"schema": esquemaCampo(campo, esquemas),
+			}
+			if campo.IsList() {
+				parametro["description"] = "Se repite o va separado por coma"
+			}
+			parametros = append(parametros, parametro)
+		}
+		if len(parametros) > 0 {
+			operacion["parameters"] = parametros
+		}
+		if ruta.entrada != nil && ruta.consulta == nil && (ruta.metodo == "POST" || ruta.metodo == "PUT") {
+			operacion["requestBody"] = map[string]any{
+				"required": true,
+				"content":  contenidoJSON(referenciaMensaje(ruta.entrada, esquemas)),
+			}
+		}
+
+		if rutasDoc[ruta.ruta] == nil {
+			rutasDoc[ruta.ruta] = make(map[string]any)
+		}
+		rutasDoc[ruta.ruta][strings.ToLower(ruta.metodo)] = operacion
+	}
+
+	esquemas["Error"] = map[string]any{
+		"type":       "object",
+		"properties": map[string]any{"error": map[string]any{"type": "string"}},
+	}
+
+	return json.MarshalIndent(map[string]any{
+		"openapi": "3.0.3",
+		"info": map[string]any{
+			"title":   "CyberDay",
+			"version": "1",
+		},
+		"paths":      rutasDoc,
+		"components": map[string]any{"schemas": esquemas},
+	}, "", "  ")
+}
+
+// referenciaMensaje agrega a esquemas el del mensaje y los de todos los
+// mensajes y enums que usa, y retorna la referencia al primero.
+func referenciaMensaje(mensaje protoreflect.MessageDescriptor, esquemas map[string]any) map[string]any {
+	nombre := string(mensaje.Name())
+	if _, existe := esquemas[nombre]; !existe {
+		propiedades := make(map[string]any)
+		// Se reserva antes de recorrer los campos por los mensajes recursivos
+		esquemas[nombre] = nil
+		campos := mensaje.Fields()
+		for i := 0; i < campos.Len(); i++ {
+			propiedades[string(campos.Get(i).Name())] = esquemaCampo(campos.Get(i), esquemas)
+		}
+		esquemas[nombre] = map[string]any{"type": "object", "properties": propiedades}
+	}
+	return referencia(nombre)
+}
+
+func esquemaCampo(campo protoreflect.FieldDescriptor, esquemas map[string]any) map[string]any {
+	var esquema map[string]any
+	switch campo.Kind() {
+	case protoreflect.BoolKind:
+		esquema = map[string]any{"type": "boolean"}
+	case protoreflect.StringKind:
+		esquema = map[string]any{"type": "string"}
+	case protoreflect.BytesKind:
+		esquema = map[string]any{"type": "string", "format": "byte"}
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind:
+		esquema = map[string]any{"type": "integer", "format": "int32"}
+	case protoreflect.Uint32Kind, protoreflect.Fixed32Kind:
+		esquema = map[string]any{"type": "integer", "format": "int64", "minimum": 0}
+	case protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind:
+		esquema = map[string]any{"type": "string", "format": "int64"}
+	case protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		esquema = map[string]any{"type": "string", "format": "uint64"}
+	case protoreflect.FloatKind, protoreflect.DoubleKind:
+		esquema = map[string]any{"type": "number"}
+	case protoreflect.EnumKind:
+		esquema = referenciaEnum(campo.Enum(), esquemas)
+	case protoreflect.MessageKind:
+		esquema = referenciaMensaje(campo.Message(), esquemas)
+	}
+
+	if campo.IsList() {
+		return map[string]any{"type": "array", "items": esquema}
+	}
+	return esquema
+}
+
+func referenciaEnum(enum protoreflect.EnumDescriptor, esquemas map[string]any) map[string]any {
+	nombre := string(enum.Name())
+	if _, existe := esquemas[nombre]; !existe {
+		var valores []string
+		for i := 0; i < enum.Values().Len(); i++ {
+			valores = append(valores, string(enum.Values().Get(i).Name()))
+		}
+		esquemas[nombre] = map[string]any{"type": "string", "enum": valores}
+	}
+	return referencia(nombre)
+}
+
+func referencia(nombre string) map[string]any {
+	return map[string]any{"$ref": "#/components/schemas/" + nombre}
+}
+
+func respuestaOpenAPI(descripcion string, esquema map[string]any) map[string]any {
+	return map[string]any{"description": descripcion, "content": contenidoJSON(esquema)}
+}
+
+func contenidoJSON(esquema map[string]any) map[string]any {
+	return map[string]any{"application/json": map[string]any{"schema": esquema}}
+}
+
+// nombreRPC deja solo el método de un nombre completo como
+// /cyberday.CyberDayService/EnviarOferta.
+func nombreRPC(rpc string) string {
+	return rpc[strings.LastIndex(rpc, "/")+1:]
+}
@@ -0,0 +1,296 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/protobuf/encoding/protojson"
+
+	pb "lab2/broker/proto"
+)
+
+const (
+	archivoEstado = "estado.json"
+
+	intervaloPersistencia = time.Second
+)
+
+// estadoGuardado es lo que el broker deja en disco para retomar el CyberDay si
+// se reinicia: el registro de entidades, los contadores del reporte, el reloj
+// de versiones, la última secuencia asignada y los IDs confirmados de la ventana de deduplicación. El log de
+// ofertas de las suscripciones se persiste aparte, registro a registro.
+type estadoGuardado struct {
+	Inicio       bool                 `json:"inicio"`
+	Reloj        int64                `json:"reloj"`
+	Secuencia    int64                `json:"secuencia"`
+	Contadores   map[string]int64     `json:"contadores"`
+	Productores  []productorGuardado  `json:"productores"`
+	Nodos        []nodoGuardado       `json:"nodos"`
+	Consumidores []consumidorGuardado `json:"consumidores"`
+	Confirmadas  []string             `json:"confirmadas"`
+}
+
+type productorGuardado struct {
+	Nombre            string `json:"nombre"`
+	OfertasEnviadas   int64  `json:"ofertas_enviadas"`
+	OfertasAceptadas  int64  `json:"ofertas_aceptadas"`
+	OfertasDuplicadas int64  `json:"ofertas_duplicadas"`
+	Reintentos        int64  `json:"reintentos"`
+}
+
+type nodoGuardado struct {
+	Nombre           string `json:"nombre"`
+	Direccion        string `json:"direccion"`
+	OfertasReparadas int64  `json:"ofertas_reparadas"`
+	Caidas           int    `json:"caidas"`
+}
+
+// Un consumidor se restaura sin stream y vuelve a estar disponible cuando se
+// suscribe de nuevo.
+type consumidorGuardado struct {
+	ID               string          `json:"id"`
+	Filtro           json.RawMessage `json:"filtro,omitempty"`
+	OfertasRecibidas int64           `json:"ofertas_recibidas"`
+	Caidas           int             `json:"caidas"`
+}
+
+// almacenEstado serializa el guardado del estado, que puede pedirse desde la
+// goroutine periódica, desde un cambio de membresía o al finalizar.
+type almacenEstado struct {
+	ruta    string
+	mu      sync.Mutex
+	cambios chan struct{}
+}
+
+func nuevoAlmacenEstado(dir string) *almacenEstado {
+	return &almacenEstado{
+		ruta:    filepath.Join(dir, archivoEstado),
+		cambios: make(chan struct{}, 1),
+	}
+}
+
+// contadores asocia cada contador global del broker con su nombre en disco.
+func (b *Broker) contadores() map[string]*atomic.Int64 {
+	return map[string]*atomic.Int64{
+		"ofertas_recibidas":     &b.ofertasRecibidas,
+		"escrituras_exitosas":   &b.escriturasExitosas,
+		"escrituras_fallidas":   &b.escriturasFallidas,
+		"ofertas_reparadas":     &b.ofertasReparadas,
+		"reparaciones_fallidas": &b.reparacionesFallidas,
+		"hints_guardados":       &b.hintsGuardados,
+		"hints_entregados":      &b.hintsEntregados,
+		"rondas_anti_entropia":  &b.rondasAntiEntropia,
+		"buckets_divergentes":   &b.bucketsDivergentes,
+		"ofertas_anti_entropia": &b.ofertasAntiEntropia,
+		"stock_actualizado":     &b.stockActualizado,
+		"ofertas_agotadas":      &b.ofertasAgotadas,
+		"ofertas_retiradas":     &b.ofertasRetiradas,
+		"ofertas_expiradas":     &b.ofertasExpiradas,
+	}
+}
+
+// iniciarPersistencia guarda el estado en cada intervalo y cada vez que cambia
+// la membresía, para que un reinicio pierda a lo más un intervalo de métricas.
+// Con réplicas, en vez de guardarlo en disco el líder lo replica por Raft.
+func (b *Broker) iniciarPersistencia() {
+	go func() {
+		ticker := time.NewTicker(intervaloPersistencia)
+		defer ticker.Stop()
+
+		for b.sistemaActivo.Load() {
+			select {
+			case <-ticker.C:
+			case <-b.estado.cambios:
+			}
+			if b.raft != nil {
+				b.proponerEstado()
+			} else {
+				b.guardarEstado()
+			}
+		}
+	}()
+}
+
+// programarGuardado pide guardar el estado sin esperar a que termine.
+func (b *Broker) programarGuardado() {
+	select {
+	case b.estado.cambios <- struct{}{}:
+	default:
+	}
+}
+
+func (b *Broker) guardarEstado() {
+	b.estado.mu.Lock()
+	defer b.estado.mu.Unlock()
+
+	datos, err := json.MarshalIndent(b.capturarEstado(), "", "  ")
+	if err != nil {
+		log.Printf("Error serializando estado del broker: %v", err)
+		return
+	}
+
+	rutaTmp := b.estado.ruta + ".tmp"
+	if err := os.WriteFile(rutaTmp, datos, 0644); err != nil {
+		log.Printf("Error guardando estado del broker: %v", err)
+		return
+	}
+	if err := os.Rename(rutaTmp, b.estado.ruta); err != nil {
+		log.Printf("Error guardando estado del broker: %v", err)
+	}
+}
+
+func (b *Broker) capturarEstado() estadoGuardado {
+	estado := estadoGuardado{
+		Inicio:      b.inicio.Load(),
+		Reloj:       b.reloj.actual(),
+		Secuencia:   b.secuencias.actual(),
+		Contadores:  make(map[string]int64),
+		Confirmadas: b.dedup.confirmadas(),
+	}
+	for nombre, contador := range b.contadores() {
+		estado.Contadores[nombre] = contador.Load()
+	}
+
+	b.registroMu.RLock()
+	defer b.registroMu.RUnlock()
+
+	for _, prod := range b.productores {
+		estado.Productores = append(estado.Productores, productorGuardado{
+			Nombre:            prod.nombre,
+			OfertasEnviadas:   prod.ofertasEnviadas.Load(),
+			OfertasAceptadas:  prod.ofertasAceptadas.Load(),
+			OfertasDuplicadas: prod.ofertasDuplicadas.Load(),
+			Reintentos:        prod.reintentos.Load(),
+		})
+	}
+	for _, nodo := range b.nodos {
+		_, caidas := nodo.obtenerEstado()
+		estado.Nodos = append(estado.Nodos, nodoGuardado{
+			Nombre:           nodo.nombre,
+			Direccion:        nodo.direccion,
+			OfertasReparadas: nodo.ofertasReparadas.Load(),
+			Caidas:           caidas,
+		})
+	}
+	for _, consumidor := range b.consumidores {
+		_, caidas := consumidor.obtenerEstado()
+		guardado := consumidorGuardado{
+			ID:               consumidor.id_consumidor,
+			OfertasRecibidas: consumidor.ofertasRecibidas.Load(),
+			Caidas:           caidas,
+		}
+		if filtro := consumidor.obtenerFiltro().Proto(); filtro != nil {
+			guardado.Filtro, _ = protojson.Marshal(filtro)
+		}
+		estado.Consumidores = append(estado.Consumidores, guardado)
+	}
+
+	// Orden fijo, para que dos capturas del mismo estado sean iguales
+	sort.Slice(estado.Productores, func(i, j int) bool { return estado.Productores[i].Nombre < estado.Productores[j].Nombre })
+	sort.Slice(estado.Nodos, func(i, j int) bool { return estado.Nodos[i].Nombre < estado.Nodos[j].Nombre })
+	sort.Slice(estado.Consumidores, func(i, j int) bool { return estado.Consumidores[i].ID < estado.Consumidores[j].ID })
+	return estado
+}
+
+// restaurarEstado carga el estado guardado en disco. Se llama desde NewBroker,
+// antes de atender RPCs.
+func (b *Broker) restaurarEstado() error {
+	datos, err := os.ReadFile(b.estado.ruta)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return b.aplicarEstado(datos)
+}
+
+// aplicarEstado restaura un estado serializado y vuelve a conectarse a los
+// nodos y consumidores registrados. Si una entidad ya no responde, el detector
+// de fallos la dará por caída.
+func (b *Broker) aplicarEstado(datos []byte) error {
+	var estado estadoGuardado
+	if err := json.Unmarshal(datos, &estado); err != nil {
+		return fmt.Errorf("estado guardado inválido: %v", err)
+	}
+
+	b.inicio.Store(estado.Inicio)
+	b.reloj.observar(estado.Reloj)
+	b.secuencias.observar(estado.Secuencia)
+	b.dedup.restaurar(estado.Confirmadas)
+	for nombre, contador := range b.contadores() {
+		contador.Store(estado.Contadores[nombre])
+	}
+
+	b.registroMu.Lock()
+	defer b.registroMu.Unlock()
+
+	for _, guardado := range estado.Productores {
+		prod := &ProductorInfo{nombre: guardado.Nombre}
+		prod.ofertasEnviadas.Store(guardado.OfertasEnviadas)
+		prod.ofertasAceptadas.Store(guardado.OfertasAceptadas)
+		prod.ofertasDuplicadas.Store(guardado.OfertasDuplicadas)
+		prod.reintentos.Store(guardado.Reintentos)
+		b.productores[prod.nombre] = prod
+	}
+
+	for _, guardado := range estado.Nodos {
+		conn, err := grpc.Dial(guardado.Direccion, grpc.WithTransportCredentials(insecure.NewCredentials()))
+		if err != nil {
+			log.Printf("No se pudo reconectar a %s: %v", guardado.Nombre, err)
+			continue
+		}
+		nodo := &NodoInfo{
+			nombre:    guardado.Nombre,
+			direccion: guardado.Direccion,
+			conn:      conn,
+			client:    pb.NewCyberDayServiceClient(conn),
+			salud:     healthpb.NewHealthClient(conn),
+		}
+		nodo.restaurarSalud(guardado.Caidas)
+		nodo.ofertasReparadas.Store(guardado.OfertasReparadas)
+		b.nodos[nodo.nombre] = nodo
+		b.anillo.agregar(nodo.nombre)
+	}
+
+	for _, guardado := range estado.Consumidores {
+		var filtroProto *pb.FiltroOferta
+		if len(guardado.Filtro) > 0 {
+			filtroProto = &pb.FiltroOferta{}
+			if err := protojson.Unmarshal(guardado.Filtro, filtroProto); err != nil {
+				log.Printf("Filtro guardado inválido para %s: %v", guardado.ID, err)
+				continue
+			}
+		}
+		filtro, err := compilarFiltro(filtroProto, nil, nil, 0)
+		if err != nil {
+			log.Printf("Filtro guardado inválido para %s: %v", guardado.ID, err)
+			continue
+		}
+
+		consumidor := &ConsumidorInfo{
+			id_consumidor: guardado.ID,
+			archivoCSV:    fmt.Sprintf("consumidor_%s.csv", guardado.ID),
+		}
+		consumidor.filtro.Store(filtro)
+		consumidor.restaurarSalud(guardado.Caidas)
+		consumidor.ofertasRecibidas.Store(guardado.OfertasRecibidas)
+		b.consumidores[consumidor.id_consumidor] = consumidor
+		b.indice.agregar(consumidor)
+	}
+
+	log.Printf("Estado del broker recuperado: %d productores, %d nodos, %d consumidores, %d ofertas recibidas",
+		len(b.productores), len(b.nodos), len(b.consumidores), b.ofertasRecibidas.Load())
+	return nil
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"context"
+	"log"
+
+	"lab2/broker/filtros"
+	pb "lab2/broker/proto"
+)
+
+// ActualizarPreferencias cambia el filtro de un consumidor registrado. El
+// filtro nuevo se aplica antes de leer el historial: así una oferta que llega
+// durante el cambio se notifica con el filtro nuevo o aparece en el historial
+// (o ambas, y el consumidor descarta la repetida), pero no se pierde.
+func (b *Broker) ActualizarPreferencias(ctx context.Context, req *pb.ActualizacionPreferenciasRequest) (*pb.ActualizacionPreferenciasResponse, error) {
+	consumidorID := req.GetConsumidorId()
+
+	filtro, err := filtros.Compilar(req.GetFiltro())
+	if err != nil {
+		log.Printf("Filtro inválido para consumidor %s: %v", consumidorID, err)
+		return &pb.ActualizacionPreferenciasResponse{Exito: false}, nil
+	}
+
+	b.registroMu.Lock()
+	consumidor, existe := b.consumidores[consumidorID]
+	if !existe {
+		b.registroMu.Unlock()
+		log.Printf("Consumidor %s no encontrado para actualizar preferencias", consumidorID)
+		return &pb.ActualizacionPreferenciasResponse{Exito: false}, nil
+	}
+	b.indice.quitar(consumidorID)
+	anterior := consumidor.filtro.Swap(filtro)
+	b.indice.agregar(consumidor)
+	b.registroMu.Unlock()
+	b.programarGuardado()
+
+	log.Printf("Consumidor %s actualizó sus preferencias", consumidorID)
+	log.Printf("-Filtro: %s", filtro)
+
+	if b.ofertasRecibidas.Load() == 0 {
+		return &pb.ActualizacionPreferenciasResponse{Exito: true, Resincronizado: true}, nil
+	}
+
+	historial := b.obtenerHistorialOfertas(filtro.Lectura())
+	if historial == nil {
+		log.Printf("No se pudo resincronizar a %s - No se alcanzó quorum R=%d", consumidorID, b.quorum.R)
+		return &pb.ActualizacionPreferenciasResponse{Exito: true, Resincronizado: false}, nil
+	}
+
+	var nuevas []*pb.OfertaRequest
+	for _, oferta := range historial {
+		if oferta.GetEstado() == pb.EstadoOferta_VIGENTE && filtro.Coincide(oferta) && !anterior.Coincide(oferta) {
+			nuevas = append(nuevas, oferta)
+		}
+	}
+	consumidor.ofertasRecibidas.Add(int64(len(nuevas)))
+
+	log.Printf("Consumidor %s resincronizado: +%d ofertas", consumidorID, len(nuevas))
+	return &pb.ActualizacionPreferenciasResponse{
+		Exito:          true,
+		Resincronizado: true,
+		Ofertas:        nuevas,
+	}, nil
+}
+
+// DarDeBajaConsumidor saca al consumidor del registro y corta su conexión. A
+// diferencia de una caída, no queda esperando que vuelva.
+func (b *Broker) DarDeBajaConsumidor(ctx context.Context, req *pb.BajaConsumidorRequest) (*pb.RegistroResponse, error) {
+	consumidorID := req.GetConsumidorId()
+
+	b.registroMu.Lock()
+	defer b.registroMu.Unlock()
+
+	if !b.quitarConsumidor(consumidorID) {
+		log.Printf("Consumidor %s no encontrado para darlo de baja", consumidorID)
+		return &pb.RegistroResponse{Exito: false}, nil
+	}
+
+	log.Printf("Consumidor %s se dio de baja", consumidorID)
+	b.verificarInicio()
+	return &pb.RegistroResponse{Exito: true}, nil
+}
+
+// quitarConsumidor elimina al consumidor del registro y del índice. Se llama
+// con registroMu tomado.
+func (b *Broker) quitarConsumidor(consumidorID string) bool {
+	consumidor, existe := b.consumidores[consumidorID]
+	if !existe {
+		return false
+	}
+	delete(b.consumidores, consumidorID)
+	b.indice.quitar(consumidorID)
+	consumidor.cerrar()
+	return true
+}
+
+func (c *ConsumidorInfo) obtenerFiltro() *filtros.Filtro {
+	return c.filtro.Load()
+}
@@ -19,14 +19,28 @@ import (
 const _ = grpc.SupportPackageIsVersion9
 
 const (
-	CyberDayService_RegistrarProductor_FullMethodName  = "/cyberday.CyberDayService/RegistrarProductor"
-	CyberDayService_RegistrarNodo_FullMethodName       = "/cyberday.CyberDayService/RegistrarNodo"
-	CyberDayService_RegistrarConsumidor_FullMethodName = "/cyberday.CyberDayService/RegistrarConsumidor"
-	CyberDayService_SolicitarInicio_FullMethodName     = "/cyberday.CyberDayService/SolicitarInicio"
-	CyberDayService_EnviarOferta_FullMethodName        = "/cyberday.CyberDayService/EnviarOferta"
-	CyberDayService_SincronizarEntidad_FullMethodName  = "/cyberday.CyberDayService/SincronizarEntidad"
-	CyberDayService_LeerOfertas_FullMethodName         = "/cyberday.CyberDayService/LeerOfertas"
-	CyberDayService_ConsultarEstado_FullMethodName     = "/cyberday.CyberDayService/ConsultarEstado"
+	CyberDayService_RegistrarProductor_FullMethodName     = "/cyberday.CyberDayService/RegistrarProductor"
+	CyberDayService_RegistrarNodo_FullMethodName          = "/cyberday.CyberDayService/RegistrarNodo"
+	CyberDayService_RegistrarConsumidor_FullMethodName    = "/cyberday.CyberDayService/RegistrarConsumidor"
+	CyberDayService_AbandonarCluster_FullMethodName       = "/cyberday.CyberDayService/AbandonarCluster"
+	CyberDayService_ActualizarPreferencias_FullMethodName = "/cyberday.CyberDayService/ActualizarPreferencias"
+	CyberDayService_DarDeBajaConsumidor_FullMethodName    = "/cyberday.CyberDayService/DarDeBajaConsumidor"
+	CyberDayService_SolicitarInicio_FullMethodName        = "/cyberday.CyberDayService/SolicitarInicio"
+	CyberDayService_EnviarOferta_FullMethodName           = "/cyberday.CyberDayService/EnviarOferta"
+	CyberDayService_ActualizarStock_FullMethodName        = "/cyberday.CyberDayService/ActualizarStock"
+	CyberDayService_RetirarOferta_FullMethodName          = "/cyberday.CyberDayService/RetirarOferta"
+	CyberDayService_SincronizarEntidad_FullMethodName     = "/cyberday.CyberDayService/SincronizarEntidad"
+	CyberDayService_ConfirmarHints_FullMethodName         = "/cyberday.CyberDayService/ConfirmarHints"
+	CyberDayService_LeerOfertas_FullMethodName            = "/cyberday.CyberDayService/LeerOfertas"
+	CyberDayService_LeerOferta_FullMethodName             = "/cyberday.CyberDayService/LeerOferta"
+	CyberDayService_BuscarOfertas_FullMethodName          = "/cyberday.CyberDayService/BuscarOfertas"
+	CyberDayService_ObtenerHashesMerkle_FullMethodName    = "/cyberday.CyberDayService/ObtenerHashesMerkle"
+	CyberDayService_LeerBuckets_FullMethodName            = "/cyberday.CyberDayService/LeerBuckets"
+	CyberDayService_Suscribir_FullMethodName              = "/cyberday.CyberDayService/Suscribir"
+	CyberDayService_SolicitarVoto_FullMethodName          = "/cyberday.CyberDayService/SolicitarVoto"
+	CyberDayService_AgregarEntradas_FullMethodName        = "/cyberday.CyberDayService/AgregarEntradas"
+	CyberDayService_InstalarSnapshot_FullMethodName       = "/cyberday.CyberDayService/InstalarSnapshot"
+	CyberDayService_ConsultarEstado_FullMethodName        = "/cyberday.CyberDayService/ConsultarEstado"
 )
 
 // CyberDayServiceClient is the client API for CyberDayService service.
@@ -37,14 +51,35 @@ type CyberDayServiceClient interface {
 	RegistrarProductor(ctx context.Context, in *RegistroProductorRequest, opts ...grpc.CallOption) (*RegistroResponse, error)
 	RegistrarNodo(ctx context.Context, in *RegistroNodoRequest, opts ...grpc.CallOption) (*RegistroResponse, error)
 	RegistrarConsumidor(ctx context.Context, in *RegistroConsumidorRequest, opts ...grpc.CallOption) (*RegistroResponse, error)
+	//Salida voluntaria del cluster (entidades -> broker)
+	AbandonarCluster(ctx context.Context, in *SalidaRequest, opts ...grpc.CallOption) (*RegistroResponse, error)
+	//Cambio de filtros y baja de un consumidor (consumidor -> broker)
+	ActualizarPreferencias(ctx context.Context, in *ActualizacionPreferenciasRequest, opts ...grpc.CallOption) (*ActualizacionPreferenciasResponse, error)
+	DarDeBajaConsumidor(ctx context.Context, in *BajaConsumidorRequest, opts ...grpc.CallOption) (*RegistroResponse, error)
 	//Inicio de envio de ofertas (nodos -> broker)
 	SolicitarInicio(ctx context.Context, in *InicioRequest, opts ...grpc.CallOption) (*InicioResponse, error)
 	//Envio de ofertas (consumidor -> broker, broker -> nodo, broker -> consumidor)
 	EnviarOferta(ctx context.Context, in *OfertaRequest, opts ...grpc.CallOption) (*OfertaResponse, error)
+	//Ciclo de vida (consumidor -> broker, productor -> broker)
+	ActualizarStock(ctx context.Context, in *ActualizacionStockRequest, opts ...grpc.CallOption) (*CambioOfertaResponse, error)
+	RetirarOferta(ctx context.Context, in *RetiroOfertaRequest, opts ...grpc.CallOption) (*CambioOfertaResponse, error)
 	//Sincronizacion (Nodo -> broker, Consumidores -> broker)
 	SincronizarEntidad(ctx context.Context, in *SincronizacionRequest, opts ...grpc.CallOption) (*SincronizacionResponse, error)
+	ConfirmarHints(ctx context.Context, in *ConfirmacionHintsRequest, opts ...grpc.CallOption) (*RegistroResponse, error)
 	//Lectura de ofertas (broker -> nodos)
 	LeerOfertas(ctx context.Context, in *LecturaRequest, opts ...grpc.CallOption) (*LecturaResponse, error)
+	LeerOferta(ctx context.Context, in *LecturaOfertaRequest, opts ...grpc.CallOption) (*LecturaResponse, error)
+	//Búsqueda de ofertas (cualquier cliente -> broker)
+	BuscarOfertas(ctx context.Context, in *BusquedaRequest, opts ...grpc.CallOption) (*BusquedaResponse, error)
+	//Anti-entropía entre réplicas (broker -> nodos)
+	ObtenerHashesMerkle(ctx context.Context, in *HashesMerkleRequest, opts ...grpc.CallOption) (*HashesMerkleResponse, error)
+	LeerBuckets(ctx context.Context, in *LecturaBucketsRequest, opts ...grpc.CallOption) (*LecturaResponse, error)
+	//Suscripción a ofertas por stream (consumidor -> broker)
+	Suscribir(ctx context.Context, in *SuscripcionRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[NotificacionOferta], error)
+	//Elección de líder y replicación (broker -> broker)
+	SolicitarVoto(ctx context.Context, in *SolicitudVotoRequest, opts ...grpc.CallOption) (*SolicitudVotoResponse, error)
+	AgregarEntradas(ctx context.Context, in *AgregarEntradasRequest, opts ...grpc.CallOption) (*AgregarEntradasResponse, error)
+	InstalarSnapshot(ctx context.Context, in *InstalarSnapshotRequest, opts ...grpc.CallOption) (*AgregarEntradasResponse, error)
 	//Shutdown (productores -> broker)
 	ConsultarEstado(ctx context.Context, in *ConsultarEstadoRequest, opts ...grpc.CallOption) (*ConsultarEstadoResponse, error)
 }
@@ -87,6 +122,36 @@ func (c *cyberDayServiceClient) RegistrarConsumidor(ctx context.Context, in *Reg
 	return out, nil
 }
 
+func (c *cyberDayServiceClient) AbandonarCluster(ctx context.Context, in *SalidaRequest, opts ...grpc.CallOption) (*RegistroResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(RegistroResponse)
+	err := c.cc.Invoke(ctx, CyberDayService_AbandonarCluster_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *cyberDayServiceClient) ActualizarPreferencias(ctx context.Context, in *ActualizacionPreferenciasRequest, opts ...grpc.CallOption) (*ActualizacionPreferenciasResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ActualizacionPreferenciasResponse)
+	err := c.cc.Invoke(ctx, CyberDayService_ActualizarPreferencias_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *cyberDayServiceClient) DarDeBajaConsumidor(ctx context.Context, in *BajaConsumidorRequest, opts ...grpc.CallOption) (*RegistroResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(RegistroResponse)
+	err := c.cc.Invoke(ctx, CyberDayService_DarDeBajaConsumidor_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *cyberDayServiceClient) SolicitarInicio(ctx context.Context, in *InicioRequest, opts ...grpc.CallOption) (*InicioResponse, error) {
 	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
 	out := new(InicioResponse)
@@ -107,6 +172,26 @@ func (c *cyberDayServiceClient) EnviarOferta(ctx context.Context, in *OfertaRequ
 	return out, nil
 }
 
+func (c *cyberDayServiceClient) ActualizarStock(ctx context.Context, in *ActualizacionStockRequest, opts ...grpc.CallOption) (*CambioOfertaResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(CambioOfertaResponse)
+	err := c.cc.Invoke(ctx, CyberDayService_ActualizarStock_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *cyberDayServiceClient) RetirarOferta(ctx context.Context, in *RetiroOfertaRequest, opts ...grpc.CallOption) (*CambioOfertaResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(CambioOfertaResponse)
+	err := c.cc.Invoke(ctx, CyberDayService_RetirarOferta_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *cyberDayServiceClient) SincronizarEntidad(ctx context.Context, in *SincronizacionRequest, opts ...grpc.CallOption) (*SincronizacionResponse, error) {
 	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
 	out := new(SincronizacionResponse)
@@ -117,6 +202,16 @@ func (c *cyberDayServiceClient) SincronizarEntidad(ctx context.Context, in *Sinc
 	return out, nil
 }
 
+func (c *cyberDayServiceClient) ConfirmarHints(ctx context.Context, in *ConfirmacionHintsRequest, opts ...grpc.CallOption) (*RegistroResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(RegistroResponse)
+	err := c.cc.Invoke(ctx, CyberDayService_ConfirmarHints_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *cyberDayServiceClient) LeerOfertas(ctx context.Context, in *LecturaRequest, opts ...grpc.CallOption) (*LecturaResponse, error) {
 	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
 	out := new(LecturaResponse)
@@ -127,6 +222,95 @@ func (c *cyberDayServiceClient) LeerOfertas(ctx context.Context, in *LecturaRequ
 	return out, nil
 }
 
+func (c *cyberDayServiceClient) LeerOferta(ctx context.Context, in *LecturaOfertaRequest, opts ...grpc.CallOption) (*LecturaResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(LecturaResponse)
+	err := c.cc.Invoke(ctx, CyberDayService_LeerOferta_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *cyberDayServiceClient) BuscarOfertas(ctx context.Context, in *BusquedaRequest, opts ...grpc.CallOption) (*BusquedaResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(BusquedaResponse)
+	err := c.cc.Invoke(ctx, CyberDayService_BuscarOfertas_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *cyberDayServiceClient) ObtenerHashesMerkle(ctx context.Context, in *HashesMerkleRequest, opts ...grpc.CallOption) (*HashesMerkleResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(HashesMerkleResponse)
+	err := c.cc.Invoke(ctx, CyberDayService_ObtenerHashesMerkle_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *cyberDayServiceClient) LeerBuckets(ctx context.Context, in *LecturaBucketsRequest, opts ...grpc.CallOption) (*LecturaResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(LecturaResponse)
+	err := c.cc.Invoke(ctx, CyberDayService_LeerBuckets_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *cyberDayServiceClient) Suscribir(ctx context.Context, in *SuscripcionRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[NotificacionOferta], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &CyberDayService_ServiceDesc.Streams[0], CyberDayService_Suscribir_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[SuscripcionRequest, NotificacionOferta]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type CyberDayService_SuscribirClient = grpc.ServerStreamingClient[NotificacionOferta]
+
+func (c *cyberDayServiceClient) SolicitarVoto(ctx context.Context, in *SolicitudVotoRequest, opts ...grpc.CallOption) (*SolicitudVotoResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(SolicitudVotoResponse)
+	err := c.cc.Invoke(ctx, CyberDayService_SolicitarVoto_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *cyberDayServiceClient) AgregarEntradas(ctx context.Context, in *AgregarEntradasRequest, opts ...grpc.CallOption) (*AgregarEntradasResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(AgregarEntradasResponse)
+	err := c.cc.Invoke(ctx, CyberDayService_AgregarEntradas_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *cyberDayServiceClient) InstalarSnapshot(ctx context.Context, in *InstalarSnapshotRequest, opts ...grpc.CallOption) (*AgregarEntradasResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(AgregarEntradasResponse)
+	err := c.cc.Invoke(ctx, CyberDayService_InstalarSnapshot_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *cyberDayServiceClient) ConsultarEstado(ctx context.Context, in *ConsultarEstadoRequest, opts ...grpc.CallOption) (*ConsultarEstadoResponse, error) {
 	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
 	out := new(ConsultarEstadoResponse)
@@ -145,14 +329,35 @@ type CyberDayServiceServer interface {
 	RegistrarProductor(context.Context, *RegistroProductorRequest) (*RegistroResponse, error)
 	RegistrarNodo(context.Context, *RegistroNodoRequest) (*RegistroResponse, error)
 	RegistrarConsumidor(context.Context, *RegistroConsumidorRequest) (*RegistroResponse, error)
+	//Salida voluntaria del cluster (entidades -> broker)
+	AbandonarCluster(context.Context, *SalidaRequest) (*RegistroResponse, error)
+	//Cambio de filtros y baja de un consumidor (consumidor -> broker)
+	ActualizarPreferencias(context.Context, *ActualizacionPreferenciasRequest) (*ActualizacionPreferenciasResponse, error)
+	DarDeBajaConsumidor(context.Context, *BajaConsumidorRequest) (*RegistroResponse, error)
 	//Inicio de envio de ofertas (nodos -> broker)
 	SolicitarInicio(context.Context, *InicioRequest) (*InicioResponse, error)
 	//Envio de ofertas (consumidor -> broker, broker -> nodo, broker -> consumidor)
 	EnviarOferta(context.Context, *OfertaRequest) (*OfertaResponse, error)
+	//Ciclo de vida (consumidor -> broker, productor -> broker)
+	ActualizarStock(context.Context, *ActualizacionStockRequest) (*CambioOfertaResponse, error)
+	RetirarOferta(context.Context, *RetiroOfertaRequest) (*CambioOfertaResponse, error)
 	//Sincronizacion (Nodo -> broker, Consumidores -> broker)
 	SincronizarEntidad(context.Context, *SincronizacionRequest) (*SincronizacionResponse, error)
+	ConfirmarHints(context.Context, *ConfirmacionHintsRequest) (*RegistroResponse, error)
 	//Lectura de ofertas (broker -> nodos)
 	LeerOfertas(context.Context, *LecturaRequest) (*LecturaResponse, error)
+	LeerOferta(context.Context, *LecturaOfertaRequest) (*LecturaResponse, error)
+	//Búsqueda de ofertas (cualquier cliente -> broker)
+	BuscarOfertas(context.Context, *BusquedaRequest) (*BusquedaResponse, error)
+	//Anti-entropía entre réplicas (broker -> nodos)
+	ObtenerHashesMerkle(context.Context, *HashesMerkleRequest) (*HashesMerkleResponse, error)
+	LeerBuckets(context.Context, *LecturaBucketsRequest) (*LecturaResponse, error)
+	//Suscripción a ofertas por stream (consumidor -> broker)
+	Suscribir(*SuscripcionRequest, grpc.ServerStreamingServer[NotificacionOferta]) error
+	//Elección de líder y replicación (broker -> broker)
+	SolicitarVoto(context.Context, *SolicitudVotoRequest) (*SolicitudVotoResponse, error)
+	AgregarEntradas(context.Context, *AgregarEntradasRequest) (*AgregarEntradasResponse, error)
+	InstalarSnapshot(context.Context, *InstalarSnapshotRequest) (*AgregarEntradasResponse, error)
 	//Shutdown (productores -> broker)
 	ConsultarEstado(context.Context, *ConsultarEstadoRequest) (*ConsultarEstadoResponse, error)
 	mustEmbedUnimplementedCyberDayServiceServer()
@@ -174,18 +379,60 @@ func (UnimplementedCyberDayServiceServer) RegistrarNodo(context.Context, *Regist
 func (UnimplementedCyberDayServiceServer) RegistrarConsumidor(context.Context, *RegistroConsumidorRequest) (*RegistroResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method RegistrarConsumidor not implemented")
 }
+func (UnimplementedCyberDayServiceServer) AbandonarCluster(context.Context, *SalidaRequest) (*RegistroResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method AbandonarCluster not implemented")
+}
+func (UnimplementedCyberDayServiceServer) ActualizarPreferencias(context.Context, *ActualizacionPreferenciasRequest) (*ActualizacionPreferenciasResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ActualizarPreferencias not implemented")
+}
+func (UnimplementedCyberDayServiceServer) DarDeBajaConsumidor(context.Context, *BajaConsumidorRequest) (*RegistroResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DarDeBajaConsumidor not implemented")
+}
 func (UnimplementedCyberDayServiceServer) SolicitarInicio(context.Context, *InicioRequest) (*InicioResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method SolicitarInicio not implemented")
 }
 func (UnimplementedCyberDayServiceServer) EnviarOferta(context.Context, *OfertaRequest) (*OfertaResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method EnviarOferta not implemented")
 }
+func (UnimplementedCyberDayServiceServer) ActualizarStock(context.Context, *ActualizacionStockRequest) (*CambioOfertaResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ActualizarStock not implemented")
+}
+func (UnimplementedCyberDayServiceServer) RetirarOferta(context.Context, *RetiroOfertaRequest) (*CambioOfertaResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RetirarOferta not implemented")
+}
 func (UnimplementedCyberDayServiceServer) SincronizarEntidad(context.Context, *SincronizacionRequest) (*SincronizacionResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method SincronizarEntidad not implemented")
 }
+func (UnimplementedCyberDayServiceServer) ConfirmarHints(context.Context, *ConfirmacionHintsRequest) (*RegistroResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ConfirmarHints not implemented")
+}
 func (UnimplementedCyberDayServiceServer) LeerOfertas(context.Context, *LecturaRequest) (*LecturaResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method LeerOfertas not implemented")
 }
+func (UnimplementedCyberDayServiceServer) LeerOferta(context.Context, *LecturaOfertaRequest) (*LecturaResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method LeerOferta not implemented")
+}
+func (UnimplementedCyberDayServiceServer) BuscarOfertas(context.Context, *BusquedaRequest) (*BusquedaResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method BuscarOfertas not implemented")
+}
+func (UnimplementedCyberDayServiceServer) ObtenerHashesMerkle(context.Context, *HashesMerkleRequest) (*HashesMerkleResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ObtenerHashesMerkle not implemented")
+}
+func (UnimplementedCyberDayServiceServer) LeerBuckets(context.Context, *LecturaBucketsRequest) (*LecturaResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method LeerBuckets not implemented")
+}
+func (UnimplementedCyberDayServiceServer) Suscribir(*SuscripcionRequest, grpc.ServerStreamingServer[NotificacionOferta]) error {
+	return status.Errorf(codes.Unimplemented, "method Suscribir not implemented")
+}
+func (UnimplementedCyberDayServiceServer) SolicitarVoto(context.Context, *SolicitudVotoRequest) (*SolicitudVotoResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SolicitarVoto not implemented")
+}
+func (UnimplementedCyberDayServiceServer) AgregarEntradas(context.Context, *AgregarEntradasRequest) (*AgregarEntradasResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method AgregarEntradas not implemented")
+}
+func (UnimplementedCyberDayServiceServer) InstalarSnapshot(context.Context, *InstalarSnapshotRequest) (*AgregarEntradasResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method InstalarSnapshot not implemented")
+}
 func (UnimplementedCyberDayServiceServer) ConsultarEstado(context.Context, *ConsultarEstadoRequest) (*ConsultarEstadoResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method ConsultarEstado not implemented")
 }
@@ -264,6 +511,60 @@ func _CyberDayService_RegistrarConsumidor_Handler(srv interface{}, ctx context.C
 	return interceptor(ctx, in, info, handler)
 }
 
+func _CyberDayService_AbandonarCluster_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SalidaRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CyberDayServiceServer).AbandonarCluster(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: CyberDayService_AbandonarCluster_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CyberDayServiceServer).AbandonarCluster(ctx, req.(*SalidaRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CyberDayService_ActualizarPreferencias_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ActualizacionPreferenciasRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CyberDayServiceServer).ActualizarPreferencias(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: CyberDayService_ActualizarPreferencias_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CyberDayServiceServer).ActualizarPreferencias(ctx, req.(*ActualizacionPreferenciasRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CyberDayService_DarDeBajaConsumidor_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(BajaConsumidorRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CyberDayServiceServer).DarDeBajaConsumidor(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: CyberDayService_DarDeBajaConsumidor_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CyberDayServiceServer).DarDeBajaConsumidor(ctx, req.(*BajaConsumidorRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _CyberDayService_SolicitarInicio_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(InicioRequest)
 	if err := dec(in); err != nil {
@@ -300,6 +601,42 @@ func _CyberDayService_EnviarOferta_Handler(srv interface{}, ctx context.Context,
 	return interceptor(ctx, in, info, handler)
 }
 
+func _CyberDayService_ActualizarStock_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ActualizacionStockRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CyberDayServiceServer).ActualizarStock(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: CyberDayService_ActualizarStock_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CyberDayServiceServer).ActualizarStock(ctx, req.(*ActualizacionStockRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CyberDayService_RetirarOferta_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RetiroOfertaRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CyberDayServiceServer).RetirarOferta(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: CyberDayService_RetirarOferta_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CyberDayServiceServer).RetirarOferta(ctx, req.(*RetiroOfertaRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _CyberDayService_SincronizarEntidad_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(SincronizacionRequest)
 	if err := dec(in); err != nil {
@@ -318,6 +655,24 @@ func _CyberDayService_SincronizarEntidad_Handler(srv interface{}, ctx context.Co
 	return interceptor(ctx, in, info, handler)
 }
 
+func _CyberDayService_ConfirmarHints_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ConfirmacionHintsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CyberDayServiceServer).ConfirmarHints(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: CyberDayService_ConfirmarHints_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CyberDayServiceServer).ConfirmarHints(ctx, req.(*ConfirmacionHintsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _CyberDayService_LeerOfertas_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(LecturaRequest)
 	if err := dec(in); err != nil {
@@ -336,6 +691,143 @@ func _CyberDayService_LeerOfertas_Handler(srv interface{}, ctx context.Context,
 	return interceptor(ctx, in, info, handler)
 }
 
+func _CyberDayService_LeerOferta_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(LecturaOfertaRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CyberDayServiceServer).LeerOferta(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: CyberDayService_LeerOferta_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CyberDayServiceServer).LeerOferta(ctx, req.(*LecturaOfertaRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CyberDayService_BuscarOfertas_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(BusquedaRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CyberDayServiceServer).BuscarOfertas(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: CyberDayService_BuscarOfertas_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CyberDayServiceServer).BuscarOfertas(ctx, req.(*BusquedaRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CyberDayService_ObtenerHashesMerkle_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(HashesMerkleRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CyberDayServiceServer).ObtenerHashesMerkle(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: CyberDayService_ObtenerHashesMerkle_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CyberDayServiceServer).ObtenerHashesMerkle(ctx, req.(*HashesMerkleRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CyberDayService_LeerBuckets_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(LecturaBucketsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CyberDayServiceServer).LeerBuckets(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: CyberDayService_LeerBuckets_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CyberDayServiceServer).LeerBuckets(ctx, req.(*LecturaBucketsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CyberDayService_Suscribir_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(SuscripcionRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(CyberDayServiceServer).Suscribir(m, &grpc.GenericServerStream[SuscripcionRequest, NotificacionOferta]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type CyberDayService_SuscribirServer = grpc.ServerStreamingServer[NotificacionOferta]
+
+func _CyberDayService_SolicitarVoto_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SolicitudVotoRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CyberDayServiceServer).SolicitarVoto(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: CyberDayService_SolicitarVoto_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CyberDayServiceServer).SolicitarVoto(ctx, req.(*SolicitudVotoRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CyberDayService_AgregarEntradas_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AgregarEntradasRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CyberDayServiceServer).AgregarEntradas(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: CyberDayService_AgregarEntradas_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CyberDayServiceServer).AgregarEntradas(ctx, req.(*AgregarEntradasRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CyberDayService_InstalarSnapshot_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(InstalarSnapshotRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CyberDayServiceServer).InstalarSnapshot(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: CyberDayService_InstalarSnapshot_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CyberDayServiceServer).InstalarSnapshot(ctx, req.(*InstalarSnapshotRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _CyberDayService_ConsultarEstado_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(ConsultarEstadoRequest)
 	if err := dec(in); err != nil {
@@ -373,6 +865,18 @@ var CyberDayService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "RegistrarConsumidor",
 			Handler:    _CyberDayService_RegistrarConsumidor_Handler,
 		},
+		{
+			MethodName: "AbandonarCluster",
+			Handler:    _CyberDayService_AbandonarCluster_Handler,
+		},
+		{
+			MethodName: "ActualizarPreferencias",
+			Handler:    _CyberDayService_ActualizarPreferencias_Handler,
+		},
+		{
+			MethodName: "DarDeBajaConsumidor",
+			Handler:    _CyberDayService_DarDeBajaConsumidor_Handler,
+		},
 		{
 			MethodName: "SolicitarInicio",
 			Handler:    _CyberDayService_SolicitarInicio_Handler,
@@ -381,19 +885,65 @@ var CyberDayService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "EnviarOferta",
 			Handler:    _CyberDayService_EnviarOferta_Handler,
 		},
+		{
+			MethodName: "ActualizarStock",
+			Handler:    _CyberDayService_ActualizarStock_Handler,
+		},
+		{
+			MethodName: "RetirarOferta",
+			Handler:    _CyberDayService_RetirarOferta_Handler,
+		},
 		{
 			MethodName: "SincronizarEntidad",
 			Handler:    _CyberDayService_SincronizarEntidad_Handler,
 		},
+		{
+			MethodName: "ConfirmarHints",
+			Handler:    _CyberDayService_ConfirmarHints_Handler,
+		},
 		{
 			MethodName: "LeerOfertas",
 			Handler:    _CyberDayService_LeerOfertas_Handler,
 		},
+		{
+			MethodName: "LeerOferta",
+			Handler:    _CyberDayService_LeerOferta_Handler,
+		},
+		{
+			MethodName: "BuscarOfertas",
+			Handler:    _CyberDayService_BuscarOfertas_Handler,
+		},
+		{
+			MethodName: "ObtenerHashesMerkle",
+			Handler:    _CyberDayService_ObtenerHashesMerkle_Handler,
+		},
+		{
+			MethodName: "LeerBuckets",
+			Handler:    _CyberDayService_LeerBuckets_Handler,
+		},
+		{
+			MethodName: "SolicitarVoto",
+			Handler:    _CyberDayService_SolicitarVoto_Handler,
+		},
+		{
+			MethodName: "AgregarEntradas",
+			Handler:    _CyberDayService_AgregarEntradas_Handler,
+		},
+		{
+			MethodName: "InstalarSnapshot",
+			Handler:    _CyberDayService_InstalarSnapshot_Handler,
+		},
 		{
 			MethodName: "ConsultarEstado",
 			Handler:    _CyberDayService_ConsultarEstado_Handler,
 		},
 	},
-	Streams:  []grpc.StreamDesc{},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "Suscribir",
+			Handler:       _CyberDayService_Suscribir_Handler,
+			ServerStreams: true,
+		},
+	},
 	Metadata: "proto/cyberday.proto",
 }
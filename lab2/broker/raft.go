@@ -0,0 +1,964 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/protobuf/proto"
+
+	pb "lab2/broker/proto"
+	"lab2/registros"
+)
+
+const (
+	archivoTerminoRaft  = "raft.json"
+	archivoLogRaft      = "raft.log"
+	archivoSnapshotRaft = "raft.snapshot"
+
+	latidoRaft          = 100 * time.Millisecond
+	eleccionMinRaft     = 600 * time.Millisecond
+	eleccionMaxRaft     = 1200 * time.Millisecond
+	timeoutRPCRaft      = 300 * time.Millisecond
+	timeoutPropuesta    = 3 * time.Second
+	timeoutSnapshotRaft = 5 * time.Second
+	maxEntradasPorEnvio = 256
+
+	// Un snapshot viaja en un solo mensaje, así que el broker acepta mensajes
+	// más grandes que el límite por defecto de gRPC
+	maxMensajeRaft = 64 << 20
+
+	// Cada tantas entradas aplicadas se guarda un snapshot y se descartan del
+	// log, para que no crezca sin límite
+	entradasPorSnapshot = 1000
+)
+
+var errNoLider = errors.New("esta réplica no es el líder")
+
+type rolRaft int
+
+const (
+	seguidor rolRaft = iota
+	candidato
+	lider
+)
+
+func (r rolRaft) String() string {
+	switch r {
+	case seguidor:
+		return "SEGUIDOR"
+	case candidato:
+		return "CANDIDATO"
+	case lider:
+		return "LÍDER"
+	}
+	return "DESCONOCIDO"
+}
+
+// terminoGuardado es lo que Raft exige persistir antes de responder: el
+// término actual y a quién se le dio el voto en él.
+type terminoGuardado struct {
+	Termino int64  `json:"termino"`
+	VotoPor string `json:"voto_por"`
+}
+
+// nodoRaft implementa la elección de líder y la replicación de log de Raft
+// entre las réplicas del broker. Las entradas confirmadas por una mayoría se
+// entregan en orden a aplicar en todas las réplicas. El líder solo atiende a
+// los clientes cuando ya aplicó una entrada de su propio término: en ese
+// momento llama a asumir, y si pierde el liderazgo llama a dejar.
+//
+// Cada entradasPorSnapshot entradas aplicadas se guarda lo que entrega
+// capturar y se descartan del log. Una réplica que recibe un snapshot más
+// nuevo que lo que aplicó lo entrega a restaurar.
+type nodoRaft struct {
+	id          string
+	direcciones map[string]string
+	pares       map[string]pb.CyberDayServiceClient
+	conexiones  []*grpc.ClientConn
+	dir         string
+
+	aplicar   func(*pb.EntradaRaft)
+	capturar  func() ([]byte, error)
+	restaurar func([]byte)
+	asumir    func()
+	dejar     func()
+
+	mu              sync.Mutex
+	rol             rolRaft
+	termino         int64
+	votoPor         string
+	lider           string
+	entradas        []*pb.EntradaRaft // la entrada de índice i está en entradas[i-base-1]
+	base            int64             // último índice incluido en el snapshot
+	terminoBase     int64
+	snapshot        []byte
+	limiteLog       int64
+	confirmado      int64
+	aplicado        int64
+	siguiente       map[string]int64
+	coincide        map[string]int64
+	enVuelo         map[string]bool
+	ultimaRespuesta map[string]time.Time
+	ultimoContacto  time.Time
+	timeoutEleccion time.Duration
+	inicioTermino   int64
+	listo           bool
+	cambio          chan struct{}
+	fin             chan struct{}
+	archivoLog      *os.File
+}
+
+func abrirRaft(config ConfigReplicacion, dir string, aplicar func(*pb.EntradaRaft),
+	capturar func() ([]byte, error), restaurar func([]byte), asumir, dejar func()) (*nodoRaft, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("no se pudo crear directorio %s: %v", dir, err)
+	}
+
+	r := &nodoRaft{
+		id:              config.ID,
+		direcciones:     config.Pares,
+		pares:           make(map[string]pb.CyberDayServiceClient),
+		dir:             dir,
+		aplicar:         aplicar,
+		capturar:        capturar,
+		restaurar:       restaurar,
+		asumir:          asumir,
+		dejar:           dejar,
+		limiteLog:       entradasPorSnapshot,
+		siguiente:       make(map[string]int64),
+		coincide:        make(map[string]int64),
+		enVuelo:         make(map[string]bool),
+		ultimaRespuesta: make(map[string]time.Time),
+		cambio:          make(chan struct{}),
+		fin:             make(chan struct{}),
+	}
+
+	for id, direccion := range config.Pares {
+		if id == config.ID {
+			continue
+		}
+		conn, err := grpc.Dial(direccion, grpc.WithTransportCredentials(insecure.NewCredentials()))
+		if err != nil {
+			return nil, fmt.Errorf("no se pudo conectar a la réplica %s: %v", id, err)
+		}
+		r.pares[id] = pb.NewCyberDayServiceClient(conn)
+		r.conexiones = append(r.conexiones, conn)
+	}
+
+	datos, err := os.ReadFile(filepath.Join(dir, archivoTerminoRaft))
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+	if err == nil {
+		var guardado terminoGuardado
+		if err := json.Unmarshal(datos, &guardado); err != nil {
+			return nil, fmt.Errorf("término de Raft inválido: %v", err)
+		}
+		r.termino, r.votoPor = guardado.Termino, guardado.VotoPor
+	}
+
+	if err := r.cargarSnapshot(); err != nil {
+		return nil, err
+	}
+	// Una entrada no puede superar maxMensajeRaft, lo más que recibe el servidor.
+	// Un registro más largo indica un log dañado y no una escritura
+	// interrumpida: descartarlo como cola y reescribir el log perdería para
+	// siempre entradas confirmadas
+	entradas, err := leerMensajes(r.rutaLog(), maxMensajeRaft, func() *pb.EntradaRaft { return &pb.EntradaRaft{} })
+	if err != nil {
+		return nil, fmt.Errorf("log de Raft inválido: %v", err)
+	}
+	// Si una compactación no alcanzó a reescribir el log, quedan entradas que
+	// ya están en el snapshot
+	for _, entrada := range entradas {
+		if entrada.GetIndice() > r.base {
+			r.entradas = append(r.entradas, entrada)
+		}
+	}
+	if len(r.entradas) > 0 && r.entradas[0].GetIndice() != r.base+1 {
+		log.Printf("Raft %s: el log empieza en %d y no sigue al snapshot (%d) - se descarta",
+			r.id, r.entradas[0].GetIndice(), r.base)
+		r.entradas = nil
+	}
+	r.confirmado = r.base
+	// Se reescribe el log para descartar una posible cola corrupta
+	if err := r.reescribirLogLocked(); err != nil {
+		return nil, err
+	}
+
+	log.Printf("Raft %s: término %d, snapshot hasta %d, %d entradas en el log", r.id, r.termino, r.base, len(r.entradas))
+	return r, nil
+}
+
+// iniciar arranca el temporizador de elección y la aplicación de entradas.
+func (r *nodoRaft) iniciar() {
+	r.mu.Lock()
+	r.reiniciarTemporizadorLocked()
+	r.mu.Unlock()
+
+	go r.ejecutar()
+	go r.aplicarConfirmadas()
+}
+
+func (r *nodoRaft) ejecutar() {
+	ticker := time.NewTicker(latidoRaft / 5)
+	defer ticker.Stop()
+
+	ultimoLatido := time.Time{}
+	for {
+		select {
+		case <-r.fin:
+			return
+		case <-ticker.C:
+		}
+
+		r.mu.Lock()
+		rol := r.rol
+		vencido := time.Since(r.ultimoContacto) >= r.timeoutEleccion
+		if rol == lider && !r.mayoriaAlcanzableLocked() {
+			log.Printf("Raft %s: sin contacto con una mayoría, deja de ser líder", r.id)
+			r.convertirEnSeguidorLocked(r.termino)
+			rol = seguidor
+		}
+		r.mu.Unlock()
+
+		switch {
+		case rol == lider && time.Since(ultimoLatido) >= latidoRaft:
+			ultimoLatido = time.Now()
+			r.replicar()
+		case rol != lider && vencido:
+			r.iniciarEleccion()
+		}
+	}
+}
+
+func (r *nodoRaft) iniciarEleccion() {
+	r.mu.Lock()
+	r.termino++
+	r.rol = candidato
+	r.votoPor = r.id
+	r.lider = ""
+	r.reiniciarTemporizadorLocked()
+	if err := r.persistirTerminoLocked(); err != nil {
+		// Sin el voto propio en disco no se pide ninguno; se reintenta al
+		// vencer el temporizador
+		log.Printf("Raft %s: error persistiendo el término: %v", r.id, err)
+		r.mu.Unlock()
+		return
+	}
+
+	termino := r.termino
+	req := &pb.SolicitudVotoRequest{
+		Termino:       termino,
+		Candidato:     r.id,
+		UltimoIndice:  r.ultimoIndiceLocked(),
+		UltimoTermino: r.terminoEnLocked(r.ultimoIndiceLocked()),
+	}
+	r.mu.Unlock()
+
+	log.Printf("Raft %s: inicia elección para el término %d", r.id, termino)
+
+	votos := 1
+	var votosMu sync.Mutex
+	for id, par := range r.pares {
+		go func(id string, par pb.CyberDayServiceClient) {
+			ctx, cancel := context.WithTimeout(context.Background(), timeoutRPCRaft)
+			defer cancel()
+
+			resp, err := par.SolicitarVoto(ctx, req)
+			if err != nil {
+				return
+			}
+
+			r.mu.Lock()
+			defer r.mu.Unlock()
+			if resp.GetTermino() > r.termino {
+				r.convertirEnSeguidorLocked(resp.GetTermino())
+				return
+			}
+			if !resp.GetVotoConcedido() || r.rol != candidato || r.termino != termino {
+				return
+			}
+
+			votosMu.Lock()
+			votos++
+			gano := votos == r.mayoria()
+			votosMu.Unlock()
+			if gano {
+				r.convertirEnLiderLocked()
+			}
+		}(id, par)
+	}
+}
+
+// convertirEnLiderLocked agrega una entrada VACIA del nuevo término: cuando se
+// aplique, todo lo confirmado por líderes anteriores ya estará aplicado.
+func (r *nodoRaft) convertirEnLiderLocked() {
+	r.rol = lider
+	r.lider = r.id
+	r.listo = false
+	ahora := time.Now()
+	for id := range r.pares {
+		r.siguiente[id] = r.ultimoIndiceLocked() + 1
+		r.coincide[id] = 0
+		r.ultimaRespuesta[id] = ahora
+	}
+
+	vacia := &pb.EntradaRaft{Termino: r.termino, Indice: r.ultimoIndiceLocked() + 1, Tipo: pb.TipoEntradaRaft_VACIA}
+	if err := r.agregarEntradasLocked(vacia); err != nil {
+		log.Printf("Raft %s: error persistiendo el log: %v", r.id, err)
+	}
+	r.inicioTermino = vacia.GetIndice()
+	r.avisarCambioLocked()
+
+	log.Printf("Raft %s: elegido líder en el término %d", r.id, r.termino)
+	go r.replicar()
+}
+
+// convertirEnSeguidorLocked adopta un término igual o mayor. Si esta réplica
+// estaba atendiendo como líder, avisa en otra goroutine que dejó de hacerlo.
+func (r *nodoRaft) convertirEnSeguidorLocked(termino int64) {
+	if termino > r.termino {
+		r.termino = termino
+		r.votoPor = ""
+		if err := r.persistirTerminoLocked(); err != nil {
+			log.Printf("Raft %s: error persistiendo el término: %v", r.id, err)
+		}
+	}
+	if r.rol == lider && r.listo {
+		go r.dejar()
+	}
+	if r.rol != seguidor {
+		log.Printf("Raft %s: pasa a seguidor en el término %d", r.id, r.termino)
+	}
+	r.rol = seguidor
+	r.listo = false
+	r.reiniciarTemporizadorLocked()
+	r.avisarCambioLocked()
+}
+
+func (r *nodoRaft) replicar() {
+	for id := range r.pares {
+		go r.replicarA(id)
+	}
+}
+
+// replicarA envía al par las entradas que le faltan desde siguiente[id], o un
+// latido vacío si está al día. Si el log del par no coincide en el índice
+// previo, se retrocede hasta encontrar el punto en común.
+func (r *nodoRaft) replicarA(id string) {
+	r.mu.Lock()
+	if r.rol != lider || r.enVuelo[id] {
+		r.mu.Unlock()
+		return
+	}
+	r.enVuelo[id] = true
+	defer func() {
+		r.mu.Lock()
+		delete(r.enVuelo, id)
+		r.mu.Unlock()
+	}()
+
+	termino := r.termino
+	previo := r.siguiente[id] - 1
+	if previo < r.base {
+		// Le faltan entradas que ya se descartaron: se le envía el snapshot
+		req := &pb.InstalarSnapshotRequest{
+			Termino:        termino,
+			Lider:          r.id,
+			DireccionLider: r.direcciones[r.id],
+			UltimoIndice:   r.base,
+			UltimoTermino:  r.terminoBase,
+			Datos:          r.snapshot,
+		}
+		r.mu.Unlock()
+		r.enviarSnapshot(id, req)
+		return
+	}
+	hasta := min(r.ultimoIndiceLocked(), previo+maxEntradasPorEnvio)
+	req := &pb.AgregarEntradasRequest{
+		Termino:          termino,
+		Lider:            r.id,
+		DireccionLider:   r.direcciones[r.id],
+		IndicePrevio:     previo,
+		TerminoPrevio:    r.terminoEnLocked(previo),
+		Entradas:         append([]*pb.EntradaRaft(nil), r.entradas[previo-r.base:hasta-r.base]...),
+		IndiceConfirmado: r.confirmado,
+	}
+	r.mu.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeoutRPCRaft)
+	resp, err := r.pares[id].AgregarEntradas(ctx, req)
+	cancel()
+	if err != nil {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if resp.GetTermino() > r.termino {
+		r.convertirEnSeguidorLocked(resp.GetTermino())
+		return
+	}
+	if r.rol != lider || r.termino != termino {
+		return
+	}
+	r.ultimaRespuesta[id] = time.Now()
+
+	if !resp.GetExito() {
+		r.siguiente[id] = max(1, min(previo, resp.GetUltimoIndice()+1))
+		return
+	}
+	r.coincide[id] = max(r.coincide[id], hasta)
+	r.siguiente[id] = r.coincide[id] + 1
+	r.avanzarConfirmadoLocked()
+}
+
+func (r *nodoRaft) enviarSnapshot(id string, req *pb.InstalarSnapshotRequest) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeoutSnapshotRaft)
+	resp, err := r.pares[id].InstalarSnapshot(ctx, req)
+	cancel()
+	if err != nil {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if resp.GetTermino() > r.termino {
+		r.convertirEnSeguidorLocked(resp.GetTermino())
+		return
+	}
+	if r.rol != lider || r.termino != req.GetTermino() || !resp.GetExito() {
+		return
+	}
+	r.ultimaRespuesta[id] = time.Now()
+	r.coincide[id] = max(r.coincide[id], req.GetUltimoIndice())
+	r.siguiente[id] = r.coincide[id] + 1
+	r.avanzarConfirmadoLocked()
+	log.Printf("Raft %s: snapshot hasta %d instalado en %s", r.id, req.GetUltimoIndice(), id)
+}
+
+// avanzarConfirmadoLocked confirma el índice más alto que ya está en una
+// mayoría, siempre que sea del término actual (las entradas de términos
+// anteriores quedan confirmadas junto con él).
+func (r *nodoRaft) avanzarConfirmadoLocked() {
+	for indice := r.ultimoIndiceLocked(); indice > r.confirmado; indice-- {
+		if r.terminoEnLocked(indice) != r.termino {
+			return
+		}
+		copias := 1
+		for id := range r.pares {
+			if r.coincide[id] >= indice {
+				copias++
+			}
+		}
+		if copias >= r.mayoria() {
+			r.confirmado = indice
+			r.avisarCambioLocked()
+			return
+		}
+	}
+}
+
+// aplicarConfirmadas entrega en orden las entradas confirmadas. Si llegó un
+// snapshot más nuevo que lo aplicado, primero restaura el estado desde él.
+func (r *nodoRaft) aplicarConfirmadas() {
+	for {
+		r.mu.Lock()
+		for r.aplicado >= r.confirmado {
+			cambio := r.cambio
+			r.mu.Unlock()
+			select {
+			case <-cambio:
+			case <-r.fin:
+				return
+			}
+			r.mu.Lock()
+		}
+		if r.aplicado < r.base {
+			datos, base := r.snapshot, r.base
+			r.mu.Unlock()
+
+			r.restaurar(datos)
+
+			r.mu.Lock()
+			r.aplicado = base
+			r.avisarCambioLocked()
+			r.mu.Unlock()
+			log.Printf("Raft %s: estado restaurado desde el snapshot hasta %d", r.id, base)
+			continue
+		}
+		r.aplicado++
+		aplicado := r.aplicado
+		entrada := r.entradas[r.aplicado-r.base-1]
+		asumir := r.rol == lider && !r.listo && r.aplicado == r.inicioTermino
+		termino := r.termino
+		r.mu.Unlock()
+
+		r.aplicar(entrada)
+
+		if asumir {
+			r.asumir()
+			r.mu.Lock()
+			if r.rol == lider && r.termino == termino {
+				r.listo = true
+				log.Printf("Raft %s: líder listo para atender clientes", r.id)
+			}
+			r.mu.Unlock()
+		}
+
+		r.mu.Lock()
+		compactar := aplicado-r.base >= r.limiteLog
+		r.avisarCambioLocked()
+		r.mu.Unlock()
+
+		if compactar {
+			r.compactar(aplicado)
+		}
+	}
+}
+
+// compactar guarda el estado con todo lo aplicado hasta indice y descarta esas
+// entradas del log. Se llama entre dos entradas aplicadas, así lo que entrega
+// capturar corresponde exactamente a indice.
+func (r *nodoRaft) compactar(indice int64) {
+	datos, err := r.capturar()
+	if err != nil {
+		log.Printf("Raft %s: no se pudo capturar el estado: %v", r.id, err)
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if indice <= r.base {
+		// Mientras tanto llegó un snapshot más nuevo
+		return
+	}
+	descartadas := indice - r.base
+	if err := r.instalarLocked(indice, r.terminoEnLocked(indice), datos); err != nil {
+		log.Printf("Raft %s: error guardando el snapshot: %v", r.id, err)
+		return
+	}
+	log.Printf("Raft %s: snapshot hasta %d, %d entradas descartadas del log", r.id, indice, descartadas)
+}
+
+// instalarLocked deja el snapshot como base del log. Si el log tiene la
+// entrada del snapshot, se conservan las que le siguen; si no, se descarta
+// entero porque no coincide con el del líder.
+func (r *nodoRaft) instalarLocked(indice, termino int64, datos []byte) error {
+	if indice <= r.ultimoIndiceLocked() && r.terminoEnLocked(indice) == termino {
+		r.entradas = append([]*pb.EntradaRaft(nil), r.entradas[indice-r.base:]...)
+	} else {
+		r.entradas = nil
+	}
+	r.base, r.terminoBase, r.snapshot = indice, termino, datos
+	r.confirmado = max(r.confirmado, indice)
+
+	if err := r.guardarSnapshotLocked(); err != nil {
+		return err
+	}
+	return r.reescribirLogLocked()
+}
+
+// proponer agrega la entrada al log y espera a que esté confirmada y aplicada.
+func (r *nodoRaft) proponer(entrada *pb.EntradaRaft) error {
+	r.mu.Lock()
+	if r.rol != lider || !r.listo {
+		r.mu.Unlock()
+		return errNoLider
+	}
+	termino := r.termino
+	entrada.Termino = termino
+	entrada.Indice = r.ultimoIndiceLocked() + 1
+	if err := r.agregarEntradasLocked(entrada); err != nil {
+		r.mu.Unlock()
+		return err
+	}
+	r.mu.Unlock()
+
+	r.replicar()
+
+	limite := time.NewTimer(timeoutPropuesta)
+	defer limite.Stop()
+	for {
+		r.mu.Lock()
+		if r.aplicado >= entrada.GetIndice() {
+			// Si ya se compactó, basta con que la base sea del mismo término:
+			// solo este líder escribe entradas de su término
+			aplicada := r.terminoEnLocked(max(entrada.GetIndice(), r.base)) == termino
+			r.mu.Unlock()
+			if !aplicada {
+				return errNoLider
+			}
+			return nil
+		}
+		if r.termino != termino || r.rol != lider {
+			r.mu.Unlock()
+			return errNoLider
+		}
+		cambio := r.cambio
+		r.mu.Unlock()
+
+		select {
+		case <-cambio:
+		case <-limite.C:
+			return fmt.Errorf("la entrada %d no se confirmó a tiempo", entrada.GetIndice())
+		}
+	}
+}
+
+// atiendeClientes indica si esta réplica es el líder y ya está lista.
+func (r *nodoRaft) atiendeClientes() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.rol == lider && r.listo
+}
+
+// direccionLider retorna la dirección del líder conocido, o vacío si no hay.
+func (r *nodoRaft) direccionLider() string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.direcciones[r.lider]
+}
+
+func (r *nodoRaft) describir() string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return fmt.Sprintf("*Réplica %s: %s en el término %d (líder: %s)\n  - Entradas: %d (snapshot hasta %d), confirmadas: %d, aplicadas: %d\n",
+		r.id, r.rol, r.termino, r.lider, len(r.entradas), r.base, r.confirmado, r.aplicado)
+}
+
+func (r *nodoRaft) solicitarVoto(req *pb.SolicitudVotoRequest) *pb.SolicitudVotoResponse {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if req.GetTermino() > r.termino {
+		r.convertirEnSeguidorLocked(req.GetTermino())
+	}
+	if req.GetTermino() < r.termino {
+		return &pb.SolicitudVotoResponse{Termino: r.termino}
+	}
+
+	// Solo se vota por un candidato cuyo log esté al menos tan al día
+	ultimoTermino := r.terminoEnLocked(r.ultimoIndiceLocked())
+	alDia := req.GetUltimoTermino() > ultimoTermino ||
+		(req.GetUltimoTermino() == ultimoTermino && req.GetUltimoIndice() >= r.ultimoIndiceLocked())
+	if !alDia || (r.votoPor != "" && r.votoPor != req.GetCandidato()) {
+		return &pb.SolicitudVotoResponse{Termino: r.termino}
+	}
+
+	r.votoPor = req.GetCandidato()
+	if err := r.persistirTerminoLocked(); err != nil {
+		log.Printf("Raft %s: error persistiendo el voto: %v", r.id, err)
+		return &pb.SolicitudVotoResponse{Termino: r.termino}
+	}
+	r.reiniciarTemporizadorLocked()
+	return &pb.SolicitudVotoResponse{Termino: r.termino, VotoConcedido: true}
+}
+
+func (r *nodoRaft) agregarEntradas(req *pb.AgregarEntradasRequest) *pb.AgregarEntradasResponse {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if req.GetTermino() < r.termino {
+		return &pb.AgregarEntradasResponse{Termino: r.termino, UltimoIndice: r.ultimoIndiceLocked()}
+	}
+	r.reconocerLiderLocked(req.GetTermino(), req.GetLider())
+
+	// Lo que ya está en el snapshot está confirmado y coincide con el líder
+	previo := req.GetIndicePrevio()
+	if previo > r.ultimoIndiceLocked() || (previo >= r.base && r.terminoEnLocked(previo) != req.GetTerminoPrevio()) {
+		return &pb.AgregarEntradasResponse{Termino: r.termino, UltimoIndice: min(previo-1, r.ultimoIndiceLocked())}
+	}
+
+	var nuevas []*pb.EntradaRaft
+	for i, entrada := range req.GetEntradas() {
+		indice := entrada.GetIndice()
+		if indice <= r.base {
+			continue
+		}
+		if indice <= r.ultimoIndiceLocked() {
+			if r.terminoEnLocked(indice) == entrada.GetTermino() {
+				continue
+			}
+			// Conflicto: se descarta desde aquí lo que no está confirmado
+			r.entradas = r.entradas[:indice-r.base-1]
+			if err := r.reescribirLogLocked(); err != nil {
+				log.Printf("Raft %s: error reescribiendo el log: %v", r.id, err)
+			}
+		}
+		nuevas = req.GetEntradas()[i:]
+		break
+	}
+	if len(nuevas) > 0 {
+		if err := r.agregarEntradasLocked(nuevas...); err != nil {
+			log.Printf("Raft %s: error persistiendo el log: %v", r.id, err)
+			return &pb.AgregarEntradasResponse{Termino: r.termino, UltimoIndice: r.ultimoIndiceLocked()}
+		}
+	}
+
+	ultimaNueva := previo + int64(len(req.GetEntradas()))
+	if req.GetIndiceConfirmado() > r.confirmado {
+		r.confirmado = min(req.GetIndiceConfirmado(), ultimaNueva)
+		r.avisarCambioLocked()
+	}
+	return &pb.AgregarEntradasResponse{Termino: r.termino, Exito: true, UltimoIndice: r.ultimoIndiceLocked()}
+}
+
+// instalarSnapshot reemplaza lo que la réplica tiene hasta el índice del
+// snapshot. El estado se restaura al aplicar, en aplicarConfirmadas.
+func (r *nodoRaft) instalarSnapshot(req *pb.InstalarSnapshotRequest) *pb.AgregarEntradasResponse {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if req.GetTermino() < r.termino {
+		return &pb.AgregarEntradasResponse{Termino: r.termino, UltimoIndice: r.ultimoIndiceLocked()}
+	}
+	r.reconocerLiderLocked(req.GetTermino(), req.GetLider())
+
+	if req.GetUltimoIndice() > r.base {
+		if err := r.instalarLocked(req.GetUltimoIndice(), req.GetUltimoTermino(), req.GetDatos()); err != nil {
+			log.Printf("Raft %s: error instalando el snapshot: %v", r.id, err)
+			return &pb.AgregarEntradasResponse{Termino: r.termino, UltimoIndice: r.ultimoIndiceLocked()}
+		}
+		log.Printf("Raft %s: snapshot hasta %d recibido de %s", r.id, r.base, r.lider)
+		r.avisarCambioLocked()
+	}
+	return &pb.AgregarEntradasResponse{Termino: r.termino, Exito: true, UltimoIndice: r.ultimoIndiceLocked()}
+}
+
+// reconocerLiderLocked adopta el término de quien envía entradas o un
+// snapshot y lo anota como líder.
+func (r *nodoRaft) reconocerLiderLocked(termino int64, lider string) {
+	if termino > r.termino || r.rol != seguidor {
+		r.convertirEnSeguidorLocked(termino)
+	}
+	if r.lider != lider {
+		r.lider = lider
+		log.Printf("Raft %s: líder %s en el término %d", r.id, r.lider, r.termino)
+	}
+	r.reiniciarTemporizadorLocked()
+}
+
+func (r *nodoRaft) mayoria() int {
+	return (len(r.pares)+1)/2 + 1
+}
+
+// mayoriaAlcanzableLocked indica si el líder tuvo respuesta de una mayoría
+// dentro del timeout de elección. Si no, lo más probable es que otro líder ya
+// haya sido elegido al otro lado de una partición.
+func (r *nodoRaft) mayoriaAlcanzableLocked() bool {
+	alcanzables := 1
+	for id := range r.pares {
+		if time.Since(r.ultimaRespuesta[id]) < eleccionMaxRaft {
+			alcanzables++
+		}
+	}
+	return alcanzables >= r.mayoria()
+}
+
+func (r *nodoRaft) ultimoIndiceLocked() int64 {
+	return r.base + int64(len(r.entradas))
+}
+
+// terminoEnLocked retorna el término de la entrada, o 0 si no está en el log.
+func (r *nodoRaft) terminoEnLocked(indice int64) int64 {
+	if indice == r.base {
+		return r.terminoBase
+	}
+	if indice < r.base || indice > r.ultimoIndiceLocked() {
+		return 0
+	}
+	return r.entradas[indice-r.base-1].GetTermino()
+}
+
+func (r *nodoRaft) reiniciarTemporizadorLocked() {
+	r.ultimoContacto = time.Now()
+	r.timeoutEleccion = eleccionMinRaft + time.Duration(rand.Int63n(int64(eleccionMaxRaft-eleccionMinRaft)))
+}
+
+// avisarCambioLocked despierta a quienes esperan un avance del log o del rol.
+func (r *nodoRaft) avisarCambioLocked() {
+	close(r.cambio)
+	r.cambio = make(chan struct{})
+}
+
+// persistirTerminoLocked deja en disco el término y el voto antes de actuar
+// según ellos: un voto que se pierde en una caída permitiría votar dos veces en
+// el mismo término. Como el snapshot, se escribe en un temporal que se renombra.
+func (r *nodoRaft) persistirTerminoLocked() error {
+	datos, err := json.Marshal(terminoGuardado{Termino: r.termino, VotoPor: r.votoPor})
+	if err != nil {
+		return err
+	}
+
+	ruta := filepath.Join(r.dir, archivoTerminoRaft)
+	tmp, err := os.Create(ruta + ".tmp")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(datos); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(ruta+".tmp", ruta); err != nil {
+		return err
+	}
+	return sincronizarDirectorio(r.dir)
+}
+
+// sincronizarDirectorio hace durable un renombre dentro de dir: sin esto una
+// caída puede dejar el nombre apuntando al archivo anterior.
+func sincronizarDirectorio(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+	return d.Sync()
+}
+
+func (r *nodoRaft) agregarEntradasLocked(entradas ...*pb.EntradaRaft) error {
+	for _, entrada := range entradas {
+		if err := registros.Escribir(r.archivoLog, entrada); err != nil {
+			return err
+		}
+		r.entradas = append(r.entradas, entrada)
+	}
+	return r.archivoLog.Sync()
+}
+
+// reescribirLogLocked deja en disco exactamente las entradas en memoria y
+// reabre el archivo para seguir agregando.
+func (r *nodoRaft) reescribirLogLocked() error {
+	if r.archivoLog != nil {
+		r.archivoLog.Close()
+	}
+
+	rutaTmp := r.rutaLog() + ".tmp"
+	tmp, err := os.Create(rutaTmp)
+	if err != nil {
+		return err
+	}
+	for _, entrada := range r.entradas {
+		if err := registros.Escribir(tmp, entrada); err != nil {
+			tmp.Close()
+			return err
+		}
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(rutaTmp, r.rutaLog()); err != nil {
+		return err
+	}
+	if err := sincronizarDirectorio(r.dir); err != nil {
+		return err
+	}
+
+	r.archivoLog, err = os.OpenFile(r.rutaLog(), os.O_APPEND|os.O_WRONLY, 0644)
+	return err
+}
+
+func (r *nodoRaft) rutaLog() string {
+	return filepath.Join(r.dir, archivoLogRaft)
+}
+
+func (r *nodoRaft) rutaSnapshot() string {
+	return filepath.Join(r.dir, archivoSnapshotRaft)
+}
+
+func (r *nodoRaft) cargarSnapshot() error {
+	datos, err := os.ReadFile(r.rutaSnapshot())
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	snapshot := &pb.SnapshotRaft{}
+	if err := proto.Unmarshal(datos, snapshot); err != nil {
+		return fmt.Errorf("snapshot de Raft inválido: %v", err)
+	}
+	r.base, r.terminoBase, r.snapshot = snapshot.GetUltimoIndice(), snapshot.GetUltimoTermino(), snapshot.GetDatos()
+	return nil
+}
+
+// guardarSnapshotLocked escribe el snapshot en un archivo temporal y lo
+// renombra, así una caída a mitad de camino deja el anterior intacto.
+func (r *nodoRaft) guardarSnapshotLocked() error {
+	datos, err := proto.Marshal(&pb.SnapshotRaft{UltimoIndice: r.base, UltimoTermino: r.terminoBase, Datos: r.snapshot})
+	if err != nil {
+		return err
+	}
+
+	rutaTmp := r.rutaSnapshot() + ".tmp"
+	tmp, err := os.Create(rutaTmp)
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(datos); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(rutaTmp, r.rutaSnapshot()); err != nil {
+		return err
+	}
+	return sincronizarDirectorio(r.dir)
+}
+
+// detener corta la réplica como si el proceso muriera: deja de enviar latidos
+// y de aplicar entradas, y cierra las conexiones con los pares.
+func (r *nodoRaft) detener() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	select {
+	case <-r.fin:
+		return
+	default:
+	}
+	close(r.fin)
+	r.rol = seguidor
+	r.listo = false
+	for _, conn := range r.conexiones {
+		conn.Close()
+	}
+	r.archivoLog.Close()
+	log.Printf("Raft %s: detenida", r.id)
+}
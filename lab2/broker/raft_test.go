@@ -0,0 +1,349 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/proto"
+
+	pb "lab2/broker/proto"
+	"lab2/registros"
+)
+
+// replicaPrueba es una réplica de Raft con su propio servidor gRPC en
+// localhost, como un proceso broker que solo replica. Su estado es la lista de
+// IDs de las ofertas aplicadas.
+type replicaPrueba struct {
+	pb.UnimplementedCyberDayServiceServer
+	id        string
+	direccion string
+	dir       string
+	raft      *nodoRaft
+	servidor  *grpc.Server
+
+	mu        sync.Mutex
+	aplicadas []string
+}
+
+func (p *replicaPrueba) SolicitarVoto(ctx context.Context, req *pb.SolicitudVotoRequest) (*pb.SolicitudVotoResponse, error) {
+	return p.raft.solicitarVoto(req), nil
+}
+
+func (p *replicaPrueba) AgregarEntradas(ctx context.Context, req *pb.AgregarEntradasRequest) (*pb.AgregarEntradasResponse, error) {
+	return p.raft.agregarEntradas(req), nil
+}
+
+func (p *replicaPrueba) InstalarSnapshot(ctx context.Context, req *pb.InstalarSnapshotRequest) (*pb.AgregarEntradasResponse, error) {
+	return p.raft.instalarSnapshot(req), nil
+}
+
+func (p *replicaPrueba) aplicar(entrada *pb.EntradaRaft) {
+	if entrada.GetTipo() != pb.TipoEntradaRaft_OFERTA {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.aplicadas = append(p.aplicadas, entrada.GetOferta().GetOfertaId())
+}
+
+func (p *replicaPrueba) capturar() ([]byte, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	snapshot := &pb.SnapshotBroker{}
+	for _, ofertaID := range p.aplicadas {
+		snapshot.Ofertas = append(snapshot.Ofertas, &pb.OfertaRequest{OfertaId: ofertaID})
+	}
+	return proto.Marshal(snapshot)
+}
+
+func (p *replicaPrueba) restaurar(datos []byte) {
+	snapshot := &pb.SnapshotBroker{}
+	if err := proto.Unmarshal(datos, snapshot); err != nil {
+		panic(err)
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.aplicadas = nil
+	for _, oferta := range snapshot.GetOfertas() {
+		p.aplicadas = append(p.aplicadas, oferta.GetOfertaId())
+	}
+}
+
+func (p *replicaPrueba) ofertas() []string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return slices.Clone(p.aplicadas)
+}
+
+// levantar abre la réplica desde su directorio y empieza a atender, como al
+// iniciar el proceso.
+func (p *replicaPrueba) levantar(t *testing.T, pares map[string]string, limiteLog int64) {
+	t.Helper()
+
+	p.aplicadas = nil
+	raft, err := abrirRaft(ConfigReplicacion{ID: p.id, Pares: pares}, p.dir,
+		p.aplicar, p.capturar, p.restaurar, func() {}, func() {})
+	if err != nil {
+		t.Fatalf("abriendo réplica %s: %v", p.id, err)
+	}
+	if limiteLog > 0 {
+		raft.limiteLog = limiteLog
+	}
+	p.raft = raft
+
+	listener, err := net.Listen("tcp", p.direccion)
+	if err != nil {
+		t.Fatalf("escuchando en %s: %v", p.direccion, err)
+	}
+	p.servidor = grpc.NewServer()
+	pb.RegisterCyberDayServiceServer(p.servidor, p)
+	go p.servidor.Serve(listener)
+	raft.iniciar()
+}
+
+// matar corta la réplica sin aviso, como un kill del proceso.
+func (p *replicaPrueba) matar() {
+	p.servidor.Stop()
+	p.raft.detener()
+}
+
+func levantarCluster(t *testing.T, cantidad int, limiteLog int64) ([]*replicaPrueba, map[string]string) {
+	t.Helper()
+
+	replicas := make([]*replicaPrueba, cantidad)
+	pares := make(map[string]string)
+	for i := range replicas {
+		// Se reserva un puerto libre y se libera para que lo use la réplica
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatal(err)
+		}
+		replicas[i] = &replicaPrueba{
+			id:        fmt.Sprintf("B%d", i+1),
+			direccion: listener.Addr().String(),
+			dir:       t.TempDir(),
+		}
+		listener.Close()
+		pares[replicas[i].id] = replicas[i].direccion
+	}
+	for _, replica := range replicas {
+		replica.levantar(t, pares, limiteLog)
+	}
+	t.Cleanup(func() {
+		for _, replica := range replicas {
+			replica.matar()
+		}
+	})
+	return replicas, pares
+}
+
+// esperarLider espera a que exactamente una de las réplicas vivas atienda
+// clientes.
+func esperarLider(t *testing.T, vivas ...*replicaPrueba) *replicaPrueba {
+	t.Helper()
+
+	limite := time.Now().Add(10 * time.Second)
+	for time.Now().Before(limite) {
+		var lideres []*replicaPrueba
+		for _, replica := range vivas {
+			if replica.raft.atiendeClientes() {
+				lideres = append(lideres, replica)
+			}
+		}
+		if len(lideres) == 1 {
+			return lideres[0]
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	t.Fatal("no se eligió un líder a tiempo")
+	return nil
+}
+
+func esperarOfertas(t *testing.T, replica *replicaPrueba, esperadas []string) {
+	t.Helper()
+
+	limite := time.Now().Add(10 * time.Second)
+	for time.Now().Before(limite) {
+		if slices.Equal(replica.ofertas(), esperadas) {
+			return
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	t.Fatalf("%s aplicó %v, se esperaba %v", replica.id, replica.ofertas(), esperadas)
+}
+
+func proponerOfertas(t *testing.T, lider *replicaPrueba, desde, hasta int) []string {
+	t.Helper()
+
+	var ids []string
+	for i := desde; i < hasta; i++ {
+		ofertaID := fmt.Sprintf("oferta-%d", i)
+		entrada := &pb.EntradaRaft{Tipo: pb.TipoEntradaRaft_OFERTA, Oferta: &pb.OfertaRequest{OfertaId: ofertaID}}
+		if err := lider.raft.proponer(entrada); err != nil {
+			t.Fatalf("%s no confirmó %s: %v", lider.id, ofertaID, err)
+		}
+		ids = append(ids, ofertaID)
+	}
+	return ids
+}
+
+func sinReplica(replicas []*replicaPrueba, excluida *replicaPrueba) []*replicaPrueba {
+	var resto []*replicaPrueba
+	for _, replica := range replicas {
+		if replica != excluida {
+			resto = append(resto, replica)
+		}
+	}
+	return resto
+}
+
+func TestRaftEligeNuevoLiderYConservaLoConfirmado(t *testing.T) {
+	replicas, pares := levantarCluster(t, 3, 0)
+
+	lider := esperarLider(t, replicas...)
+	confirmadas := proponerOfertas(t, lider, 0, 5)
+
+	lider.matar()
+	vivas := sinReplica(replicas, lider)
+	nuevo := esperarLider(t, vivas...)
+	if nuevo == lider {
+		t.Fatal("la réplica muerta sigue como líder")
+	}
+	// Un líder nuevo solo atiende después de aplicar todo lo confirmado
+	if got := nuevo.ofertas(); !slices.Equal(got, confirmadas) {
+		t.Fatalf("el líder nuevo %s aplicó %v, se esperaba %v", nuevo.id, got, confirmadas)
+	}
+
+	confirmadas = append(confirmadas, proponerOfertas(t, nuevo, 5, 8)...)
+	for _, replica := range vivas {
+		esperarOfertas(t, replica, confirmadas)
+	}
+
+	// La réplica muerta vuelve desde su disco y se pone al día
+	lider.levantar(t, pares, 0)
+	esperarOfertas(t, lider, confirmadas)
+	if l := esperarLider(t, replicas...); l == lider {
+		t.Fatalf("%s volvió con un log atrasado y quedó como líder", lider.id)
+	}
+}
+
+func TestRaftSinMayoriaNoConfirma(t *testing.T) {
+	replicas, _ := levantarCluster(t, 3, 0)
+
+	lider := esperarLider(t, replicas...)
+	for _, replica := range sinReplica(replicas, lider) {
+		replica.matar()
+	}
+
+	entrada := &pb.EntradaRaft{Tipo: pb.TipoEntradaRaft_OFERTA, Oferta: &pb.OfertaRequest{OfertaId: "sin-quorum"}}
+	if err := lider.raft.proponer(entrada); err == nil {
+		t.Fatal("se confirmó una entrada sin mayoría")
+	}
+	if got := lider.ofertas(); len(got) != 0 {
+		t.Fatalf("se aplicó %v sin mayoría", got)
+	}
+}
+
+func TestRaftCompactaElLogYPoneAlDiaConSnapshot(t *testing.T) {
+	const limiteLog = 10
+	replicas, pares := levantarCluster(t, 3, limiteLog)
+
+	lider := esperarLider(t, replicas...)
+	atrasada := sinReplica(replicas, lider)[0]
+	atrasada.matar()
+
+	confirmadas := proponerOfertas(t, lider, 0, 35)
+	lider.raft.mu.Lock()
+	base, enLog := lider.raft.base, len(lider.raft.entradas)
+	lider.raft.mu.Unlock()
+	if base == 0 || enLog > limiteLog {
+		t.Fatalf("el log del líder no se compactó: snapshot hasta %d, %d entradas", base, enLog)
+	}
+
+	// La réplica atrasada necesita entradas que el líder ya descartó
+	atrasada.levantar(t, pares, limiteLog)
+	esperarOfertas(t, atrasada, confirmadas)
+	atrasada.raft.mu.Lock()
+	recibido := atrasada.raft.base
+	atrasada.raft.mu.Unlock()
+	if recibido == 0 {
+		t.Fatalf("%s se puso al día sin recibir el snapshot", atrasada.id)
+	}
+
+	// Al reiniciar, cada réplica parte de su snapshot y del resto del log
+	for _, replica := range replicas {
+		replica.matar()
+	}
+	for _, replica := range replicas {
+		replica.levantar(t, pares, limiteLog)
+	}
+	nuevo := esperarLider(t, replicas...)
+	confirmadas = append(confirmadas, proponerOfertas(t, nuevo, 35, 40)...)
+	for _, replica := range replicas {
+		esperarOfertas(t, replica, confirmadas)
+	}
+}
+
+// Una entrada sobre el límite por defecto de gRPC se recupera al reiniciar, y
+// un largo imposible en el log impide abrirlo en vez de truncar lo confirmado.
+func TestRaftRecuperaEntradasGrandesYRechazaLogDañado(t *testing.T) {
+	dir := t.TempDir()
+	config := ConfigReplicacion{ID: "B1", Pares: map[string]string{"B1": "127.0.0.1:0"}}
+	abrir := func() (*nodoRaft, error) {
+		return abrirRaft(config, dir, func(*pb.EntradaRaft) {}, func() ([]byte, error) { return nil, nil },
+			func([]byte) {}, func() {}, func() {})
+	}
+
+	raft, err := abrir()
+	if err != nil {
+		t.Fatal(err)
+	}
+	grande := &pb.EntradaRaft{Indice: 1, Termino: 1, Tipo: pb.TipoEntradaRaft_OFERTA,
+		Oferta: &pb.OfertaRequest{OfertaId: "Riploy-1", Producto: strings.Repeat("x", registros.MaxMensajeGRPC)}}
+	raft.mu.Lock()
+	err = raft.agregarEntradasLocked(grande)
+	raft.mu.Unlock()
+	if err != nil {
+		t.Fatal(err)
+	}
+	raft.detener()
+
+	raft, err = abrir()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(raft.entradas) != 1 || !proto.Equal(raft.entradas[0], grande) {
+		t.Fatalf("se recuperaron %d entradas, se esperaba la entrada grande", len(raft.entradas))
+	}
+	raft.detener()
+
+	archivo, err := os.OpenFile(filepath.Join(dir, archivoLogRaft), os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cabecera := make([]byte, 8)
+	binary.BigEndian.PutUint32(cabecera, maxMensajeRaft+1)
+	archivo.Write(cabecera)
+	archivo.Close()
+	antes, _ := os.ReadFile(filepath.Join(dir, archivoLogRaft))
+
+	if _, err := abrir(); err == nil {
+		t.Fatal("se abrió un log con un registro más largo que maxMensajeRaft")
+	}
+	if despues, _ := os.ReadFile(filepath.Join(dir, archivoLogRaft)); !bytes.Equal(antes, despues) {
+		t.Fatal("el log dañado se reescribió")
+	}
+}
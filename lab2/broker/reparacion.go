@@ -0,0 +1,38 @@
+package main
+
+import (
+	"log"
+
+	pb "lab2/broker/proto"
+)
+
+// repararLecturas se ejecuta después de cada lectura de quórum: a las réplicas
+// que respondieron con ofertas faltantes o desactualizadas se les envía la
+// versión más nueva de cada una, solo de las que le tocan según el anillo.
+func (b *Broker) repararLecturas(lecturas []lecturaNodo, historial []*pb.OfertaRequest) {
+	for _, lectura := range lecturas {
+		pendientes := b.ofertasDeNodo(lectura.nodo.nombre, ofertasPendientes(historial, lectura.ofertas))
+		if len(pendientes) == 0 {
+			continue
+		}
+
+		nodo := lectura.nodo
+		log.Printf("Nodo %s divergente: %d ofertas faltantes o desactualizadas - reparando", nodo.nombre, len(pendientes))
+
+		reparadas := 0
+		for _, oferta := range pendientes {
+			if !b.enviarOfertaANodo(nodo, oferta) {
+				b.reparacionesFallidas.Add(int64(len(pendientes) - reparadas))
+				log.Printf("Reparación de %s interrumpida: %d/%d ofertas reparadas", nodo.nombre, reparadas, len(pendientes))
+				break
+			}
+			reparadas++
+			nodo.ofertasReparadas.Add(1)
+			b.ofertasReparadas.Add(1)
+		}
+
+		if reparadas == len(pendientes) {
+			log.Printf("Nodo %s reparado: %d ofertas", nodo.nombre, reparadas)
+		}
+	}
+}
@@ -0,0 +1,222 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"path/filepath"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+
+	pb "lab2/broker/proto"
+)
+
+// claveLider es el trailer con que una réplica que no es líder indica a los
+// clientes a dónde reintentar.
+const claveLider = "lider"
+
+// iniciarReplicacion prepara Raft entre las réplicas del broker. Se replican
+// las ofertas del log de suscripciones, para que los offsets sean los mismos en
+// todas, y cada tanto el registro de entidades y los contadores. Los seguidores
+// solo guardan el último registro replicado; al asumir como líder lo
+// restauran y se conectan a los nodos y consumidores.
+func (b *Broker) iniciarReplicacion(config Configuracion) error {
+	raft, err := abrirRaft(config.Replicacion, filepath.Join(config.DirDatos, "raft"),
+		b.aplicarEntrada, b.capturarSnapshot, b.restaurarSnapshot, b.asumirLiderazgo, b.liberarRegistro)
+	if err != nil {
+		return err
+	}
+	b.raft = raft
+	return nil
+}
+
+func (b *Broker) aplicarEntrada(entrada *pb.EntradaRaft) {
+	switch entrada.GetTipo() {
+	case pb.TipoEntradaRaft_OFERTA:
+		oferta := entrada.GetOferta()
+		b.reloj.observar(oferta.GetVersion())
+		b.secuencias.observar(oferta.GetSecuencia())
+		// Solo se recuerda como confirmada si alcanzó quorum W: si no, un
+		// reintento del productor debe volver a escribirla
+		b.dedup.registrarPublicada(oferta.GetOfertaId(), entrada.GetConfirmada())
+		b.agregarAlLog(oferta)
+	case pb.TipoEntradaRaft_ESTADO:
+		b.replicadoMu.Lock()
+		b.estadoReplicado = entrada.GetEstado()
+		b.replicadoMu.Unlock()
+	}
+}
+
+// capturarSnapshot serializa lo que construyen las entradas de Raft: el log de
+// suscripciones, las ofertas confirmadas y el último registro replicado.
+func (b *Broker) capturarSnapshot() ([]byte, error) {
+	ofertas, _ := b.logOfertas.leerDesde(0)
+	b.replicadoMu.Lock()
+	estado := b.estadoReplicado
+	b.replicadoMu.Unlock()
+
+	return proto.Marshal(&pb.SnapshotBroker{
+		Ofertas:     ofertas,
+		Confirmadas: b.dedup.confirmadas(),
+		Estado:      estado,
+	})
+}
+
+// restaurarSnapshot reemplaza lo que construyeron las entradas de Raft por el
+// contenido del snapshot.
+func (b *Broker) restaurarSnapshot(datos []byte) {
+	snapshot := &pb.SnapshotBroker{}
+	if err := proto.Unmarshal(datos, snapshot); err != nil {
+		log.Printf("Snapshot del broker inválido: %v", err)
+		return
+	}
+
+	confirmadas := make(map[string]bool, len(snapshot.GetConfirmadas()))
+	for _, ofertaID := range snapshot.GetConfirmadas() {
+		confirmadas[ofertaID] = true
+	}
+	for _, oferta := range snapshot.GetOfertas() {
+		b.reloj.observar(oferta.GetVersion())
+		b.secuencias.observar(oferta.GetSecuencia())
+		b.dedup.registrarPublicada(oferta.GetOfertaId(), confirmadas[oferta.GetOfertaId()])
+	}
+	b.reemplazarLog(snapshot.GetOfertas())
+
+	b.replicadoMu.Lock()
+	b.estadoReplicado = snapshot.GetEstado()
+	b.replicadoMu.Unlock()
+	log.Printf("Estado restaurado desde snapshot: %d ofertas en el log", len(snapshot.GetOfertas()))
+}
+
+func (b *Broker) asumirLiderazgo() {
+	b.replicadoMu.Lock()
+	datos := b.estadoReplicado
+	b.replicadoMu.Unlock()
+
+	if datos != nil {
+		if err := b.aplicarEstado(datos); err != nil {
+			log.Printf("Error restaurando el registro replicado: %v", err)
+		}
+	}
+	log.Printf("Broker asume como líder")
+}
+
+// liberarRegistro se llama al dejar de ser líder: se cierran las conexiones y
+// los streams, y los clientes reintentan con el líder nuevo.
+func (b *Broker) liberarRegistro() {
+	b.registroMu.Lock()
+	defer b.registroMu.Unlock()
+
+	for _, nodo := range b.nodos {
+		nodo.conn.Close()
+	}
+	for _, consumidor := range b.consumidores {
+		consumidor.cerrar()
+	}
+	b.productores = make(map[string]*ProductorInfo)
+	b.nodos = make(map[string]*NodoInfo)
+	b.consumidores = make(map[string]*ConsumidorInfo)
+	b.indice = nuevoIndiceSuscripciones()
+	b.anillo = nuevoAnilloConsistente(b.anillo.porNodo)
+	log.Printf("Broker deja de ser líder: registro liberado")
+}
+
+// publicarOferta agrega la oferta al log de suscripciones. Con réplicas, pasa
+// antes por Raft y cada réplica la agrega al aplicarla; retorna cuando la
+// entrada ya está confirmada. confirmada indica si la oferta alcanzó quorum W
+// en los nodos.
+func (b *Broker) publicarOferta(oferta *pb.OfertaRequest, confirmada bool) error {
+	if b.raft == nil {
+		b.agregarAlLog(oferta)
+		return nil
+	}
+	entrada := &pb.EntradaRaft{Tipo: pb.TipoEntradaRaft_OFERTA, Oferta: oferta, Confirmada: confirmada}
+	if err := b.raft.proponer(entrada); err != nil {
+		return fmt.Errorf("no se pudo replicar la oferta %s: %v", oferta.GetOfertaId(), err)
+	}
+	return nil
+}
+
+// proponerEstado replica el registro y los contadores si cambiaron desde la
+// última vez. Los IDs confirmados no se incluyen: cada réplica los conoce por
+// las entradas de ofertas.
+func (b *Broker) proponerEstado() {
+	if !b.raft.atiendeClientes() {
+		b.ultimoEstadoPropuesto = nil
+		return
+	}
+
+	estado := b.capturarEstado()
+	estado.Confirmadas = nil
+	datos, err := json.Marshal(estado)
+	if err != nil {
+		log.Printf("Error serializando estado del broker: %v", err)
+		return
+	}
+	if bytes.Equal(datos, b.ultimoEstadoPropuesto) {
+		return
+	}
+
+	entrada := &pb.EntradaRaft{Tipo: pb.TipoEntradaRaft_ESTADO, Estado: datos}
+	if err := b.raft.proponer(entrada); err != nil {
+		log.Printf("No se pudo replicar el estado del broker: %v", err)
+		return
+	}
+	b.ultimoEstadoPropuesto = datos
+}
+
+// verificarLider rechaza las RPCs de clientes en una réplica que no es el
+// líder, indicando en el trailer cuál es. Las RPCs de Raft se atienden siempre.
+func (b *Broker) verificarLider(metodo string) (metadata.MD, error) {
+	if b.raft == nil || b.raft.atiendeClientes() ||
+		metodo == pb.CyberDayService_SolicitarVoto_FullMethodName ||
+		metodo == pb.CyberDayService_AgregarEntradas_FullMethodName ||
+		metodo == pb.CyberDayService_InstalarSnapshot_FullMethodName {
+		return nil, nil
+	}
+	return metadata.Pairs(claveLider, b.raft.direccionLider()),
+		status.Error(codes.FailedPrecondition, "esta réplica del broker no es el líder")
+}
+
+func (b *Broker) interceptorLider(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	if trailer, err := b.verificarLider(info.FullMethod); err != nil {
+		grpc.SetTrailer(ctx, trailer)
+		return nil, err
+	}
+	return handler(ctx, req)
+}
+
+func (b *Broker) interceptorLiderStream(srv any, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	if trailer, err := b.verificarLider(info.FullMethod); err != nil {
+		stream.SetTrailer(trailer)
+		return err
+	}
+	return handler(srv, stream)
+}
+
+func (b *Broker) SolicitarVoto(ctx context.Context, req *pb.SolicitudVotoRequest) (*pb.SolicitudVotoResponse, error) {
+	if b.raft == nil {
+		return nil, status.Error(codes.FailedPrecondition, "el broker no tiene réplicas")
+	}
+	return b.raft.solicitarVoto(req), nil
+}
+
+func (b *Broker) AgregarEntradas(ctx context.Context, req *pb.AgregarEntradasRequest) (*pb.AgregarEntradasResponse, error) {
+	if b.raft == nil {
+		return nil, status.Error(codes.FailedPrecondition, "el broker no tiene réplicas")
+	}
+	return b.raft.agregarEntradas(req), nil
+}
+
+func (b *Broker) InstalarSnapshot(ctx context.Context, req *pb.InstalarSnapshotRequest) (*pb.AgregarEntradasResponse, error) {
+	if b.raft == nil {
+		return nil, status.Error(codes.FailedPrecondition, "el broker no tiene réplicas")
+	}
+	return b.raft.instalarSnapshot(req), nil
+}
@@ -0,0 +1,257 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+// estadoSalud es el nivel de sospecha del detector de fallos sobre una entidad.
+type estadoSalud int
+
+const (
+	vivo estadoSalud = iota
+	sospechoso
+	caido
+	recuperando
+)
+
+func (s estadoSalud) String() string {
+	switch s {
+	case vivo:
+		return "VIVO"
+	case sospechoso:
+		return "SOSPECHOSO"
+	case caido:
+		return "CAÍDO"
+	case recuperando:
+		return "RECUPERANDO"
+	}
+	return "DESCONOCIDO"
+}
+
+type cambioSalud struct {
+	estado   estadoSalud
+	instante time.Time
+}
+
+// estadoEntidad guarda lo que sabe el detector de fallos de un nodo o
+// consumidor y se actualiza desde varias goroutines, por eso tiene su propio
+// lock. Una entidad pasa a sospechosa si no responde un latido a tiempo o
+// falla una llamada, a caída si no hay latidos durante el timeout de caída, y
+// al volver pasa por recuperando antes de quedar viva de nuevo.
+type estadoEntidad struct {
+	mu           sync.Mutex
+	salud        estadoSalud
+	cantCaidas   int
+	ultimoLatido time.Time
+	cambios      []cambioSalud
+}
+
+func (e *estadoEntidad) iniciarSalud() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.ultimoLatido = time.Now()
+	e.salud = vivo
+	e.cambios = []cambioSalud{{estado: vivo, instante: e.ultimoLatido}}
+}
+
+// restaurarSalud inicia la entidad con las caídas que tenía antes de que el
+// broker se reiniciara.
+func (e *estadoEntidad) restaurarSalud(cantCaidas int) {
+	e.iniciarSalud()
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.cantCaidas = cantCaidas
+}
+
+// heredarSalud conserva la historia de una entidad que se vuelve a registrar.
+func (e *estadoEntidad) heredarSalud(anterior *estadoEntidad) {
+	anterior.mu.Lock()
+	defer anterior.mu.Unlock()
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.salud = anterior.salud
+	e.cantCaidas = anterior.cantCaidas
+	e.ultimoLatido = anterior.ultimoLatido
+	e.cambios = append([]cambioSalud(nil), anterior.cambios...)
+}
+
+func (e *estadoEntidad) cambiarLocked(nuevo estadoSalud) {
+	if e.salud == nuevo {
+		return
+	}
+	e.salud = nuevo
+	e.cambios = append(e.cambios, cambioSalud{estado: nuevo, instante: time.Now()})
+	if nuevo == caido {
+		e.cantCaidas++
+	}
+}
+
+// registrarLatido anota que la entidad respondió. Retorna true si estaba caída,
+// para que quien llama la ponga al día.
+func (e *estadoEntidad) registrarLatido() bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.ultimoLatido = time.Now()
+	switch e.salud {
+	case caido:
+		e.cambiarLocked(recuperando)
+		return true
+	case sospechoso, recuperando:
+		e.cambiarLocked(vivo)
+	}
+	return false
+}
+
+// registrarExito se usa cuando una llamada normal a la entidad funciona, lo que
+// cuenta igual que un latido.
+func (e *estadoEntidad) registrarExito() bool {
+	return e.registrarLatido()
+}
+
+// registrarFallo se usa cuando falla una llamada a la entidad. No basta para
+// declararla caída: eso lo decide el detector cuando vence el timeout.
+func (e *estadoEntidad) registrarFallo() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.salud == vivo || e.salud == recuperando {
+		e.cambiarLocked(sospechoso)
+	}
+}
+
+// registrarCaida declara caída a la entidad sin esperar el timeout, por ejemplo
+// cuando un consumidor cierra su stream.
+func (e *estadoEntidad) registrarCaida() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.cambiarLocked(caido)
+}
+
+// evaluar aplica los timeouts del detector y retorna el estado resultante y si
+// cambió.
+func (e *estadoEntidad) evaluar(ahora time.Time, config ConfigLatidos) (estadoSalud, bool) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	anterior := e.salud
+	silencio := ahora.Sub(e.ultimoLatido)
+	switch {
+	case e.salud == caido:
+	case silencio >= config.caida():
+		e.cambiarLocked(caido)
+	case silencio >= config.sospecha() && e.salud == vivo:
+		e.cambiarLocked(sospechoso)
+	}
+	return e.salud, e.salud != anterior
+}
+
+// obtenerEstado retorna si la entidad está disponible y cuántas veces cayó.
+func (e *estadoEntidad) obtenerEstado() (bool, int) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	return e.salud != caido, e.cantCaidas
+}
+
+func (e *estadoEntidad) obtenerSalud() (estadoSalud, time.Time, []cambioSalud) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	return e.salud, e.ultimoLatido, append([]cambioSalud(nil), e.cambios...)
+}
+
+// iniciarDetectorFallos envía un latido (gRPC health check) a cada nodo en
+// cada intervalo y evalúa los timeouts de nodos y consumidores. Los latidos de
+// los consumidores viajan por su stream de suscripción.
+func (b *Broker) iniciarDetectorFallos() {
+	go func() {
+		ticker := time.NewTicker(b.latidos.intervalo())
+		defer ticker.Stop()
+
+		for range ticker.C {
+			if !b.sistemaActivo.Load() {
+				return
+			}
+
+			for _, nodo := range b.listarNodos() {
+				go b.latidoNodo(nodo)
+			}
+
+			ahora := time.Now()
+			for _, nodo := range b.listarNodos() {
+				if estado, cambio := nodo.evaluar(ahora, b.latidos); cambio {
+					log.Printf("Detector: nodo %s %s", nodo.nombre, estado)
+				}
+			}
+			for _, consumidor := range b.listarConsumidores() {
+				if estado, cambio := consumidor.evaluar(ahora, b.latidos); cambio {
+					log.Printf("Detector: consumidor %s %s", consumidor.id_consumidor, estado)
+				}
+			}
+		}
+	}()
+}
+
+func (b *Broker) latidoNodo(nodo *NodoInfo) {
+	ctx, cancel := context.WithTimeout(context.Background(), b.latidos.intervalo())
+	defer cancel()
+
+	resp, err := nodo.salud.Check(ctx, &healthpb.HealthCheckRequest{})
+	if err != nil || resp.GetStatus() != healthpb.HealthCheckResponse_SERVING {
+		return
+	}
+
+	anterior, _, _ := nodo.obtenerSalud()
+	if nodo.registrarLatido() {
+		b.recuperarNodo(nodo)
+	}
+	if actual, _, _ := nodo.obtenerSalud(); actual != anterior {
+		log.Printf("Detector: nodo %s %s", nodo.nombre, actual)
+	}
+}
+
+// recuperarNodo pone al día a un nodo que se registra o que el detector vio
+// volver: primero con su cola de hints y luego con lo que falte del historial.
+// La secuencia del nodo se lee antes de reproducir los hints y se acota a la
+// del broker en este momento, para que lo que le llegue después (hints o
+// escrituras nuevas) no oculte versiones anteriores que le falten. Si el nodo
+// ya tenía todo lo asignado hasta ahora, no hace falta leer el historial.
+func (b *Broker) recuperarNodo(nodo *NodoInfo) {
+	tope := b.secuencias.actual()
+	go func() {
+		desde, err := b.secuenciaNodo(nodo)
+		entregados := b.reproducirHints(nodo)
+		if err != nil {
+			log.Printf("No se pudo leer la secuencia de %s para ponerlo al día: %v", nodo.nombre, err)
+			return
+		}
+		if desde < tope {
+			b.ponerAlDiaNodo(nodo, desde, entregados)
+		}
+	}()
+}
+
+// describirSalud arma la sección del reporte con el estado actual de la
+// entidad y todas sus transiciones.
+func describirSalud(nombre string, e *estadoEntidad) string {
+	salud, ultimoLatido, cambios := e.obtenerSalud()
+
+	var texto strings.Builder
+	texto.WriteString(fmt.Sprintf("*%s: %s (último latido %s)\n", nombre, salud, ultimoLatido.Format("15:04:05.000")))
+	for _, cambio := range cambios {
+		texto.WriteString(fmt.Sprintf("  - %s %s\n", cambio.instante.Format("15:04:05.000"), cambio.estado))
+	}
+	return texto.String()
+}
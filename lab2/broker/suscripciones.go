@@ -0,0 +1,406 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"lab2/broker/filtros"
+	pb "lab2/broker/proto"
+	"lab2/registros"
+)
+
+const archivoLogOfertas = "ofertas.log"
+
+// logOfertas guarda en orden todas las ofertas distribuidas a consumidores. El
+// offset de una oferta es su posición en el log (desde 1), y es lo que usa un
+// suscriptor para retomar el stream donde lo dejó. Se persiste en disco para
+// que los offsets sigan valiendo si el broker se reinicia.
+type logOfertas struct {
+	mu      sync.Mutex
+	ofertas []*pb.OfertaRequest
+	archivo *os.File
+	// Cuándo se agregó cada oferta, para medir la latencia de entrega. Las
+	// recuperadas de disco quedan en cero
+	agregadas []time.Time
+	// Última versión publicada de cada oferta y las que tienen plazo,
+	// ordenadas por vencimiento
+	versiones    map[string]int64
+	expiraciones colaExpiracion
+}
+
+// abrirLogOfertas recupera el log guardado en dir y lo deja abierto para
+// agregar ofertas al final. Con dir vacío el log queda solo en memoria, como
+// cuando lo reconstruye Raft.
+func abrirLogOfertas(dir string) (*logOfertas, error) {
+	if dir == "" {
+		return &logOfertas{versiones: make(map[string]int64)}, nil
+	}
+
+	ruta := filepath.Join(dir, archivoLogOfertas)
+	ofertas, err := leerRegistros(ruta)
+	if err != nil {
+		return nil, err
+	}
+
+	// Se reescribe el archivo para descartar una posible cola corrupta antes de
+	// seguir agregando
+	rutaTmp := ruta + ".tmp"
+	tmp, err := os.Create(rutaTmp)
+	if err != nil {
+		return nil, err
+	}
+	writer := bufio.NewWriter(tmp)
+	for _, oferta := range ofertas {
+		if err := registros.Escribir(writer, oferta); err != nil {
+			tmp.Close()
+			return nil, err
+		}
+	}
+	if err := writer.Flush(); err != nil {
+		tmp.Close()
+		return nil, err
+	}
+	if err := tmp.Close(); err != nil {
+		return nil, err
+	}
+	if err := os.Rename(rutaTmp, ruta); err != nil {
+		return nil, err
+	}
+
+	archivo, err := os.OpenFile(ruta, os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, err
+	}
+	if len(ofertas) > 0 {
+		log.Printf("Log de ofertas recuperado: %d ofertas", len(ofertas))
+	}
+
+	l := &logOfertas{
+		ofertas:   ofertas,
+		archivo:   archivo,
+		agregadas: make([]time.Time, len(ofertas)),
+		versiones: make(map[string]int64),
+	}
+	for _, oferta := range ofertas {
+		l.agendarLocked(oferta)
+	}
+	return l, nil
+}
+
+// agregar añade la oferta al final del log y retorna el offset asignado.
+func (l *logOfertas) agregar(oferta *pb.OfertaRequest) int64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.archivo != nil {
+		if err := registros.Escribir(l.archivo, oferta); err != nil {
+			log.Printf("Error persistiendo oferta %s en el log: %v", oferta.GetOfertaId(), err)
+		}
+	}
+
+	l.ofertas = append(l.ofertas, oferta)
+	l.agregadas = append(l.agregadas, time.Now())
+	l.agendarLocked(oferta)
+	return int64(len(l.ofertas))
+}
+
+// reemplazar deja en el log exactamente las ofertas indicadas, como cuando una
+// réplica restaura un snapshot de Raft.
+func (l *logOfertas) reemplazar(ofertas []*pb.OfertaRequest) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.ofertas = ofertas
+	l.agregadas = make([]time.Time, len(ofertas))
+	l.versiones = make(map[string]int64)
+	l.expiraciones = nil
+	for _, oferta := range ofertas {
+		l.agendarLocked(oferta)
+	}
+}
+
+// leerDesde retorna las ofertas con offset mayor a desde y el último offset del
+// log.
+func (l *logOfertas) leerDesde(desde int64) ([]*pb.OfertaRequest, int64) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	ultimo := int64(len(l.ofertas))
+	if desde < 0 {
+		desde = 0
+	}
+	if desde >= ultimo {
+		return nil, ultimo
+	}
+	return l.ofertas[desde:ultimo], ultimo
+}
+
+// en retorna la oferta del offset, o nil si el log ya no la tiene.
+func (l *logOfertas) en(offset int64) *pb.OfertaRequest {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if offset < 1 || offset > int64(len(l.ofertas)) {
+		return nil
+	}
+	return l.ofertas[offset-1]
+}
+
+// agregada retorna cuándo se agregó la oferta del offset, o cero si no se sabe.
+func (l *logOfertas) agregada(offset int64) time.Time {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if offset < 1 || offset > int64(len(l.agregadas)) {
+		return time.Time{}
+	}
+	return l.agregadas[offset-1]
+}
+
+// colaSuscriptor guarda los offsets de las ofertas nuevas que van a un stream
+// abierto. Al publicar una oferta el broker busca sus destinatarios en el
+// índice y encola el offset solo en ellos, así cada stream no tiene que
+// revisar todas las ofertas del log.
+type colaSuscriptor struct {
+	mu      sync.Mutex
+	offsets []int64
+	// El log se reemplazó por un snapshot y los offsets encolados ya no valen:
+	// hay que volver a recorrerlo
+	releer bool
+	aviso  chan struct{}
+}
+
+func nuevaColaSuscriptor() *colaSuscriptor {
+	return &colaSuscriptor{aviso: make(chan struct{}, 1)}
+}
+
+func (c *colaSuscriptor) encolar(offset int64) {
+	c.mu.Lock()
+	c.offsets = append(c.offsets, offset)
+	c.mu.Unlock()
+	c.avisar()
+}
+
+func (c *colaSuscriptor) marcarReleer() {
+	c.mu.Lock()
+	c.offsets = nil
+	c.releer = true
+	c.mu.Unlock()
+	c.avisar()
+}
+
+func (c *colaSuscriptor) avisar() {
+	select {
+	case c.aviso <- struct{}{}:
+	default:
+	}
+}
+
+// tomar vacía la cola y retorna lo que tenía.
+func (c *colaSuscriptor) tomar() ([]int64, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	offsets, releer := c.offsets, c.releer
+	c.offsets, c.releer = nil, false
+	return offsets, releer
+}
+
+// agregarAlLog publica la oferta en el log y la encola en los streams de los
+// consumidores interesados. publicacionMu mantiene el orden de los offsets en
+// cada cola cuando se publica desde varias goroutines.
+func (b *Broker) agregarAlLog(oferta *pb.OfertaRequest) {
+	b.publicacionMu.Lock()
+	offset := b.logOfertas.agregar(oferta)
+	b.encolarEnSuscriptores(oferta, offset)
+	b.publicacionMu.Unlock()
+	log.Printf("Oferta %s publicada en offset %d", oferta.GetOfertaId(), offset)
+}
+
+// reemplazarLog deja en el log las ofertas de un snapshot y avisa a los
+// streams abiertos que vuelvan a leerlo desde su offset.
+func (b *Broker) reemplazarLog(ofertas []*pb.OfertaRequest) {
+	b.publicacionMu.Lock()
+	defer b.publicacionMu.Unlock()
+
+	b.logOfertas.reemplazar(ofertas)
+	b.registroMu.RLock()
+	defer b.registroMu.RUnlock()
+	for _, consumidor := range b.consumidores {
+		if consumidor.cola != nil {
+			consumidor.cola.marcarReleer()
+		}
+	}
+}
+
+// encolarEnSuscriptores deja el offset en la cola de los consumidores con
+// stream abierto cuyo filtro acepta la oferta.
+func (b *Broker) encolarEnSuscriptores(oferta *pb.OfertaRequest, offset int64) {
+	b.registroMu.RLock()
+	defer b.registroMu.RUnlock()
+
+	for _, consumidor := range b.indice.candidatos(oferta) {
+		if consumidor.cola != nil {
+			consumidor.cola.encolar(offset)
+		}
+	}
+}
+
+// Suscribir mantiene abierto un stream hacia el consumidor con las ofertas que
+// coinciden con sus preferencias. Primero envía las ofertas posteriores a
+// desde_offset que el consumidor no alcanzó a recibir, filtrando el log, y
+// luego las nuevas que el broker le encola al publicarlas.
+func (b *Broker) Suscribir(req *pb.SuscripcionRequest, stream grpc.ServerStreamingServer[pb.NotificacionOferta]) error {
+	consumidorID := req.GetConsumidorId()
+	if consumidorID == "" {
+		return status.Error(codes.InvalidArgument, "consumidor_id es obligatorio")
+	}
+
+	filtro, err := compilarFiltro(req.GetFiltro(), req.GetCategorias(), req.GetTiendas(), req.GetPrecioMax())
+	if err != nil {
+		return status.Errorf(codes.InvalidArgument, "filtro inválido: %v", err)
+	}
+
+	ctx, cancelar := context.WithCancel(stream.Context())
+	defer cancelar()
+
+	consumidor := b.registrarSuscriptor(req, filtro, cancelar)
+	offset := req.GetDesdeOffset()
+	log.Printf("Consumidor %s suscrito desde offset %d", consumidorID, offset)
+
+	defer func() {
+		// Si el consumidor salió del cluster o abrió otro stream no es una caída
+		if b.consumidorVigente(consumidor) {
+			consumidor.registrarCaida()
+			log.Printf("Consumidor %s desconectado en offset %d", consumidorID, offset)
+		}
+	}()
+
+	latidos := time.NewTicker(b.latidos.intervalo())
+	defer latidos.Stop()
+
+	enviar := func(offsetOferta int64, oferta *pb.OfertaRequest) error {
+		err := stream.Send(&pb.NotificacionOferta{
+			Offset: offsetOferta,
+			Oferta: oferta,
+		})
+		if err != nil {
+			log.Printf("Error notificando a consumidor %s: %v", consumidorID, err)
+			return err
+		}
+		consumidor.ofertasRecibidas.Add(1)
+		if agregada := b.logOfertas.agregada(offsetOferta); !agregada.IsZero() {
+			metricaLatenciaNotificacion.WithLabelValues("stream").Observe(time.Since(agregada).Seconds())
+		}
+		return nil
+	}
+	// ponerAlDia envía lo que hay en el log después de offset. La cola ya
+	// existe, así que una oferta publicada mientras tanto queda en el log o en
+	// la cola, y la que queda en ambos se descarta por su offset
+	ponerAlDia := func() error {
+		pendientes, ultimo := b.logOfertas.leerDesde(offset)
+		for i, oferta := range pendientes {
+			if b.coincideConPreferencias(oferta, consumidor) {
+				if err := enviar(offset+int64(i)+1, oferta); err != nil {
+					return err
+				}
+			}
+		}
+		offset = ultimo
+		return nil
+	}
+
+	if err := ponerAlDia(); err != nil {
+		return err
+	}
+	for {
+		select {
+		case <-consumidor.cola.aviso:
+			offsets, releer := consumidor.cola.tomar()
+			if releer {
+				if err := ponerAlDia(); err != nil {
+					return err
+				}
+				continue
+			}
+			for _, offsetOferta := range offsets {
+				if offsetOferta <= offset {
+					continue
+				}
+				if oferta := b.logOfertas.en(offsetOferta); oferta != nil {
+					if err := enviar(offsetOferta, oferta); err != nil {
+						return err
+					}
+				}
+				offset = offsetOferta
+			}
+		case <-latidos.C:
+			// El latido viaja por el mismo stream y cuenta como respuesta del consumidor
+			if err := stream.Send(&pb.NotificacionOferta{Offset: offset, Latido: true}); err != nil {
+				return err
+			}
+			consumidor.registrarLatido()
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+// registrarSuscriptor agrega o reemplaza al consumidor en el registro con las
+// preferencias de la suscripción. Si ya tenía un stream abierto se cierra.
+func (b *Broker) registrarSuscriptor(req *pb.SuscripcionRequest, filtro *filtros.Filtro, cancelar context.CancelFunc) *ConsumidorInfo {
+	consumidorID := req.GetConsumidorId()
+
+	b.registroMu.Lock()
+	defer b.registroMu.Unlock()
+
+	consumidor := &ConsumidorInfo{
+		id_consumidor: consumidorID,
+		archivoCSV:    fmt.Sprintf("consumidor_%s.csv", consumidorID),
+		cancelar:      cancelar,
+		cola:          nuevaColaSuscriptor(),
+	}
+	consumidor.filtro.Store(filtro)
+
+	if anterior, existe := b.consumidores[consumidorID]; existe {
+		consumidor.heredarSalud(&anterior.estadoEntidad)
+		consumidor.ofertasRecibidas.Store(anterior.ofertasRecibidas.Load())
+		anterior.cerrar()
+		if consumidor.registrarLatido() {
+			log.Printf("%s se reconectó", consumidorID)
+		}
+	} else {
+		consumidor.iniciarSalud()
+		log.Printf("Consumidor %s registrado por suscripción", consumidorID)
+		log.Printf("-Filtro: %s", filtro)
+	}
+	b.consumidores[consumidorID] = consumidor
+	b.indice.agregar(consumidor)
+
+	b.verificarInicio()
+	return consumidor
+}
+
+func (b *Broker) consumidorVigente(consumidor *ConsumidorInfo) bool {
+	b.registroMu.RLock()
+	defer b.registroMu.RUnlock()
+
+	return b.consumidores[consumidor.id_consumidor] == consumidor
+}
+
+// cerrar corta el stream de suscripción del consumidor, si tiene uno abierto.
+func (c *ConsumidorInfo) cerrar() {
+	if c.cancelar != nil {
+		c.cancelar()
+	}
+}
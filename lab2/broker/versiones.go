@@ -0,0 +1,95 @@
+package main
+
+import (
+	"sort"
+	"sync/atomic"
+
+	pb "lab2/broker/proto"
+)
+
+// relojLamport asigna las versiones de las ofertas. Cada oferta recibida
+// avanza el reloj, y las versiones que se observan en las lecturas lo
+// adelantan, así una versión nueva siempre es mayor que cualquiera ya vista.
+type relojLamport struct {
+	valor atomic.Int64
+}
+
+// recibir aplica la regla de Lamport para un evento con versión observada:
+// reloj = max(reloj, observada) + 1. Retorna la versión asignada.
+func (r *relojLamport) recibir(observada int64) int64 {
+	for {
+		actual := r.valor.Load()
+		siguiente := max(actual, observada) + 1
+		if r.valor.CompareAndSwap(actual, siguiente) {
+			return siguiente
+		}
+	}
+}
+
+// observar adelanta el reloj hasta la versión vista sin generar un evento.
+func (r *relojLamport) observar(version int64) {
+	for {
+		actual := r.valor.Load()
+		if version <= actual || r.valor.CompareAndSwap(actual, version) {
+			return
+		}
+	}
+}
+
+func (r *relojLamport) actual() int64 {
+	return r.valor.Load()
+}
+
+// lecturaNodo es lo que entregó una réplica al leerla, con todas sus páginas.
+type lecturaNodo struct {
+	nodo    *NodoInfo
+	ofertas []*pb.OfertaRequest
+}
+
+// fusionarLecturas combina las respuestas de varias réplicas quedándose con la
+// versión más nueva de cada oferta, así una réplica atrasada no hace fallar la
+// lectura.
+func (b *Broker) fusionarLecturas(lecturas []lecturaNodo) []*pb.OfertaRequest {
+	ultimas := make(map[string]*pb.OfertaRequest)
+	for _, lectura := range lecturas {
+		for _, oferta := range lectura.ofertas {
+			actual, existe := ultimas[oferta.GetOfertaId()]
+			if !existe || oferta.GetVersion() > actual.GetVersion() {
+				ultimas[oferta.GetOfertaId()] = oferta
+			}
+		}
+	}
+
+	fusionadas := make([]*pb.OfertaRequest, 0, len(ultimas))
+	for _, oferta := range ultimas {
+		b.reloj.observar(oferta.GetVersion())
+		b.secuencias.observar(oferta.GetSecuencia())
+		fusionadas = append(fusionadas, oferta)
+	}
+	sort.Slice(fusionadas, func(i, j int) bool {
+		if fusionadas[i].GetVersion() != fusionadas[j].GetVersion() {
+			return fusionadas[i].GetVersion() < fusionadas[j].GetVersion()
+		}
+		return fusionadas[i].GetOfertaId() < fusionadas[j].GetOfertaId()
+	})
+
+	return fusionadas
+}
+
+// ofertasPendientes retorna las ofertas de historial que no están en actuales
+// o que están con una versión anterior.
+func ofertasPendientes(historial, actuales []*pb.OfertaRequest) []*pb.OfertaRequest {
+	versiones := make(map[string]int64, len(actuales))
+	for _, oferta := range actuales {
+		versiones[oferta.GetOfertaId()] = oferta.GetVersion()
+	}
+
+	var pendientes []*pb.OfertaRequest
+	for _, oferta := range historial {
+		version, existe := versiones[oferta.GetOfertaId()]
+		if !existe || version < oferta.GetVersion() {
+			pendientes = append(pendientes, oferta)
+		}
+	}
+	return pendientes
+}
@@ -0,0 +1,196 @@
+// Package conexion mantiene la conexión de productores, nodos y consumidores
+// con la réplica líder del broker.
+package conexion
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// claveLider es el trailer con que una réplica que no es líder indica cuál es.
+const claveLider = "lider"
+
+const esperaEntreReplicas = 200 * time.Millisecond
+
+// Broker es una conexión a las réplicas del broker que implementa
+// grpc.ClientConnInterface, así cada binario arma el cliente con su propio
+// paquete proto. Las llamadas van a la réplica actual; si no responde o
+// contesta que no es el líder, se cambia al líder que indique o a la siguiente
+// réplica de la lista. Las llamadas simples se reintentan de inmediato; en los
+// streams el error llega al que llama, que decide cuándo volver a abrirlo.
+type Broker struct {
+	direcciones []string
+	mu          sync.Mutex
+	actual      string
+	conexiones  map[string]*grpc.ClientConn
+}
+
+// DireccionesDesdeEntorno lee las réplicas de BROKER_HOSTS (host:puerto
+// separados por coma). Si no está, usa BROKER_HOST:50051 como antes.
+func DireccionesDesdeEntorno() []string {
+	if hosts := os.Getenv("BROKER_HOSTS"); hosts != "" {
+		var direcciones []string
+		for _, host := range strings.Split(hosts, ",") {
+			if host = strings.TrimSpace(host); host != "" {
+				direcciones = append(direcciones, host)
+			}
+		}
+		return direcciones
+	}
+
+	brokerHost := os.Getenv("BROKER_HOST")
+	if brokerHost == "" {
+		brokerHost = "broker" // nombre del servicio en docker-compose
+	}
+	return []string{brokerHost + ":50051"}
+}
+
+func Conectar(direcciones []string) (*Broker, error) {
+	if len(direcciones) == 0 {
+		return nil, fmt.Errorf("no hay direcciones del broker")
+	}
+
+	b := &Broker{
+		direcciones: direcciones,
+		actual:      direcciones[0],
+		conexiones:  make(map[string]*grpc.ClientConn),
+	}
+	if _, err := b.conexion(b.actual); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+func (b *Broker) Invoke(ctx context.Context, metodo string, args, reply any, opts ...grpc.CallOption) error {
+	var err error
+	for intento := 0; intento <= len(b.direcciones); intento++ {
+		direccion := b.direccionActual()
+		conn, errConn := b.conexion(direccion)
+		if errConn != nil {
+			return errConn
+		}
+
+		var trailer metadata.MD
+		err = conn.Invoke(ctx, metodo, args, reply, append(opts, grpc.Trailer(&trailer))...)
+		if !debeCambiar(err) {
+			return err
+		}
+		b.cambiar(direccion, trailer)
+
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(esperaEntreReplicas):
+		}
+	}
+	return err
+}
+
+func (b *Broker) NewStream(ctx context.Context, desc *grpc.StreamDesc, metodo string, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+	direccion := b.direccionActual()
+	conn, err := b.conexion(direccion)
+	if err != nil {
+		return nil, err
+	}
+
+	stream, err := conn.NewStream(ctx, desc, metodo, opts...)
+	if err != nil {
+		if debeCambiar(err) {
+			b.cambiar(direccion, nil)
+		}
+		return nil, err
+	}
+	return &streamBroker{ClientStream: stream, broker: b, direccion: direccion}, nil
+}
+
+// Close cierra las conexiones con todas las réplicas.
+func (b *Broker) Close() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for _, conn := range b.conexiones {
+		conn.Close()
+	}
+	b.conexiones = make(map[string]*grpc.ClientConn)
+	return nil
+}
+
+func (b *Broker) direccionActual() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.actual
+}
+
+// conexion reutiliza la conexión con cada réplica. No se cierran al cambiar de
+// líder porque otras llamadas en curso pueden estar usándolas.
+func (b *Broker) conexion(direccion string) (*grpc.ClientConn, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if conn, existe := b.conexiones[direccion]; existe {
+		return conn, nil
+	}
+	conn, err := grpc.Dial(direccion, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		return nil, fmt.Errorf("no se pudo conectar al broker %s: %v", direccion, err)
+	}
+	b.conexiones[direccion] = conn
+	return conn, nil
+}
+
+// cambiar deja de usar la réplica que falló, salvo que otra llamada ya lo haya
+// hecho. Prefiere el líder indicado en el trailer.
+func (b *Broker) cambiar(fallida string, trailer metadata.MD) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.actual != fallida {
+		return
+	}
+
+	if lideres := trailer.Get(claveLider); len(lideres) > 0 && lideres[0] != "" && lideres[0] != fallida {
+		b.actual = lideres[0]
+	} else {
+		siguiente := (slices.Index(b.direcciones, fallida) + 1) % len(b.direcciones)
+		b.actual = b.direcciones[siguiente]
+	}
+	if b.actual != fallida {
+		log.Printf("Broker %s no disponible o no es el líder - usando %s", fallida, b.actual)
+	}
+}
+
+// debeCambiar indica si el error es de la réplica y no de la llamada.
+func debeCambiar(err error) bool {
+	switch status.Code(err) {
+	case codes.Unavailable, codes.FailedPrecondition:
+		return true
+	}
+	return false
+}
+
+type streamBroker struct {
+	grpc.ClientStream
+	broker    *Broker
+	direccion string
+}
+
+func (s *streamBroker) RecvMsg(m any) error {
+	err := s.ClientStream.RecvMsg(m)
+	if debeCambiar(err) {
+		s.broker.cambiar(s.direccion, s.Trailer())
+	}
+	return err
+}
@@ -10,27 +10,28 @@ import (
 	"os"
 	"strconv"
 	"strings"
-	"net"
+	"os/signal"
+	"slices"
 	"sync"
+	"syscall"
 	"time"
 
-	"google.golang.org/grpc"
-	"google.golang.org/grpc/credentials/insecure"
+	"lab2/conexion"
+	"lab2/metricas"
 	pb "lab2/consumidores/proto"
 )
 
 type Consumidor struct {
-	pb.UnimplementedCyberDayServiceServer
 	id         			string
-	direccion        	string
-	categorias 			[]string
-	tiendas   			[]string
-	precioMax  			int32
+	filtro     			*pb.FiltroOferta
 	ofertasRecibidas 	[]*pb.OfertaRequest
+	versiones        	map[string]int64
+	ultimoOffset     	int64
 	archivoCSV     		string
 	ofertasCount    	int
 	mu               	sync.Mutex
 	probabilidadFallo 	float64
+	probabilidadCompra	float64
 	enFallo          	bool
 	caidasSimuladas  	int
 	client            	pb.CyberDayServiceClient
@@ -49,8 +50,8 @@ func cargarConfiguracion(archivo string, numeroCliente int) (*Consumidor, error)
 		return nil, fmt.Errorf("error leyendo CSV: %v", err)
 	}
 
-	if len(records) < 13 {
-		return nil, fmt.Errorf("archivo CSV debe tener al menos 13 filas (header + 12 consumidores)")
+	if numeroCliente < 1 || numeroCliente >= len(records) {
+		return nil, fmt.Errorf("el cliente %d no existe: el CSV tiene %d consumidores", numeroCliente, len(records)-1)
 	}
 
 	record := records[numeroCliente]
@@ -59,181 +60,322 @@ func cargarConfiguracion(archivo string, numeroCliente int) (*Consumidor, error)
 		return nil, fmt.Errorf("línea %d del CSV no tiene suficientes columnas", numeroCliente)
 	}
 
-	var categorias []string
-	if record[1] != "null" {
-		categorias = strings.Split(record[1], ";")
-	} else {
-		categorias = []string{"null"}
+	// Las columnas desde precio_min son opcionales. "null" o vacío significa
+	// que esa condición no restringe.
+	columna := func(i int) string {
+		if i >= len(record) || record[i] == "null" {
+			return ""
+		}
+		return strings.TrimSpace(record[i])
 	}
 
-	var tiendas []string
-	if record[2] != "null" {
-		tiendas = strings.Split(record[2], ";")
-	} else {
-		tiendas = []string{"null"}
+	filtro := &pb.FiltroOferta{
+		Categorias:       separarLista(columna(1)),
+		Tiendas:          separarLista(columna(2)),
+		ProductoContiene: columna(6),
 	}
 
-	var precioMax int32
-	if record[3] != "null" {
-		precioInt, err := strconv.Atoi(record[3])
+	enteros := []struct {
+		nombre  string
+		valor   string
+		destino *int32
+	}{
+		{"precio máximo", columna(3), &filtro.PrecioMax},
+		{"precio mínimo", columna(4), &filtro.PrecioMin},
+		{"descuento mínimo", columna(5), &filtro.DescuentoMin},
+	}
+	for _, entero := range enteros {
+		if entero.valor == "" {
+			continue
+		}
+		valorInt, err := strconv.Atoi(entero.valor)
 		if err != nil {
-			return nil, fmt.Errorf("precio máximo inválido en línea %d: %s", numeroCliente, record[3])
+			return nil, fmt.Errorf("%s inválido en línea %d: %s", entero.nombre, numeroCliente, entero.valor)
 		}
-		precioMax = int32(precioInt)
-	} else {
-		precioMax = -1 // -1 = sin límite
+		*entero.destino = int32(valorInt)
 	}
 
-	puerto := 50060 + numeroCliente
-	direccion := os.Getenv("CONSUMIDOR_DIRECCION")
-	if direccion == "" {
-		direccion = fmt.Sprintf("localhost:%s", puerto)
-	}
 	archivoCSV := fmt.Sprintf("/output/consumidor_%s.csv", record[0])
 	probabilidadFallo := 0.1
+	probabilidadCompra := 0.2
 
 	return &Consumidor{
 		id:                record[0],
-		direccion:         direccion,
-		categorias:        categorias,
-		tiendas:           tiendas,
-		precioMax:         precioMax,
+		filtro:            filtro,
 		ofertasRecibidas:  make([]*pb.OfertaRequest, 0),
+		versiones:         make(map[string]int64),
 		archivoCSV:        archivoCSV,
 		ofertasCount:      0,
 		probabilidadFallo: probabilidadFallo,
+		probabilidadCompra: probabilidadCompra,
 		enFallo:           false,
 		caidasSimuladas:   0,
 	}, nil
 }
 
-func (c *Consumidor) registrarEnBroker() {
+func separarLista(valor string) []string {
+	if valor == "" {
+		return nil
+	}
+	return strings.Split(valor, ";")
+}
+
+// escucharOfertas mantiene abierta la suscripción con el broker. Cada vez que
+// el stream se corta (por una caída simulada o un error de red) se vuelve a
+// suscribir desde el último offset recibido, y el broker reenvía las ofertas
+// perdidas sin necesidad de resincronizar.
+func (c *Consumidor) escucharOfertas(ctx context.Context) {
+	for ctx.Err() == nil {
+		err := c.suscribir(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+		if err != nil {
+			log.Printf("%s perdió la suscripción: %v - reintentando en 5s", c.id, err)
+		}
 
-	resp, err := c.client.RegistrarConsumidor(context.Background(), &pb.RegistroConsumidorRequest{
+		time.Sleep(5 * time.Second)
+	}
+}
+
+func (c *Consumidor) suscribir(ctx context.Context) error {
+	streamCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	c.mu.Lock()
+	desde := c.ultimoOffset
+	filtro := c.filtro
+	c.mu.Unlock()
+
+	stream, err := c.client.Suscribir(streamCtx, &pb.SuscripcionRequest{
 		ConsumidorId: c.id,
-		Categorias:   c.categorias,
-		Tiendas:      c.tiendas,
-		PrecioMax:    c.precioMax,
-		Direccion:    c.direccion,
+		Filtro:       filtro,
+		DesdeOffset:  desde,
 	})
 	if err != nil {
-		log.Printf("Error registrando %s: %v", c.id, err)
-		return
+		return err
 	}
+	log.Printf("%s suscrito en broker desde offset %d", c.id, desde)
 
-	if resp.GetExito() {
-		log.Printf("%s registrado en broker", c.id)
-	} else {
-		log.Printf("Registro de %s falló", c.id)
+	c.mu.Lock()
+	if c.enFallo {
+		c.enFallo = false
+		log.Printf("%s COMPLETAMENTE RECUPERADO - recibiendo ofertas pendientes desde offset %d", c.id, desde)
+	}
+	c.mu.Unlock()
+
+	for {
+		notificacion, err := stream.Recv()
+		if err != nil {
+			return err
+		}
+
+		if notificacion.GetLatido() {
+			continue
+		}
+
+		if !c.procesarNotificacion(notificacion) {
+			// Caída simulada: se corta el stream sin confirmar el offset
+			return nil
+		}
 	}
 }
 
-func (c *Consumidor) EnviarOferta(ctx context.Context, req *pb.OfertaRequest) (*pb.OfertaResponse, error) {
+// procesarNotificacion guarda la oferta recibida y avanza el offset. Retorna
+// false si el consumidor simula una caída antes de procesarla.
+func (c *Consumidor) procesarNotificacion(notificacion *pb.NotificacionOferta) bool {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
-	if c.enFallo {
-		log.Printf("%s en fallo - rechazando oferta", c.id)
-		return &pb.OfertaResponse{Exito: false}, nil
+	oferta := notificacion.GetOferta()
+
+	if c.probabilidadFallo > 0 && rand.Float64() < c.probabilidadFallo {
+		c.simularFallo()
+		return false
 	}
 
-	if !c.enFallo && c.probabilidadFallo > 0 {
-		if rand.Float64() < c.probabilidadFallo {
-			c.simularFallo()
-			return &pb.OfertaResponse{Exito: false}, nil
+	c.ultimoOffset = notificacion.GetOffset()
+
+	if c.guardarOferta(oferta) {
+		log.Printf("%s recibió oferta: %s - $%d (offset %d)", c.id, oferta.GetProducto(), oferta.GetPrecio(), notificacion.GetOffset())
+		log.Printf("   - Total recibidas: %d ofertas", c.ofertasCount)
+
+		if rand.Float64() < c.probabilidadCompra {
+			go c.comprar(oferta)
 		}
 	}
-	
-	c.ofertasRecibidas = append(c.ofertasRecibidas, req)
+
+	return true
+}
+
+// guardarOferta agrega la oferta a las recibidas y al CSV si no estaba, o
+// aplica el cambio si es una versión más nueva de una que ya tiene. Retorna
+// true solo si la oferta es nueva. Se llama con c.mu tomado.
+func (c *Consumidor) guardarOferta(oferta *pb.OfertaRequest) bool {
+	version, conocida := c.versiones[oferta.GetOfertaId()]
+	if conocida && oferta.GetVersion() <= version {
+		return false
+	}
+	c.versiones[oferta.GetOfertaId()] = oferta.GetVersion()
+
+	if conocida {
+		c.aplicarCambio(oferta)
+		return false
+	}
+	// Cambio de una oferta que nunca recibió
+	if oferta.GetEstado() != pb.EstadoOferta_VIGENTE {
+		return false
+	}
+
+	c.ofertasRecibidas = append(c.ofertasRecibidas, oferta)
 	c.ofertasCount++
-	
-	err := c.escribirEnCSV(req)
+	metricaOfertasRecibidas.Inc()
+
+	err := c.escribirEnCSV(oferta)
 	if err != nil {
 		log.Printf("Error escribiendo CSV para %s: %v", c.id, err)
-		return &pb.OfertaResponse{Exito: false}, nil
 	}
-	
-	log.Printf("%s recibió oferta: %s - $%d", c.id, req.GetProducto(), req.GetPrecio())
-	log.Printf("   - Total recibidas: %d ofertas", c.ofertasCount)
-	
-	return &pb.OfertaResponse{Exito: true}, nil
+	return true
 }
 
-func (c *Consumidor) simularFallo() {
-	c.enFallo = true
-	c.caidasSimuladas++
-	
-	log.Printf("%s CAÍDA SIMULADA - Probabilidad: %.1f%%", 
-		c.id, c.probabilidadFallo*100)
-	log.Printf("   - Caída #%d - Recuperación en 5 segundos", c.caidasSimuladas)
-	
-	go c.recuperarAutomaticamente()
-}
+// aplicarCambio actualiza una oferta ya recibida. Las retiradas y expiradas
+// salen del CSV; las demás quedan con su stock y estado nuevos.
+func (c *Consumidor) aplicarCambio(oferta *pb.OfertaRequest) {
+	i := slices.IndexFunc(c.ofertasRecibidas, func(recibida *pb.OfertaRequest) bool {
+		return recibida.GetOfertaId() == oferta.GetOfertaId()
+	})
+	if i < 0 {
+		return
+	}
+	metricaCambiosRecibidos.WithLabelValues(oferta.GetEstado().String()).Inc()
+
+	switch oferta.GetEstado() {
+	case pb.EstadoOferta_RETIRADA, pb.EstadoOferta_EXPIRADA:
+		c.ofertasRecibidas = slices.Delete(c.ofertasRecibidas, i, i+1)
+		log.Printf("%s: oferta %s %s - se quita del CSV", c.id, oferta.GetProducto(), oferta.GetEstado())
+	default:
+		c.ofertasRecibidas[i] = oferta
+		log.Printf("%s: oferta %s ahora con stock %d (%s)", c.id, oferta.GetProducto(), oferta.GetStock(), oferta.GetEstado())
+	}
 
-func (c *Consumidor) recuperarAutomaticamente() {
-	log.Printf("%s programado para recuperarse en 5 segundos", c.id)
-	time.Sleep(5 * time.Second)
-	
-	log.Printf("%s iniciando resincronización...", c.id)
-	
-	exito := c.solicitarResincronizacion()
-	
-	c.mu.Lock()
-	if exito {
-		c.enFallo = false
-		log.Printf("%s COMPLETAMENTE RECUPERADO Y SINCRONIZADO", c.id)
-	} else {
-		log.Printf("%s falló resincronización - reintentando en 5s", c.id)
-		go func() {
-			time.Sleep(5 * time.Second)
-			c.recuperarAutomaticamente()
-		}()
+	if err := c.reescribirCSV(); err != nil {
+		log.Printf("Error reescribiendo CSV para %s: %v", c.id, err)
 	}
-	c.mu.Unlock()
 }
 
-func (c *Consumidor) solicitarResincronizacion() bool {
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+// comprar descuenta una unidad del stock de la oferta. El broker notifica el
+// cambio a todos los consumidores interesados, incluido este.
+func (c *Consumidor) comprar(oferta *pb.OfertaRequest) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
-	c.mu.Lock()
-	ofertasActuales := c.ofertasRecibidas
-	c.mu.Unlock()
-
-	resp, err := c.client.SincronizarEntidad(ctx, &pb.SincronizacionRequest{
-		EntidadId:      c.id,
-		Tipo:           "consumidor",
-		OfertasActuales: ofertasActuales,
+	resp, err := c.client.ActualizarStock(ctx, &pb.ActualizacionStockRequest{
+		OfertaId:     oferta.GetOfertaId(),
+		Delta:        -1,
+		ConsumidorId: c.id,
 	})
-	
-	if err != nil || !resp.GetExito() {
-		log.Printf("%s error en resincronización: %v", c.id, err)
-		return false
+	if err != nil {
+		log.Printf("%s no pudo comprar %s: %v", c.id, oferta.GetProducto(), err)
+		metricaCompras.WithLabelValues("error").Inc()
+		return
 	}
+	if !resp.GetExito() {
+		log.Printf("%s no pudo comprar %s: %s", c.id, oferta.GetProducto(), resp.GetMotivo())
+		metricaCompras.WithLabelValues("rechazada").Inc()
+		return
+	}
+	metricaCompras.WithLabelValues("exito").Inc()
+	log.Printf("%s compró %s - quedan %d", c.id, oferta.GetProducto(), resp.GetOferta().GetStock())
+}
 
-	c.mu.Lock()
-	ofertasAntes := c.ofertasCount
-	for _, oferta := range resp.GetOfertasFaltantes() {
-		existe := false
-		for _, ofertaExistente := range c.ofertasRecibidas {
-			if ofertaExistente.GetOfertaId() == oferta.GetOfertaId() {
-				existe = true
-				break
+// recargarPreferencias vuelve a leer la fila del consumidor en el CSV cada vez
+// que recibe SIGHUP y envía el filtro nuevo al broker. Las ofertas del
+// historial que ahora coinciden llegan en la respuesta.
+func (c *Consumidor) recargarPreferencias(ctx context.Context, archivo string, numeroCliente int) {
+	recargas := make(chan os.Signal, 1)
+	signal.Notify(recargas, syscall.SIGHUP)
+	defer signal.Stop(recargas)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-recargas:
+		}
+
+		config, err := cargarConfiguracion(archivo, numeroCliente)
+		if err != nil {
+			log.Printf("%s no pudo recargar sus preferencias: %v", c.id, err)
+			continue
+		}
+
+		llamadaCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+		resp, err := c.client.ActualizarPreferencias(llamadaCtx, &pb.ActualizacionPreferenciasRequest{
+			ConsumidorId: c.id,
+			Filtro:       config.filtro,
+		})
+		cancel()
+		if err != nil || !resp.GetExito() {
+			log.Printf("%s no pudo actualizar sus preferencias: %v", c.id, err)
+			continue
+		}
+
+		c.mu.Lock()
+		c.filtro = config.filtro
+		nuevas := 0
+		for _, oferta := range resp.GetOfertas() {
+			if c.guardarOferta(oferta) {
+				nuevas++
 			}
 		}
-		if !existe {
-			c.ofertasRecibidas = append(c.ofertasRecibidas, oferta)
-			c.escribirEnCSV(oferta)
+		total := c.ofertasCount
+		c.mu.Unlock()
+
+		log.Printf("%s actualizó sus preferencias: +%d ofertas del historial (total %d)", c.id, nuevas, total)
+		if !resp.GetResincronizado() {
+			log.Printf("   - El broker no pudo leer el historial: pueden faltar ofertas anteriores")
 		}
 	}
-	c.ofertasCount = len(c.ofertasRecibidas)
-	c.mu.Unlock()
+}
+
+func (c *Consumidor) abandonarCluster() {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	resp, err := c.client.AbandonarCluster(ctx, &pb.SalidaRequest{
+		EntidadId: c.id,
+		Tipo:      "consumidor",
+	})
+	if err != nil || !resp.GetExito() {
+		log.Printf("%s no pudo avisar su salida al broker: %v", c.id, err)
+		return
+	}
+	log.Printf("%s abandonó el cluster", c.id)
+}
 
-	ofertasNuevas := c.ofertasCount - ofertasAntes
-	log.Printf("%s resincronizado: +%d ofertas (%d → %d total)", 
-		c.id, ofertasNuevas, ofertasAntes, c.ofertasCount)
+func (c *Consumidor) simularFallo() {
+	c.enFallo = true
+	c.caidasSimuladas++
+	metricaCaidasSimuladas.Inc()
 	
-	return true
+	log.Printf("%s CAÍDA SIMULADA - Probabilidad: %.1f%%", 
+		c.id, c.probabilidadFallo*100)
+	log.Printf("   - Caída #%d - Reconexión en 5 segundos desde offset %d", c.caidasSimuladas, c.ultimoOffset)
+}
+
+var encabezadoCSV = []string{"oferta_id", "tienda", "categoria", "producto", "precio", "stock", "fecha", "estado"}
+
+func filaCSV(oferta *pb.OfertaRequest) []string {
+    return []string{
+        oferta.GetOfertaId(),
+        oferta.GetTienda(),
+        oferta.GetCategoria(),
+        oferta.GetProducto(),
+        strconv.Itoa(int(oferta.GetPrecio())),
+        strconv.Itoa(int(oferta.GetStock())),
+        oferta.GetFecha(),
+        oferta.GetEstado().String(),
+    }
 }
 
 func (c *Consumidor) escribirEnCSV(oferta *pb.OfertaRequest) error {
@@ -246,17 +388,32 @@ func (c *Consumidor) escribirEnCSV(oferta *pb.OfertaRequest) error {
     writer := csv.NewWriter(file)
     defer writer.Flush()
 
-    record := []string{
-        oferta.GetOfertaId(),
-        oferta.GetTienda(),
-        oferta.GetCategoria(),
-        oferta.GetProducto(),
-        strconv.Itoa(int(oferta.GetPrecio())),
-        strconv.Itoa(int(oferta.GetStock())),
-        oferta.GetFecha(),
+    return writer.Write(filaCSV(oferta))
+}
+
+// reescribirCSV deja el archivo con las ofertas recibidas que siguen en pie.
+// Se escribe en un temporal y se renombra, así nunca queda a medias.
+func (c *Consumidor) reescribirCSV() error {
+    rutaTmp := c.archivoCSV + ".tmp"
+    file, err := os.Create(rutaTmp)
+    if err != nil {
+        return err
     }
 
-    return writer.Write(record)
+    writer := csv.NewWriter(file)
+    writer.Write(encabezadoCSV)
+    for _, oferta := range c.ofertasRecibidas {
+        writer.Write(filaCSV(oferta))
+    }
+    writer.Flush()
+    if err := writer.Error(); err != nil {
+        file.Close()
+        return err
+    }
+    if err := file.Close(); err != nil {
+        return err
+    }
+    return os.Rename(rutaTmp, c.archivoCSV)
 }
 
 func (c *Consumidor) crearArchivoCSVVacio() error {
@@ -272,8 +429,7 @@ func (c *Consumidor) crearArchivoCSVVacio() error {
     writer := csv.NewWriter(file)
     defer writer.Flush()
 
-    header := []string{"oferta_id", "tienda", "categoria", "producto", "precio", "stock", "fecha"}
-    err = writer.Write(header)
+    err = writer.Write(encabezadoCSV)
     if err != nil {
         return fmt.Errorf("no se pudo escribir header en CSV: %v", err)
     }
@@ -284,11 +440,13 @@ func (c *Consumidor) crearArchivoCSVVacio() error {
 
 func main() {
 	var numeroCliente int
-	flag.IntVar(&numeroCliente, "cliente", 0, "Número del cliente (1-12)")
+	var dirMetricas string
+	flag.IntVar(&numeroCliente, "cliente", 0, "Número del cliente (fila en consumidores.csv)")
+	flag.StringVar(&dirMetricas, "metricas", os.Getenv("CONSUMIDOR_METRICAS"), "Dirección de /metrics (por defecto el puerto 9200 + número de cliente)")
 	flag.Parse()
 
-	if numeroCliente < 1 || numeroCliente > 12 {
-		log.Fatal("Debe especificar un número de cliente válido: --cliente=1-12")
+	if numeroCliente < 1 {
+		log.Fatal("Debe especificar un número de cliente válido: --cliente=<fila en consumidores.csv>")
 	}
 
 	archivoConfig := "consumidores/consumidores.csv"
@@ -300,12 +458,7 @@ func main() {
 		log.Fatalf("Error cargando configuración para cliente %d: %v", numeroCliente, err)
 	}
 
-	brokerHost := os.Getenv("BROKER_HOST")
-	if brokerHost == "" {
-		brokerHost = "broker"
-	}
-	conn, err := grpc.Dial(brokerHost + ":50051", grpc.WithTransportCredentials(insecure.NewCredentials()))
-	
+	conn, err := conexion.Conectar(conexion.DireccionesDesdeEntorno())
 	if err != nil {
 		log.Fatalf("No se pudo conectar al broker: %v", err)
 	}
@@ -315,11 +468,21 @@ func main() {
 	
 	consumidor.client = client
 
+	if dirMetricas == "" {
+		dirMetricas = fmt.Sprintf(":%d", 9200+numeroCliente)
+	}
+	metricas.Servir(dirMetricas)
+
 	log.Printf("Iniciando consumidor: %s (Cliente %d)", consumidor.id, numeroCliente)
-	log.Printf("   - Categorías: %v", consumidor.categorias)
-	log.Printf("   - Tiendas: %v", consumidor.tiendas)
-	log.Printf("   - Precio Máx: %d", consumidor.precioMax)
+	log.Printf("   - Categorías: %v", consumidor.filtro.GetCategorias())
+	log.Printf("   - Tiendas: %v", consumidor.filtro.GetTiendas())
+	log.Printf("   - Precio: %d - %d", consumidor.filtro.GetPrecioMin(), consumidor.filtro.GetPrecioMax())
+	log.Printf("   - Descuento Mín: %d%%", consumidor.filtro.GetDescuentoMin())
+	if consumidor.filtro.GetProductoContiene() != "" {
+		log.Printf("   - Producto contiene: %q", consumidor.filtro.GetProductoContiene())
+	}
 	log.Printf("   - Probabilidad de fallo: %.1f%%", consumidor.probabilidadFallo*100)
+	log.Printf("   - Probabilidad de compra: %.1f%%", consumidor.probabilidadCompra*100)
 	log.Printf("   - Archivo CSV: %s", consumidor.archivoCSV)
 
 	err = consumidor.crearArchivoCSVVacio()
@@ -329,20 +492,13 @@ func main() {
         log.Printf("Archivo CSV listo para %s", consumidor.id)
     }
 	
-	grpcServer := grpc.NewServer()
-	pb.RegisterCyberDayServiceServer(grpcServer, consumidor)
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
 
-	listener, err := net.Listen("tcp", consumidor.direccion)
-	if err != nil {
-		log.Fatalf("Error al iniciar consumidor %s: %v", consumidor.id, err)
-	}
-	
-	go consumidor.registrarEnBroker()
+	go consumidor.recargarPreferencias(ctx, archivoConfig, numeroCliente)
 
-	log.Printf("Consumidor %s listo en puerto %d", consumidor.id, consumidor.direccion)
-	log.Printf("Escuchando ofertas...")
+	log.Printf("Consumidor %s escuchando ofertas...", consumidor.id)
+	consumidor.escucharOfertas(ctx)
 
-	if err := grpcServer.Serve(listener); err != nil {
-		log.Fatalf("Error en servidor consumidor %s: %v", consumidor.id, err)
-	}
-}
+	consumidor.abandonarCluster()
+}
\ No newline at end of file
@@ -0,0 +1,28 @@
+package main
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	metricaOfertasRecibidas = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "cyberday_consumidor_ofertas_recibidas_total",
+		Help: "Ofertas nuevas recibidas y guardadas en el CSV.",
+	})
+
+	metricaCambiosRecibidos = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "cyberday_consumidor_cambios_total",
+		Help: "Versiones nuevas de ofertas ya recibidas, según su estado.",
+	}, []string{"estado"})
+
+	metricaCaidasSimuladas = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "cyberday_caidas_simuladas_total",
+		Help: "Caídas simuladas del consumidor.",
+	})
+
+	metricaCompras = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "cyberday_consumidor_compras_total",
+		Help: "Compras intentadas según su resultado.",
+	}, []string{"resultado"})
+)
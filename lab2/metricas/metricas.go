@@ -0,0 +1,30 @@
+// Package metricas expone en /metrics las métricas de Prometheus que registra
+// cada binario.
+package metricas
+
+import (
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Servir atiende /metrics en la dirección indicada. Si no puede escuchar solo
+// lo registra: el proceso sigue funcionando, sin métricas.
+func Servir(direccion string) {
+	mux := http.NewServeMux()
+	mux.Handle("GET /metrics", promhttp.Handler())
+
+	servidor := &http.Server{
+		Addr:              direccion,
+		Handler:           mux,
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+	go func() {
+		if err := servidor.ListenAndServe(); err != nil {
+			log.Printf("No se pudo servir métricas en %s: %v", direccion, err)
+		}
+	}()
+	log.Printf("Métricas en %s/metrics", direccion)
+}
@@ -0,0 +1,280 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	pb "lab2/nodos/proto"
+	"lab2/registros"
+)
+
+const (
+	archivoWAL      = "ofertas.wal"
+	archivoSnapshot = "ofertas.snapshot"
+)
+
+// Almacenamiento guarda las ofertas del nodo en disco. Cada oferta aceptada se
+// agrega a un log de escritura anticipada (WAL) antes de confirmarse, y cada
+// cierto tiempo se escribe un snapshot completo que permite vaciar el log.
+// Al iniciar se carga el snapshot y se reaplica el WAL encima.
+type Almacenamiento struct {
+	dir         string
+	mu          sync.Mutex
+	ofertas     []*pb.OfertaRequest
+	indice      map[string]int
+	wal         *os.File
+	entradasWAL int
+	limiteWAL   int
+	arbol       *arbolMerkle
+	arbolRango  map[string]*arbolMerkle
+	ordenados   []string
+	secuencia   int64
+}
+
+func AbrirAlmacenamiento(dir string, limiteWAL int) (*Almacenamiento, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("no se pudo crear directorio %s: %v", dir, err)
+	}
+
+	a := &Almacenamiento{
+		dir:       dir,
+		ofertas:   make([]*pb.OfertaRequest, 0),
+		indice:    make(map[string]int),
+		limiteWAL: limiteWAL,
+	}
+
+	desdeSnapshot, err := a.cargarArchivo(filepath.Join(dir, archivoSnapshot), false)
+	if err != nil {
+		return nil, fmt.Errorf("error leyendo snapshot: %v", err)
+	}
+
+	desdeWAL, err := a.cargarArchivo(filepath.Join(dir, archivoWAL), true)
+	if err != nil {
+		return nil, fmt.Errorf("error reaplicando WAL: %v", err)
+	}
+	a.entradasWAL = desdeWAL
+
+	a.wal, err = os.OpenFile(filepath.Join(dir, archivoWAL), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("no se pudo abrir WAL: %v", err)
+	}
+
+	log.Printf("Almacenamiento %s recuperado: %d ofertas (snapshot: %d, WAL: %d)",
+		dir, len(a.ofertas), desdeSnapshot, desdeWAL)
+	return a, nil
+}
+
+// cargarArchivo lee los registros de un archivo y los aplica en memoria.
+// En el WAL una cola incompleta o corrupta corresponde a una escritura
+// interrumpida por una caída, así que se descarta y se trunca el archivo.
+func (a *Almacenamiento) cargarArchivo(ruta string, truncarCola bool) (int, error) {
+	file, err := os.Open(ruta)
+	if errors.Is(err, os.ErrNotExist) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	defer file.Close()
+
+	reader := bufio.NewReader(file)
+	aplicados := 0
+	var offsetValido int64
+
+	for {
+		// Las ofertas llegan por el servidor gRPC del nodo, que usa el límite por
+		// defecto
+		oferta := &pb.OfertaRequest{}
+		n, err := registros.Leer(reader, oferta, registros.MaxMensajeGRPC)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			if !truncarCola {
+				return aplicados, err
+			}
+			log.Printf("WAL con cola corrupta en byte %d (%v) - truncando", offsetValido, err)
+			if err := os.Truncate(ruta, offsetValido); err != nil {
+				return aplicados, err
+			}
+			break
+		}
+		offsetValido += int64(n)
+		if a.aplicar(oferta) {
+			aplicados++
+		}
+	}
+
+	return aplicados, nil
+}
+
+// aplicar agrega la oferta o reemplaza la almacenada si la nueva tiene una
+// versión mayor. Retorna false si no hubo cambios.
+func (a *Almacenamiento) aplicar(oferta *pb.OfertaRequest) bool {
+	if !a.esMasNueva(oferta) {
+		return false
+	}
+	a.arbol = nil
+	a.arbolRango = nil
+	a.secuencia = max(a.secuencia, oferta.GetSecuencia())
+	if i, existe := a.indice[oferta.GetOfertaId()]; existe {
+		a.ofertas[i] = oferta
+		return true
+	}
+	a.indice[oferta.GetOfertaId()] = len(a.ofertas)
+	a.ofertas = append(a.ofertas, oferta)
+	a.ordenados = nil
+	return true
+}
+
+func (a *Almacenamiento) esMasNueva(oferta *pb.OfertaRequest) bool {
+	i, existe := a.indice[oferta.GetOfertaId()]
+	return !existe || oferta.GetVersion() > a.ofertas[i].GetVersion()
+}
+
+// Guardar escribe la oferta en el WAL y la aplica en memoria. Retorna false
+// si ya estaba almacenada con la misma versión o una más nueva.
+func (a *Almacenamiento) Guardar(oferta *pb.OfertaRequest) (bool, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if !a.esMasNueva(oferta) {
+		return false, nil
+	}
+
+	if err := registros.Escribir(a.wal, oferta); err != nil {
+		return false, fmt.Errorf("error escribiendo WAL: %v", err)
+	}
+	if err := a.wal.Sync(); err != nil {
+		return false, fmt.Errorf("error sincronizando WAL: %v", err)
+	}
+
+	a.aplicar(oferta)
+	a.entradasWAL++
+
+	if a.limiteWAL > 0 && a.entradasWAL >= a.limiteWAL {
+		if err := a.snapshotLocked(); err != nil {
+			log.Printf("Error generando snapshot: %v", err)
+		}
+	}
+
+	return true, nil
+}
+
+func (a *Almacenamiento) Existe(ofertaID string) bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	_, existe := a.indice[ofertaID]
+	return existe
+}
+
+func (a *Almacenamiento) Buscar(ofertaID string) (*pb.OfertaRequest, bool) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	i, existe := a.indice[ofertaID]
+	if !existe {
+		return nil, false
+	}
+	return a.ofertas[i], true
+}
+
+// Secuencia retorna la mayor secuencia entre las ofertas guardadas. Es lo que
+// el nodo envía al resincronizarse para recibir solo lo posterior.
+func (a *Almacenamiento) Secuencia() int64 {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.secuencia
+}
+
+func (a *Almacenamiento) Cantidad() int {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	return len(a.ofertas)
+}
+
+func (a *Almacenamiento) Snapshot() error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	return a.snapshotLocked()
+}
+
+// snapshotLocked escribe todas las ofertas en un archivo temporal, lo renombra
+// sobre el snapshot anterior y recién entonces vacía el WAL. Si el proceso cae
+// entre ambos pasos el WAL se reaplica sobre el snapshot nuevo sin duplicar,
+// porque las ofertas se indexan por ID y solo se reemplazan por versiones mayores.
+func (a *Almacenamiento) snapshotLocked() error {
+	if a.entradasWAL == 0 {
+		return nil
+	}
+
+	rutaTmp := filepath.Join(a.dir, archivoSnapshot+".tmp")
+	tmp, err := os.Create(rutaTmp)
+	if err != nil {
+		return err
+	}
+
+	writer := bufio.NewWriter(tmp)
+	for _, oferta := range a.ofertas {
+		if err := registros.Escribir(writer, oferta); err != nil {
+			tmp.Close()
+			return err
+		}
+	}
+	if err := writer.Flush(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	if err := os.Rename(rutaTmp, filepath.Join(a.dir, archivoSnapshot)); err != nil {
+		return err
+	}
+
+	if err := a.wal.Truncate(0); err != nil {
+		return err
+	}
+	a.entradasWAL = 0
+
+	log.Printf("Snapshot generado en %s: %d ofertas", a.dir, len(a.ofertas))
+	return nil
+}
+
+func (a *Almacenamiento) iniciarSnapshotsPeriodicos(intervalo time.Duration) {
+	go func() {
+		ticker := time.NewTicker(intervalo)
+		defer ticker.Stop()
+
+		for range ticker.C {
+			if err := a.Snapshot(); err != nil {
+				log.Printf("Error generando snapshot periódico: %v", err)
+			}
+		}
+	}()
+}
+
+func (a *Almacenamiento) Cerrar() error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if err := a.snapshotLocked(); err != nil {
+		log.Printf("Error generando snapshot al cerrar: %v", err)
+	}
+	return a.wal.Close()
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"sort"
+	"strings"
+
+	pb "lab2/nodos/proto"
+)
+
+const (
+	tamanoPaginaPorDefecto = 500
+	tamanoPaginaMaximo     = 5000
+)
+
+// Pagina retorna las ofertas que cumplen los filtros de la lectura, ordenadas
+// por ID, a partir de la que sigue al token. El token de la página siguiente
+// es el ID de la última oferta entregada; queda vacío en la última página.
+// Como el orden es por ID, las ofertas que llegan entre una página y otra no
+// desplazan a las ya entregadas.
+func (a *Almacenamiento) Pagina(req *pb.LecturaRequest) ([]*pb.OfertaRequest, string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	tamano := int(req.GetTamanoPagina())
+	if tamano <= 0 {
+		tamano = tamanoPaginaPorDefecto
+	}
+	tamano = min(tamano, tamanoPaginaMaximo)
+
+	ids := a.idsOrdenadosLocked()
+	prefijo := req.GetPrefijoId()
+	i := sort.SearchStrings(ids, max(req.GetToken(), prefijo))
+	if i < len(ids) && ids[i] == req.GetToken() {
+		i++
+	}
+
+	var ofertas []*pb.OfertaRequest
+	for ; i < len(ids); i++ {
+		if !strings.HasPrefix(ids[i], prefijo) {
+			// Los IDs con el prefijo son contiguos
+			break
+		}
+		oferta := a.ofertas[a.indice[ids[i]]]
+		if !cumpleLectura(oferta, req) {
+			continue
+		}
+		ofertas = append(ofertas, oferta)
+		if len(ofertas) == tamano {
+			if i+1 < len(ids) {
+				return ofertas, ids[i]
+			}
+			break
+		}
+	}
+	return ofertas, ""
+}
+
+// idsOrdenadosLocked se reconstruye solo si llegaron ofertas nuevas desde la
+// última lectura; una versión nueva de una oferta no cambia el orden.
+func (a *Almacenamiento) idsOrdenadosLocked() []string {
+	if a.ordenados == nil {
+		a.ordenados = make([]string, 0, len(a.ofertas))
+		for _, oferta := range a.ofertas {
+			a.ordenados = append(a.ordenados, oferta.GetOfertaId())
+		}
+		sort.Strings(a.ordenados)
+	}
+	return a.ordenados
+}
+
+func cumpleLectura(oferta *pb.OfertaRequest, req *pb.LecturaRequest) bool {
+	if len(req.GetTiendas()) > 0 && !contiene(req.GetTiendas(), oferta.GetTienda()) {
+		return false
+	}
+	if len(req.GetCategorias()) > 0 && !contiene(req.GetCategorias(), oferta.GetCategoria()) {
+		return false
+	}
+	if oferta.GetPrecio() < req.GetPrecioMin() {
+		return false
+	}
+	if req.GetPrecioMax() > 0 && oferta.GetPrecio() > req.GetPrecioMax() {
+		return false
+	}
+	// Las fechas tienen formato fijo, así que se comparan como texto
+	if req.GetDesdeFecha() != "" && oferta.GetFecha() < req.GetDesdeFecha() {
+		return false
+	}
+	if req.GetHastaFecha() != "" && oferta.GetFecha() >= req.GetHastaFecha() {
+		return false
+	}
+	if oferta.GetSecuencia() <= req.GetDesdeSecuencia() {
+		return false
+	}
+	return true
+}
+
+func contiene(valores []string, valor string) bool {
+	for _, v := range valores {
+		if v == valor {
+			return true
+		}
+	}
+	return false
+}
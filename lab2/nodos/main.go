@@ -3,15 +3,23 @@ package main
 import (
 	"context"
 	"flag"
+	"fmt"
 	"log"
 	"math/rand"
 	"sync"
 	"net"
 	"time"
 	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
 
 	"google.golang.org/grpc"
-	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"lab2/conexion"
+	"lab2/metricas"
 	pb "lab2/nodos/proto"
 )
 
@@ -19,7 +27,7 @@ type NodoDB struct {
 	pb.UnimplementedCyberDayServiceServer
 	nombre        	string
 	direccion       string
-	ofertas       	[]*pb.OfertaRequest
+	almacen       	*Almacenamiento
 	mu            	sync.Mutex
 	contadorOfertas int
 	probFallo 		float64
@@ -27,6 +35,7 @@ type NodoDB struct {
 	caidasSimuladas int
 	startTime     	time.Time
 	client          pb.CyberDayServiceClient
+	salud           *health.Server
 }
 
 func (n *NodoDB) registrarEnBroker(nodoID string) {
@@ -47,6 +56,23 @@ func (n *NodoDB) registrarEnBroker(nodoID string) {
 	}
 }
 
+// abandonarCluster avisa al broker que el nodo sale voluntariamente, para que
+// deje de considerarlo en el quorum.
+func (n *NodoDB) abandonarCluster() {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	resp, err := n.client.AbandonarCluster(ctx, &pb.SalidaRequest{
+		EntidadId: n.nombre,
+		Tipo:      "nodo",
+	})
+	if err != nil || !resp.GetExito() {
+		log.Printf("%s no pudo avisar su salida al broker: %v", n.nombre, err)
+		return
+	}
+	log.Printf("%s abandonó el cluster", n.nombre)
+}
+
 func (n *NodoDB) EnviarOferta(ctx context.Context, req *pb.OfertaRequest) (*pb.OfertaResponse, error) {
 	n.mu.Lock()
 	defer n.mu.Unlock()
@@ -59,6 +85,7 @@ func (n *NodoDB) EnviarOferta(ctx context.Context, req *pb.OfertaRequest) (*pb.O
 	// Si está en fallo, no procesar
 	if n.enFallo {
 		log.Printf("%s en fallo - rechazando oferta", n.nombre)
+		metricaEscrituras.WithLabelValues("rechazada").Inc()
 		return &pb.OfertaResponse{Exito: false}, nil
 	}
 
@@ -77,29 +104,37 @@ func (n *NodoDB) EnviarOferta(ctx context.Context, req *pb.OfertaRequest) (*pb.O
 	if !n.enFallo && n.probFallo > 0 && puedeFallar {
 		if rand.Float64() < n.probFallo {
 			n.simularFallo()
+			metricaEscrituras.WithLabelValues("rechazada").Inc()
 			return &pb.OfertaResponse{Exito: false}, nil
 		}
 	}
 
-	for _, ofertaExistente := range n.ofertas {
-		if ofertaExistente.GetOfertaId() == req.GetOfertaId() {
-			log.Printf("%s: Oferta duplicada %s - ignorando", n.nombre, req.GetOfertaId())
-			return &pb.OfertaResponse{Exito: true}, nil
-		}
+	aplicada, err := n.almacen.Guardar(req)
+	if err != nil {
+		log.Printf("%s error persistiendo oferta %s: %v", n.nombre, req.GetOfertaId(), err)
+		metricaEscrituras.WithLabelValues("error").Inc()
+		return &pb.OfertaResponse{Exito: false}, nil
+	}
+	if !aplicada {
+		metricaEscrituras.WithLabelValues("repetida").Inc()
+		log.Printf("%s: Oferta %s (v%d) ya almacenada con igual o mayor versión - ignorando", n.nombre, req.GetOfertaId(), req.GetVersion())
+		return &pb.OfertaResponse{Exito: true}, nil
 	}
 
-	n.contadorOfertas++
-	n.ofertas = append(n.ofertas, req)
+	n.contadorOfertas = n.almacen.Cantidad()
+	metricaEscrituras.WithLabelValues("guardada").Inc()
 
-	log.Printf("%s almacenó: %s - $%d", n.nombre, req.GetProducto(), req.GetPrecio())
-	log.Printf("   - Total en %s: %d ofertas", n.nombre, len(n.ofertas))
+	log.Printf("%s almacenó: %s - $%d (v%d)", n.nombre, req.GetProducto(), req.GetPrecio(), req.GetVersion())
+	log.Printf("   - Total en %s: %d ofertas", n.nombre, n.contadorOfertas)
 
 	return &pb.OfertaResponse{Exito: true}, nil
 }
 
 func (n *NodoDB) simularFallo() {
 	n.enFallo = true
+	n.salud.SetServingStatus("", healthpb.HealthCheckResponse_NOT_SERVING)
 	n.caidasSimuladas++
+	metricaCaidasSimuladas.Inc()
 	
 	log.Printf("%s CAÍDA SIMULADA - Probabilidad: %.1f%%", 
 		n.nombre, n.probFallo*100)
@@ -118,6 +153,7 @@ func (n *NodoDB) recuperarAutomaticamente() {
 	n.mu.Lock()
     if exito {
         n.enFallo = false
+        n.salud.SetServingStatus("", healthpb.HealthCheckResponse_SERVING)
         log.Printf("%s COMPLETAMENTE RECUPERADO Y SINCRONIZADO", n.nombre)
     } else {
         log.Printf("%s falló resincronización - reintentando en 5s", n.nombre)
@@ -133,43 +169,61 @@ func (n *NodoDB) solicitarResincronizacion() bool {
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
 
-    n.mu.Lock()
-    ofertasActuales := n.ofertas
-    n.mu.Unlock()
+    desde := n.almacen.Secuencia()
+    inicio := time.Now()
 
     resp, err := n.client.SincronizarEntidad(ctx, &pb.SincronizacionRequest{
         EntidadId:      n.nombre,
         Tipo:           "nodo",
-        OfertasActuales: ofertasActuales,
+        DesdeSecuencia: desde,
     })
     
     if err != nil || !resp.GetExito() {
         log.Printf("%s error en resincronización: %v", n.nombre, err)
+        metricaResincronizacionDuracion.WithLabelValues("fallo").Observe(time.Since(inicio).Seconds())
         return false
     }
 
     n.mu.Lock()
     ofertasRecibidas := 0
-    for _, oferta := range resp.GetOfertasFaltantes() {
-        existe := false
-        for _, ofertaExistente := range n.ofertas {
-            if ofertaExistente.GetOfertaId() == oferta.GetOfertaId() {
-                existe = true
-                break
-            }
+    for _, oferta := range append(resp.GetOfertasFaltantes(), resp.GetHints()...) {
+        aplicada, err := n.almacen.Guardar(oferta)
+        if err != nil {
+            n.mu.Unlock()
+            log.Printf("%s error persistiendo oferta resincronizada %s: %v", n.nombre, oferta.GetOfertaId(), err)
+            metricaResincronizacionDuracion.WithLabelValues("fallo").Observe(time.Since(inicio).Seconds())
+            return false
         }
-        if !existe {
-            n.ofertas = append(n.ofertas, oferta)
+        if aplicada {
             ofertasRecibidas++
         }
     }
-    n.contadorOfertas = len(n.ofertas)
+    n.contadorOfertas = n.almacen.Cantidad()
     n.mu.Unlock()
 
-    log.Printf("%s resincronizado: +%d ofertas", n.nombre, ofertasRecibidas)
+    metricaResincronizacionDuracion.WithLabelValues("exito").Observe(time.Since(inicio).Seconds())
+    metricaResincronizacionOfertas.Observe(float64(ofertasRecibidas))
+    log.Printf("%s resincronizado desde secuencia %d: +%d ofertas", n.nombre, desde, ofertasRecibidas)
+    n.confirmarHints(ctx, resp.GetHints())
     return true
 }
 
+// confirmarHints avisa al broker qué hints quedaron guardados. Si el aviso se
+// pierde no pasa nada: el broker los reenvía y guardarlos de nuevo no cambia nada.
+func (n *NodoDB) confirmarHints(ctx context.Context, hints []*pb.OfertaRequest) {
+    if len(hints) == 0 {
+        return
+    }
+    req := &pb.ConfirmacionHintsRequest{NodoId: n.nombre}
+    for _, hint := range hints {
+        req.Hints = append(req.Hints, &pb.HintEntregado{OfertaId: hint.GetOfertaId(), Version: hint.GetVersion()})
+    }
+    resp, err := n.client.ConfirmarHints(ctx, req)
+    if err != nil || !resp.GetExito() {
+        log.Printf("%s no pudo confirmar %d hints: %v", n.nombre, len(hints), err)
+    }
+}
+
 func (n *NodoDB) LeerOfertas(ctx context.Context, req *pb.LecturaRequest) (*pb.LecturaResponse, error) {
 	n.mu.Lock()
 	defer n.mu.Unlock()
@@ -178,10 +232,32 @@ func (n *NodoDB) LeerOfertas(ctx context.Context, req *pb.LecturaRequest) (*pb.L
 		return &pb.LecturaResponse{Exito: false}, nil
 	}
 
-	log.Printf("%s enviando %d ofertas", n.nombre, len(n.ofertas))
+	ofertas, siguiente := n.almacen.Pagina(req)
+	log.Printf("%s enviando %d ofertas", n.nombre, len(ofertas))
 	
 	return &pb.LecturaResponse{
-		Ofertas: n.ofertas,
+		Ofertas:         ofertas,
+		Exito:           true,
+		SiguienteToken:  siguiente,
+		UltimaSecuencia: n.almacen.Secuencia(),
+	}, nil
+}
+
+// LeerOferta retorna la oferta pedida, o ninguna si el nodo no la tiene.
+func (n *NodoDB) LeerOferta(ctx context.Context, req *pb.LecturaOfertaRequest) (*pb.LecturaResponse, error) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if n.enFallo {
+		return &pb.LecturaResponse{Exito: false}, nil
+	}
+
+	var ofertas []*pb.OfertaRequest
+	if oferta, existe := n.almacen.Buscar(req.GetOfertaId()); existe {
+		ofertas = append(ofertas, oferta)
+	}
+	return &pb.LecturaResponse{
+		Ofertas: ofertas,
 		Exito:   true,
 	}, nil
 }
@@ -189,54 +265,58 @@ func (n *NodoDB) LeerOfertas(ctx context.Context, req *pb.LecturaRequest) (*pb.L
 func main() {
 	var nodoID string
 	var direccion string
-	flag.StringVar(&nodoID, "nodo", "", "ID del nodo DB (DB1, DB2, DB3)")
+	var dirDatos string
+	var puerto string
+	var dirMetricas string
+	flag.StringVar(&nodoID, "nodo", "", "ID del nodo DB (DB1, DB2, DB3 u otro)")
+	flag.StringVar(&dirDatos, "datos", "", "Directorio donde se persisten las ofertas (por defecto datos/<nodo>)")
+	flag.StringVar(&puerto, "puerto", os.Getenv("NODO_PUERTO"), "Puerto del nodo (obligatorio para nodos distintos de DB1-DB3)")
+	flag.StringVar(&dirMetricas, "metricas", os.Getenv("NODO_METRICAS"), "Dirección de /metrics (por defecto el puerto del nodo + 1000)")
 	flag.Parse()
 
 	if nodoID == "" {
-		log.Fatal("Debe especificar el nodo: --nodo=DB1|DB2|DB3")
+		log.Fatal("Debe especificar el nodo: --nodo=DB1|DB2|DB3|<nombre> [--puerto=:<puerto>]")
 	}
 
 	direccion = os.Getenv("NODO_DIRECCION")
 
-	puerto := ""
-	var probFallo float64
-
-	switch nodoID {
-	case "DB1":
-		puerto = ":50052"
-		probFallo = 0.1
-	case "DB2":
-		puerto = ":50053"
-		probFallo = 0.1
-	case "DB3":
-		puerto = ":50054"
-		probFallo = 0.1
-	default:
-		log.Fatalf("Nodo no válido: %s", nodoID)
-	}
+	probFallo := 0.1
 
-	if direccion == "" {
+	if puerto == "" {
 		switch nodoID {
 		case "DB1":
-			direccion = "localhost:50052"
+			puerto = ":50052"
 		case "DB2":
-			direccion = "localhost:50053"
+			puerto = ":50053"
 		case "DB3":
-			direccion = "localhost:50054"
+			puerto = ":50054"
+		default:
+			log.Fatalf("Nodo %s necesita --puerto o NODO_PUERTO", nodoID)
 		}
 	}
 
+	if !strings.HasPrefix(puerto, ":") {
+		puerto = ":" + puerto
+	}
+
+	if direccion == "" {
+		direccion = "localhost" + puerto
+	}
+
+	if dirMetricas == "" {
+		numero, err := strconv.Atoi(strings.TrimPrefix(puerto, ":"))
+		if err != nil {
+			log.Fatalf("Puerto inválido: %s", puerto)
+		}
+		dirMetricas = fmt.Sprintf(":%d", numero+1000)
+	}
+
 	rand.Seed(time.Now().UnixNano())
 
 	log.Printf("Iniciando nodo: %s en %s", nodoID, direccion)
 	log.Printf("Probabilidad de fallo: %.1f%%", probFallo*100)
 
-	brokerHost := os.Getenv("BROKER_HOST")
-	if brokerHost == "" {
-		brokerHost = "broker"  // nombre del servicio en docker-compose
-	}
-	conn, err := grpc.Dial(brokerHost + ":50051", grpc.WithTransportCredentials(insecure.NewCredentials()))
-	
+	conn, err := conexion.Conectar(conexion.DireccionesDesdeEntorno())
 	if err != nil {
 		log.Fatalf("No se pudo conectar al broker: %v", err)
 	}
@@ -244,20 +324,40 @@ func main() {
 
 	client := pb.NewCyberDayServiceClient(conn)
 
+	if dirDatos == "" {
+		dirDatos = os.Getenv("NODO_DATOS")
+	}
+	if dirDatos == "" {
+		dirDatos = "datos/" + nodoID
+	}
+
+	// Se recupera el estado desde disco antes de registrarse en el broker
+	almacen, err := AbrirAlmacenamiento(dirDatos, 500)
+	if err != nil {
+		log.Fatalf("Error recuperando almacenamiento de %s: %v", nodoID, err)
+	}
+	defer almacen.Cerrar()
+	almacen.iniciarSnapshotsPeriodicos(30 * time.Second)
+	registrarMetricasAlmacen(almacen)
+	metricas.Servir(dirMetricas)
+
 	nodo := &NodoDB{
 		nombre:           nodoID,
 		direccion:        direccion,
-		ofertas:          make([]*pb.OfertaRequest, 0),
-		contadorOfertas:  0,
+		almacen:          almacen,
+		contadorOfertas:  almacen.Cantidad(),
 		probFallo: probFallo,
 		enFallo:          false,
 		caidasSimuladas:  0,
 		startTime:        time.Time{},
 		client:           client,
+		salud:            health.NewServer(),
 	}
 	
 	grpcServer := grpc.NewServer()
 	pb.RegisterCyberDayServiceServer(grpcServer, nodo)
+	// El broker usa el protocolo estándar de health checking como latido
+	healthpb.RegisterHealthServer(grpcServer, nodo.salud)
 
 	listener, err := net.Listen("tcp", puerto)
 	if err != nil {
@@ -266,6 +366,15 @@ func main() {
 	
 	go nodo.registrarEnBroker(nodoID)
 
+	go func() {
+		senales := make(chan os.Signal, 1)
+		signal.Notify(senales, syscall.SIGINT, syscall.SIGTERM)
+		<-senales
+		nodo.abandonarCluster()
+		nodo.salud.Shutdown()
+		grpcServer.GracefulStop()
+	}()
+
 	log.Printf("Nodo %s listo en puerto %s", nodoID, puerto)
 
 	if err := grpcServer.Serve(listener); err != nil {
@@ -0,0 +1,195 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"hash/fnv"
+	"log"
+	"sort"
+	"strings"
+
+	pb "lab2/nodos/proto"
+)
+
+// hojasMerkle es la cantidad de buckets en que se reparten los IDs de oferta.
+// Debe ser potencia de 2 y la misma en todos los nodos.
+const hojasMerkle = 64
+
+// arbolMerkle guarda los hashes en un arreglo tipo heap: la raíz está en la
+// posición 1, los hijos de p en 2p y 2p+1, y el bucket i en hojasMerkle+i.
+// Cada hoja es el hash de los pares (ID, versión) de su bucket, así dos
+// réplicas con el mismo contenido tienen exactamente el mismo árbol.
+type arbolMerkle struct {
+	hashes [][]byte
+}
+
+func bucketDeOferta(ofertaID string) int {
+	h := fnv.New32a()
+	h.Write([]byte(ofertaID))
+	return int(h.Sum32() % hojasMerkle)
+}
+
+// posicionEnAnillo es la posición de una oferta en el anillo de hashing
+// consistente del broker. Debe coincidir con la que usa el broker.
+func posicionEnAnillo(ofertaID string) uint64 {
+	suma := sha256.Sum256([]byte(ofertaID))
+	return binary.BigEndian.Uint64(suma[:8])
+}
+
+// enRangos indica si la oferta cae en alguno de los tramos (inicio, fin] del
+// anillo. Sin rangos se aceptan todas.
+func enRangos(ofertaID string, rangos []*pb.RangoAnillo) bool {
+	if len(rangos) == 0 {
+		return true
+	}
+	posicion := posicionEnAnillo(ofertaID)
+	for _, rango := range rangos {
+		inicio, fin := rango.GetInicio(), rango.GetFin()
+		switch {
+		case inicio == fin:
+			return true
+		case inicio < fin && posicion > inicio && posicion <= fin:
+			return true
+		case inicio > fin && (posicion > inicio || posicion <= fin):
+			return true
+		}
+	}
+	return false
+}
+
+func construirArbolMerkle(ofertas []*pb.OfertaRequest) *arbolMerkle {
+	buckets := make([][]*pb.OfertaRequest, hojasMerkle)
+	for _, oferta := range ofertas {
+		i := bucketDeOferta(oferta.GetOfertaId())
+		buckets[i] = append(buckets[i], oferta)
+	}
+
+	arbol := &arbolMerkle{hashes: make([][]byte, 2*hojasMerkle)}
+	for i, bucket := range buckets {
+		sort.Slice(bucket, func(a, b int) bool {
+			return bucket[a].GetOfertaId() < bucket[b].GetOfertaId()
+		})
+		h := sha256.New()
+		for _, oferta := range bucket {
+			fmt.Fprintf(h, "%s:%d\n", oferta.GetOfertaId(), oferta.GetVersion())
+		}
+		arbol.hashes[hojasMerkle+i] = h.Sum(nil)
+	}
+
+	for p := hojasMerkle - 1; p >= 1; p-- {
+		h := sha256.New()
+		h.Write(arbol.hashes[2*p])
+		h.Write(arbol.hashes[2*p+1])
+		arbol.hashes[p] = h.Sum(nil)
+	}
+	return arbol
+}
+
+func (a *arbolMerkle) hash(posicion int32) []byte {
+	if posicion < 1 || int(posicion) >= len(a.hashes) {
+		return nil
+	}
+	return a.hashes[posicion]
+}
+
+// claveRangos identifica un conjunto de rangos sin importar su orden.
+func claveRangos(rangos []*pb.RangoAnillo) string {
+	tramos := make([]string, len(rangos))
+	for i, rango := range rangos {
+		tramos[i] = fmt.Sprintf("%d-%d", rango.GetInicio(), rango.GetFin())
+	}
+	sort.Strings(tramos)
+	return strings.Join(tramos, ",")
+}
+
+// ArbolMerkle retorna el árbol de las ofertas almacenadas. Se reconstruye solo
+// si hubo escrituras desde la última vez. Con rangos se guarda un árbol por
+// cada conjunto pedido: cada par de nodos comparte rangos distintos, pero una
+// comparación pide varias veces los mismos mientras baja por el árbol.
+func (a *Almacenamiento) ArbolMerkle(rangos []*pb.RangoAnillo) *arbolMerkle {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if len(rangos) > 0 {
+		clave := claveRangos(rangos)
+		if arbol, existe := a.arbolRango[clave]; existe {
+			return arbol
+		}
+		var ofertas []*pb.OfertaRequest
+		for _, oferta := range a.ofertas {
+			if enRangos(oferta.GetOfertaId(), rangos) {
+				ofertas = append(ofertas, oferta)
+			}
+		}
+		if a.arbolRango == nil {
+			a.arbolRango = make(map[string]*arbolMerkle)
+		}
+		a.arbolRango[clave] = construirArbolMerkle(ofertas)
+		return a.arbolRango[clave]
+	}
+
+	if a.arbol == nil {
+		a.arbol = construirArbolMerkle(a.ofertas)
+	}
+	return a.arbol
+}
+
+func (a *Almacenamiento) OfertasDeBuckets(buckets []int32, rangos []*pb.RangoAnillo) []*pb.OfertaRequest {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	pedidos := make(map[int]bool, len(buckets))
+	for _, bucket := range buckets {
+		pedidos[int(bucket)] = true
+	}
+
+	var ofertas []*pb.OfertaRequest
+	for _, oferta := range a.ofertas {
+		if pedidos[bucketDeOferta(oferta.GetOfertaId())] && enRangos(oferta.GetOfertaId(), rangos) {
+			ofertas = append(ofertas, oferta)
+		}
+	}
+	return ofertas
+}
+
+func (n *NodoDB) ObtenerHashesMerkle(ctx context.Context, req *pb.HashesMerkleRequest) (*pb.HashesMerkleResponse, error) {
+	n.mu.Lock()
+	enFallo := n.enFallo
+	n.mu.Unlock()
+
+	if enFallo {
+		return &pb.HashesMerkleResponse{Exito: false}, nil
+	}
+
+	arbol := n.almacen.ArbolMerkle(req.GetRangos())
+	hashes := make([][]byte, len(req.GetPosiciones()))
+	for i, posicion := range req.GetPosiciones() {
+		hashes[i] = arbol.hash(posicion)
+	}
+
+	return &pb.HashesMerkleResponse{
+		Hashes: hashes,
+		Hojas:  hojasMerkle,
+		Exito:  true,
+	}, nil
+}
+
+func (n *NodoDB) LeerBuckets(ctx context.Context, req *pb.LecturaBucketsRequest) (*pb.LecturaResponse, error) {
+	n.mu.Lock()
+	enFallo := n.enFallo
+	n.mu.Unlock()
+
+	if enFallo {
+		return &pb.LecturaResponse{Exito: false}, nil
+	}
+
+	ofertas := n.almacen.OfertasDeBuckets(req.GetBuckets(), req.GetRangos())
+	log.Printf("%s enviando %d ofertas de %d buckets", n.nombre, len(ofertas), len(req.GetBuckets()))
+
+	return &pb.LecturaResponse{
+		Ofertas: ofertas,
+		Exito:   true,
+	}, nil
+}
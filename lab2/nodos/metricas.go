@@ -0,0 +1,41 @@
+package main
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	metricaEscrituras = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "cyberday_nodo_escrituras_total",
+		Help: "Ofertas recibidas del broker según el resultado de guardarlas.",
+	}, []string{"resultado"})
+
+	metricaCaidasSimuladas = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "cyberday_caidas_simuladas_total",
+		Help: "Caídas simuladas del nodo.",
+	})
+
+	metricaResincronizacionDuracion = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "cyberday_resincronizacion_segundos",
+		Help:    "Duración de cada resincronización con el broker.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"resultado"})
+
+	metricaResincronizacionOfertas = promauto.NewHistogram(prometheus.HistogramOpts{
+		Name:    "cyberday_resincronizacion_ofertas",
+		Help:    "Ofertas nuevas recibidas en cada resincronización exitosa.",
+		Buckets: prometheus.ExponentialBuckets(1, 4, 8),
+	})
+)
+
+// registrarMetricasAlmacen expone la cantidad de ofertas guardadas, que se lee
+// del almacenamiento en cada consulta.
+func registrarMetricasAlmacen(almacen *Almacenamiento) {
+	promauto.NewGaugeFunc(prometheus.GaugeOpts{
+		Name: "cyberday_nodo_ofertas",
+		Help: "Ofertas guardadas en el nodo.",
+	}, func() float64 {
+		return float64(almacen.Cantidad())
+	})
+}
@@ -21,6 +21,225 @@ const (
 	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
 )
 
+// ********** Filtros de suscripción ***********
+// Las condiciones de un filtro se cumplen todas a la vez (los valores vacíos o
+// en 0 no restringen). Si además tiene subfiltros, se combinan con el operador:
+// Y exige que coincidan todos, O que coincida alguno y NO que no coincida
+// ninguno. Si se entrega un filtro, reemplaza a categorias/tiendas/precio_max.
+type OperadorFiltro int32
+
+const (
+	OperadorFiltro_Y  OperadorFiltro = 0
+	OperadorFiltro_O  OperadorFiltro = 1
+	OperadorFiltro_NO OperadorFiltro = 2
+)
+
+// Enum value maps for OperadorFiltro.
+var (
+	OperadorFiltro_name = map[int32]string{
+		0: "Y",
+		1: "O",
+		2: "NO",
+	}
+	OperadorFiltro_value = map[string]int32{
+		"Y":  0,
+		"O":  1,
+		"NO": 2,
+	}
+)
+
+func (x OperadorFiltro) Enum() *OperadorFiltro {
+	p := new(OperadorFiltro)
+	*p = x
+	return p
+}
+
+func (x OperadorFiltro) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (OperadorFiltro) Descriptor() protoreflect.EnumDescriptor {
+	return file_proto_cyberday_proto_enumTypes[0].Descriptor()
+}
+
+func (OperadorFiltro) Type() protoreflect.EnumType {
+	return &file_proto_cyberday_proto_enumTypes[0]
+}
+
+func (x OperadorFiltro) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use OperadorFiltro.Descriptor instead.
+func (OperadorFiltro) EnumDescriptor() ([]byte, []int) {
+	return file_proto_cyberday_proto_rawDescGZIP(), []int{0}
+}
+
+// Una oferta agotada puede volver a tener stock. Retirada y expirada son
+// lápidas: la oferta ya no cambia, pero se conserva para que una réplica
+// atrasada no la reviva.
+type EstadoOferta int32
+
+const (
+	EstadoOferta_VIGENTE  EstadoOferta = 0
+	EstadoOferta_AGOTADA  EstadoOferta = 1
+	EstadoOferta_RETIRADA EstadoOferta = 2
+	EstadoOferta_EXPIRADA EstadoOferta = 3
+)
+
+// Enum value maps for EstadoOferta.
+var (
+	EstadoOferta_name = map[int32]string{
+		0: "VIGENTE",
+		1: "AGOTADA",
+		2: "RETIRADA",
+		3: "EXPIRADA",
+	}
+	EstadoOferta_value = map[string]int32{
+		"VIGENTE":  0,
+		"AGOTADA":  1,
+		"RETIRADA": 2,
+		"EXPIRADA": 3,
+	}
+)
+
+func (x EstadoOferta) Enum() *EstadoOferta {
+	p := new(EstadoOferta)
+	*p = x
+	return p
+}
+
+func (x EstadoOferta) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (EstadoOferta) Descriptor() protoreflect.EnumDescriptor {
+	return file_proto_cyberday_proto_enumTypes[1].Descriptor()
+}
+
+func (EstadoOferta) Type() protoreflect.EnumType {
+	return &file_proto_cyberday_proto_enumTypes[1]
+}
+
+func (x EstadoOferta) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use EstadoOferta.Descriptor instead.
+func (EstadoOferta) EnumDescriptor() ([]byte, []int) {
+	return file_proto_cyberday_proto_rawDescGZIP(), []int{1}
+}
+
+// ******** Mensajes para búsqueda de ofertas **********
+// Búsqueda abierta a cualquier cliente, con lectura de quorum R. Sin
+// incluir_no_vigentes solo se entregan las ofertas vigentes y sin expirar.
+// total es la cantidad que coincide antes de aplicar el límite.
+type OrdenBusqueda int32
+
+const (
+	OrdenBusqueda_POR_ID         OrdenBusqueda = 0
+	OrdenBusqueda_PRECIO_ASC     OrdenBusqueda = 1
+	OrdenBusqueda_PRECIO_DESC    OrdenBusqueda = 2
+	OrdenBusqueda_DESCUENTO_DESC OrdenBusqueda = 3
+)
+
+// Enum value maps for OrdenBusqueda.
+var (
+	OrdenBusqueda_name = map[int32]string{
+		0: "POR_ID",
+		1: "PRECIO_ASC",
+		2: "PRECIO_DESC",
+		3: "DESCUENTO_DESC",
+	}
+	OrdenBusqueda_value = map[string]int32{
+		"POR_ID":         0,
+		"PRECIO_ASC":     1,
+		"PRECIO_DESC":    2,
+		"DESCUENTO_DESC": 3,
+	}
+)
+
+func (x OrdenBusqueda) Enum() *OrdenBusqueda {
+	p := new(OrdenBusqueda)
+	*p = x
+	return p
+}
+
+func (x OrdenBusqueda) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (OrdenBusqueda) Descriptor() protoreflect.EnumDescriptor {
+	return file_proto_cyberday_proto_enumTypes[2].Descriptor()
+}
+
+func (OrdenBusqueda) Type() protoreflect.EnumType {
+	return &file_proto_cyberday_proto_enumTypes[2]
+}
+
+func (x OrdenBusqueda) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use OrdenBusqueda.Descriptor instead.
+func (OrdenBusqueda) EnumDescriptor() ([]byte, []int) {
+	return file_proto_cyberday_proto_rawDescGZIP(), []int{2}
+}
+
+// ******** Mensajes para la replicación del broker (Raft) **********
+// Las réplicas del broker eligen un líder y le replican un log de entradas. Una
+// entrada OFERTA agrega la oferta al log de suscripciones, indicando si alcanzó
+// quorum W, y una ESTADO trae el registro de entidades y contadores serializado. VACIA la agrega el líder al
+// asumir para confirmar las entradas de términos anteriores.
+type TipoEntradaRaft int32
+
+const (
+	TipoEntradaRaft_VACIA  TipoEntradaRaft = 0
+	TipoEntradaRaft_OFERTA TipoEntradaRaft = 1
+	TipoEntradaRaft_ESTADO TipoEntradaRaft = 2
+)
+
+// Enum value maps for TipoEntradaRaft.
+var (
+	TipoEntradaRaft_name = map[int32]string{
+		0: "VACIA",
+		1: "OFERTA",
+		2: "ESTADO",
+	}
+	TipoEntradaRaft_value = map[string]int32{
+		"VACIA":  0,
+		"OFERTA": 1,
+		"ESTADO": 2,
+	}
+)
+
+func (x TipoEntradaRaft) Enum() *TipoEntradaRaft {
+	p := new(TipoEntradaRaft)
+	*p = x
+	return p
+}
+
+func (x TipoEntradaRaft) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (TipoEntradaRaft) Descriptor() protoreflect.EnumDescriptor {
+	return file_proto_cyberday_proto_enumTypes[3].Descriptor()
+}
+
+func (TipoEntradaRaft) Type() protoreflect.EnumType {
+	return &file_proto_cyberday_proto_enumTypes[3]
+}
+
+func (x TipoEntradaRaft) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use TipoEntradaRaft.Descriptor instead.
+func (TipoEntradaRaft) EnumDescriptor() ([]byte, []int) {
+	return file_proto_cyberday_proto_rawDescGZIP(), []int{3}
+}
+
 type RegistroProductorRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Nombre        string                 `protobuf:"bytes,1,opt,name=nombre,proto3" json:"nombre,omitempty"`
@@ -117,13 +336,15 @@ func (x *RegistroNodoRequest) GetDireccion() string {
 	return ""
 }
 
+// El broker no se conecta a los consumidores: las ofertas les llegan solo por
+// Suscribir.
 type RegistroConsumidorRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	ConsumidorId  string                 `protobuf:"bytes,1,opt,name=consumidor_id,json=consumidorId,proto3" json:"consumidor_id,omitempty"`
 	Categorias    []string               `protobuf:"bytes,2,rep,name=categorias,proto3" json:"categorias,omitempty"`
 	Tiendas       []string               `protobuf:"bytes,3,rep,name=tiendas,proto3" json:"tiendas,omitempty"`
 	PrecioMax     int32                  `protobuf:"varint,4,opt,name=precio_max,json=precioMax,proto3" json:"precio_max,omitempty"`
-	Direccion     string                 `protobuf:"bytes,5,opt,name=direccion,proto3" json:"direccion,omitempty"`
+	Filtro        *FiltroOferta          `protobuf:"bytes,6,opt,name=filtro,proto3" json:"filtro,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -186,13 +407,129 @@ func (x *RegistroConsumidorRequest) GetPrecioMax() int32 {
 	return 0
 }
 
-func (x *RegistroConsumidorRequest) GetDireccion() string {
+func (x *RegistroConsumidorRequest) GetFiltro() *FiltroOferta {
 	if x != nil {
-		return x.Direccion
+		return x.Filtro
+	}
+	return nil
+}
+
+type FiltroOferta struct {
+	state            protoimpl.MessageState `protogen:"open.v1"`
+	Categorias       []string               `protobuf:"bytes,1,rep,name=categorias,proto3" json:"categorias,omitempty"`
+	Tiendas          []string               `protobuf:"bytes,2,rep,name=tiendas,proto3" json:"tiendas,omitempty"`
+	PrecioMin        int32                  `protobuf:"varint,3,opt,name=precio_min,json=precioMin,proto3" json:"precio_min,omitempty"`
+	PrecioMax        int32                  `protobuf:"varint,4,opt,name=precio_max,json=precioMax,proto3" json:"precio_max,omitempty"`
+	DescuentoMin     int32                  `protobuf:"varint,5,opt,name=descuento_min,json=descuentoMin,proto3" json:"descuento_min,omitempty"`
+	StockMin         int32                  `protobuf:"varint,6,opt,name=stock_min,json=stockMin,proto3" json:"stock_min,omitempty"`
+	ProductoContiene string                 `protobuf:"bytes,7,opt,name=producto_contiene,json=productoContiene,proto3" json:"producto_contiene,omitempty"`
+	ProductoRegex    string                 `protobuf:"bytes,8,opt,name=producto_regex,json=productoRegex,proto3" json:"producto_regex,omitempty"`
+	Operador         OperadorFiltro         `protobuf:"varint,9,opt,name=operador,proto3,enum=cyberday.OperadorFiltro" json:"operador,omitempty"`
+	Subfiltros       []*FiltroOferta        `protobuf:"bytes,10,rep,name=subfiltros,proto3" json:"subfiltros,omitempty"`
+	unknownFields    protoimpl.UnknownFields
+	sizeCache        protoimpl.SizeCache
+}
+
+func (x *FiltroOferta) Reset() {
+	*x = FiltroOferta{}
+	mi := &file_proto_cyberday_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *FiltroOferta) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FiltroOferta) ProtoMessage() {}
+
+func (x *FiltroOferta) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_cyberday_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FiltroOferta.ProtoReflect.Descriptor instead.
+func (*FiltroOferta) Descriptor() ([]byte, []int) {
+	return file_proto_cyberday_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *FiltroOferta) GetCategorias() []string {
+	if x != nil {
+		return x.Categorias
+	}
+	return nil
+}
+
+func (x *FiltroOferta) GetTiendas() []string {
+	if x != nil {
+		return x.Tiendas
+	}
+	return nil
+}
+
+func (x *FiltroOferta) GetPrecioMin() int32 {
+	if x != nil {
+		return x.PrecioMin
+	}
+	return 0
+}
+
+func (x *FiltroOferta) GetPrecioMax() int32 {
+	if x != nil {
+		return x.PrecioMax
+	}
+	return 0
+}
+
+func (x *FiltroOferta) GetDescuentoMin() int32 {
+	if x != nil {
+		return x.DescuentoMin
+	}
+	return 0
+}
+
+func (x *FiltroOferta) GetStockMin() int32 {
+	if x != nil {
+		return x.StockMin
+	}
+	return 0
+}
+
+func (x *FiltroOferta) GetProductoContiene() string {
+	if x != nil {
+		return x.ProductoContiene
+	}
+	return ""
+}
+
+func (x *FiltroOferta) GetProductoRegex() string {
+	if x != nil {
+		return x.ProductoRegex
 	}
 	return ""
 }
 
+func (x *FiltroOferta) GetOperador() OperadorFiltro {
+	if x != nil {
+		return x.Operador
+	}
+	return OperadorFiltro_Y
+}
+
+func (x *FiltroOferta) GetSubfiltros() []*FiltroOferta {
+	if x != nil {
+		return x.Subfiltros
+	}
+	return nil
+}
+
 type RegistroResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Exito         bool                   `protobuf:"varint,1,opt,name=exito,proto3" json:"exito,omitempty"`
@@ -202,7 +539,7 @@ type RegistroResponse struct {
 
 func (x *RegistroResponse) Reset() {
 	*x = RegistroResponse{}
-	mi := &file_proto_cyberday_proto_msgTypes[3]
+	mi := &file_proto_cyberday_proto_msgTypes[4]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -214,7 +551,7 @@ func (x *RegistroResponse) String() string {
 func (*RegistroResponse) ProtoMessage() {}
 
 func (x *RegistroResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_cyberday_proto_msgTypes[3]
+	mi := &file_proto_cyberday_proto_msgTypes[4]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -227,7 +564,7 @@ func (x *RegistroResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use RegistroResponse.ProtoReflect.Descriptor instead.
 func (*RegistroResponse) Descriptor() ([]byte, []int) {
-	return file_proto_cyberday_proto_rawDescGZIP(), []int{3}
+	return file_proto_cyberday_proto_rawDescGZIP(), []int{4}
 }
 
 func (x *RegistroResponse) GetExito() bool {
@@ -237,27 +574,1737 @@ func (x *RegistroResponse) GetExito() bool {
 	return false
 }
 
-type InicioRequest struct {
+type SalidaRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
+	EntidadId     string                 `protobuf:"bytes,1,opt,name=entidad_id,json=entidadId,proto3" json:"entidad_id,omitempty"`
+	Tipo          string                 `protobuf:"bytes,2,opt,name=tipo,proto3" json:"tipo,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *InicioRequest) Reset() {
-	*x = InicioRequest{}
-	mi := &file_proto_cyberday_proto_msgTypes[4]
+func (x *SalidaRequest) Reset() {
+	*x = SalidaRequest{}
+	mi := &file_proto_cyberday_proto_msgTypes[5]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *InicioRequest) String() string {
+func (x *SalidaRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*InicioRequest) ProtoMessage() {}
+func (*SalidaRequest) ProtoMessage() {}
 
-func (x *InicioRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_cyberday_proto_msgTypes[4]
+func (x *SalidaRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_cyberday_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SalidaRequest.ProtoReflect.Descriptor instead.
+func (*SalidaRequest) Descriptor() ([]byte, []int) {
+	return file_proto_cyberday_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *SalidaRequest) GetEntidadId() string {
+	if x != nil {
+		return x.EntidadId
+	}
+	return ""
+}
+
+func (x *SalidaRequest) GetTipo() string {
+	if x != nil {
+		return x.Tipo
+	}
+	return ""
+}
+
+// ********** Mensajes para cambiar las preferencias ***********
+// Al actualizar, el broker responde con las ofertas del historial que coinciden
+// con el filtro nuevo y no coincidían con el anterior, es decir, las que el
+// consumidor no recibió. Si no se alcanza quorum de lectura el filtro nuevo
+// se aplica de todas formas y resincronizado es false.
+type ActualizacionPreferenciasRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	ConsumidorId  string                 `protobuf:"bytes,1,opt,name=consumidor_id,json=consumidorId,proto3" json:"consumidor_id,omitempty"`
+	Filtro        *FiltroOferta          `protobuf:"bytes,2,opt,name=filtro,proto3" json:"filtro,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ActualizacionPreferenciasRequest) Reset() {
+	*x = ActualizacionPreferenciasRequest{}
+	mi := &file_proto_cyberday_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ActualizacionPreferenciasRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ActualizacionPreferenciasRequest) ProtoMessage() {}
+
+func (x *ActualizacionPreferenciasRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_cyberday_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ActualizacionPreferenciasRequest.ProtoReflect.Descriptor instead.
+func (*ActualizacionPreferenciasRequest) Descriptor() ([]byte, []int) {
+	return file_proto_cyberday_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *ActualizacionPreferenciasRequest) GetConsumidorId() string {
+	if x != nil {
+		return x.ConsumidorId
+	}
+	return ""
+}
+
+func (x *ActualizacionPreferenciasRequest) GetFiltro() *FiltroOferta {
+	if x != nil {
+		return x.Filtro
+	}
+	return nil
+}
+
+type ActualizacionPreferenciasResponse struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	Exito          bool                   `protobuf:"varint,1,opt,name=exito,proto3" json:"exito,omitempty"`
+	Resincronizado bool                   `protobuf:"varint,2,opt,name=resincronizado,proto3" json:"resincronizado,omitempty"`
+	Ofertas        []*OfertaRequest       `protobuf:"bytes,3,rep,name=ofertas,proto3" json:"ofertas,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *ActualizacionPreferenciasResponse) Reset() {
+	*x = ActualizacionPreferenciasResponse{}
+	mi := &file_proto_cyberday_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ActualizacionPreferenciasResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ActualizacionPreferenciasResponse) ProtoMessage() {}
+
+func (x *ActualizacionPreferenciasResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_cyberday_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ActualizacionPreferenciasResponse.ProtoReflect.Descriptor instead.
+func (*ActualizacionPreferenciasResponse) Descriptor() ([]byte, []int) {
+	return file_proto_cyberday_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *ActualizacionPreferenciasResponse) GetExito() bool {
+	if x != nil {
+		return x.Exito
+	}
+	return false
+}
+
+func (x *ActualizacionPreferenciasResponse) GetResincronizado() bool {
+	if x != nil {
+		return x.Resincronizado
+	}
+	return false
+}
+
+func (x *ActualizacionPreferenciasResponse) GetOfertas() []*OfertaRequest {
+	if x != nil {
+		return x.Ofertas
+	}
+	return nil
+}
+
+type BajaConsumidorRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	ConsumidorId  string                 `protobuf:"bytes,1,opt,name=consumidor_id,json=consumidorId,proto3" json:"consumidor_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BajaConsumidorRequest) Reset() {
+	*x = BajaConsumidorRequest{}
+	mi := &file_proto_cyberday_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BajaConsumidorRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BajaConsumidorRequest) ProtoMessage() {}
+
+func (x *BajaConsumidorRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_cyberday_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BajaConsumidorRequest.ProtoReflect.Descriptor instead.
+func (*BajaConsumidorRequest) Descriptor() ([]byte, []int) {
+	return file_proto_cyberday_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *BajaConsumidorRequest) GetConsumidorId() string {
+	if x != nil {
+		return x.ConsumidorId
+	}
+	return ""
+}
+
+type InicioRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *InicioRequest) Reset() {
+	*x = InicioRequest{}
+	mi := &file_proto_cyberday_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *InicioRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*InicioRequest) ProtoMessage() {}
+
+func (x *InicioRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_cyberday_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use InicioRequest.ProtoReflect.Descriptor instead.
+func (*InicioRequest) Descriptor() ([]byte, []int) {
+	return file_proto_cyberday_proto_rawDescGZIP(), []int{9}
+}
+
+type InicioResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Inicio        bool                   `protobuf:"varint,1,opt,name=inicio,proto3" json:"inicio,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *InicioResponse) Reset() {
+	*x = InicioResponse{}
+	mi := &file_proto_cyberday_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *InicioResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*InicioResponse) ProtoMessage() {}
+
+func (x *InicioResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_cyberday_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use InicioResponse.ProtoReflect.Descriptor instead.
+func (*InicioResponse) Descriptor() ([]byte, []int) {
+	return file_proto_cyberday_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *InicioResponse) GetInicio() bool {
+	if x != nil {
+		return x.Inicio
+	}
+	return false
+}
+
+type OfertaRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	OfertaId      string                 `protobuf:"bytes,1,opt,name=oferta_id,json=ofertaId,proto3" json:"oferta_id,omitempty"`
+	Tienda        string                 `protobuf:"bytes,2,opt,name=tienda,proto3" json:"tienda,omitempty"`
+	Categoria     string                 `protobuf:"bytes,3,opt,name=categoria,proto3" json:"categoria,omitempty"`
+	Producto      string                 `protobuf:"bytes,4,opt,name=producto,proto3" json:"producto,omitempty"`
+	Precio        int32                  `protobuf:"varint,5,opt,name=precio,proto3" json:"precio,omitempty"`
+	Stock         int32                  `protobuf:"varint,6,opt,name=stock,proto3" json:"stock,omitempty"`
+	Fecha         string                 `protobuf:"bytes,7,opt,name=fecha,proto3" json:"fecha,omitempty"`
+	Version       int64                  `protobuf:"varint,8,opt,name=version,proto3" json:"version,omitempty"`
+	Descuento     int32                  `protobuf:"varint,9,opt,name=descuento,proto3" json:"descuento,omitempty"`
+	Estado        EstadoOferta           `protobuf:"varint,10,opt,name=estado,proto3,enum=cyberday.EstadoOferta" json:"estado,omitempty"`
+	Expira        int64                  `protobuf:"varint,11,opt,name=expira,proto3" json:"expira,omitempty"`       // Unix en segundos; 0 si no expira
+	Secuencia     int64                  `protobuf:"varint,12,opt,name=secuencia,proto3" json:"secuencia,omitempty"` // Asignada por el broker a cada versión guardada
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *OfertaRequest) Reset() {
+	*x = OfertaRequest{}
+	mi := &file_proto_cyberday_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *OfertaRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*OfertaRequest) ProtoMessage() {}
+
+func (x *OfertaRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_cyberday_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use OfertaRequest.ProtoReflect.Descriptor instead.
+func (*OfertaRequest) Descriptor() ([]byte, []int) {
+	return file_proto_cyberday_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *OfertaRequest) GetOfertaId() string {
+	if x != nil {
+		return x.OfertaId
+	}
+	return ""
+}
+
+func (x *OfertaRequest) GetTienda() string {
+	if x != nil {
+		return x.Tienda
+	}
+	return ""
+}
+
+func (x *OfertaRequest) GetCategoria() string {
+	if x != nil {
+		return x.Categoria
+	}
+	return ""
+}
+
+func (x *OfertaRequest) GetProducto() string {
+	if x != nil {
+		return x.Producto
+	}
+	return ""
+}
+
+func (x *OfertaRequest) GetPrecio() int32 {
+	if x != nil {
+		return x.Precio
+	}
+	return 0
+}
+
+func (x *OfertaRequest) GetStock() int32 {
+	if x != nil {
+		return x.Stock
+	}
+	return 0
+}
+
+func (x *OfertaRequest) GetFecha() string {
+	if x != nil {
+		return x.Fecha
+	}
+	return ""
+}
+
+func (x *OfertaRequest) GetVersion() int64 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+func (x *OfertaRequest) GetDescuento() int32 {
+	if x != nil {
+		return x.Descuento
+	}
+	return 0
+}
+
+func (x *OfertaRequest) GetEstado() EstadoOferta {
+	if x != nil {
+		return x.Estado
+	}
+	return EstadoOferta_VIGENTE
+}
+
+func (x *OfertaRequest) GetExpira() int64 {
+	if x != nil {
+		return x.Expira
+	}
+	return 0
+}
+
+func (x *OfertaRequest) GetSecuencia() int64 {
+	if x != nil {
+		return x.Secuencia
+	}
+	return 0
+}
+
+type OfertaResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Exito         bool                   `protobuf:"varint,1,opt,name=exito,proto3" json:"exito,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *OfertaResponse) Reset() {
+	*x = OfertaResponse{}
+	mi := &file_proto_cyberday_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *OfertaResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*OfertaResponse) ProtoMessage() {}
+
+func (x *OfertaResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_cyberday_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use OfertaResponse.ProtoReflect.Descriptor instead.
+func (*OfertaResponse) Descriptor() ([]byte, []int) {
+	return file_proto_cyberday_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *OfertaResponse) GetExito() bool {
+	if x != nil {
+		return x.Exito
+	}
+	return false
+}
+
+// ********* Mensajes para el ciclo de vida de una oferta **********
+// Cada cambio se guarda como una versión nueva de la oferta, por el mismo
+// camino de quorum que una oferta recibida. La tienda que publicó la oferta
+// puede cambiar su stock en cualquier sentido; un consumidor registrado solo
+// puede descontarlo al comprar.
+type ActualizacionStockRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	OfertaId      string                 `protobuf:"bytes,1,opt,name=oferta_id,json=ofertaId,proto3" json:"oferta_id,omitempty"`
+	Delta         int32                  `protobuf:"varint,2,opt,name=delta,proto3" json:"delta,omitempty"` // negativo en una compra
+	ConsumidorId  string                 `protobuf:"bytes,3,opt,name=consumidor_id,json=consumidorId,proto3" json:"consumidor_id,omitempty"`
+	Tienda        string                 `protobuf:"bytes,4,opt,name=tienda,proto3" json:"tienda,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ActualizacionStockRequest) Reset() {
+	*x = ActualizacionStockRequest{}
+	mi := &file_proto_cyberday_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ActualizacionStockRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ActualizacionStockRequest) ProtoMessage() {}
+
+func (x *ActualizacionStockRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_cyberday_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ActualizacionStockRequest.ProtoReflect.Descriptor instead.
+func (*ActualizacionStockRequest) Descriptor() ([]byte, []int) {
+	return file_proto_cyberday_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *ActualizacionStockRequest) GetOfertaId() string {
+	if x != nil {
+		return x.OfertaId
+	}
+	return ""
+}
+
+func (x *ActualizacionStockRequest) GetDelta() int32 {
+	if x != nil {
+		return x.Delta
+	}
+	return 0
+}
+
+func (x *ActualizacionStockRequest) GetConsumidorId() string {
+	if x != nil {
+		return x.ConsumidorId
+	}
+	return ""
+}
+
+func (x *ActualizacionStockRequest) GetTienda() string {
+	if x != nil {
+		return x.Tienda
+	}
+	return ""
+}
+
+type RetiroOfertaRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	OfertaId      string                 `protobuf:"bytes,1,opt,name=oferta_id,json=ofertaId,proto3" json:"oferta_id,omitempty"`
+	Tienda        string                 `protobuf:"bytes,2,opt,name=tienda,proto3" json:"tienda,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RetiroOfertaRequest) Reset() {
+	*x = RetiroOfertaRequest{}
+	mi := &file_proto_cyberday_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RetiroOfertaRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RetiroOfertaRequest) ProtoMessage() {}
+
+func (x *RetiroOfertaRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_cyberday_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RetiroOfertaRequest.ProtoReflect.Descriptor instead.
+func (*RetiroOfertaRequest) Descriptor() ([]byte, []int) {
+	return file_proto_cyberday_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *RetiroOfertaRequest) GetOfertaId() string {
+	if x != nil {
+		return x.OfertaId
+	}
+	return ""
+}
+
+func (x *RetiroOfertaRequest) GetTienda() string {
+	if x != nil {
+		return x.Tienda
+	}
+	return ""
+}
+
+type CambioOfertaResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Exito         bool                   `protobuf:"varint,1,opt,name=exito,proto3" json:"exito,omitempty"`
+	Oferta        *OfertaRequest         `protobuf:"bytes,2,opt,name=oferta,proto3" json:"oferta,omitempty"`
+	Motivo        string                 `protobuf:"bytes,3,opt,name=motivo,proto3" json:"motivo,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CambioOfertaResponse) Reset() {
+	*x = CambioOfertaResponse{}
+	mi := &file_proto_cyberday_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CambioOfertaResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CambioOfertaResponse) ProtoMessage() {}
+
+func (x *CambioOfertaResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_cyberday_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CambioOfertaResponse.ProtoReflect.Descriptor instead.
+func (*CambioOfertaResponse) Descriptor() ([]byte, []int) {
+	return file_proto_cyberday_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *CambioOfertaResponse) GetExito() bool {
+	if x != nil {
+		return x.Exito
+	}
+	return false
+}
+
+func (x *CambioOfertaResponse) GetOferta() *OfertaRequest {
+	if x != nil {
+		return x.Oferta
+	}
+	return nil
+}
+
+func (x *CambioOfertaResponse) GetMotivo() string {
+	if x != nil {
+		return x.Motivo
+	}
+	return ""
+}
+
+type LecturaOfertaRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	OfertaId      string                 `protobuf:"bytes,1,opt,name=oferta_id,json=ofertaId,proto3" json:"oferta_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *LecturaOfertaRequest) Reset() {
+	*x = LecturaOfertaRequest{}
+	mi := &file_proto_cyberday_proto_msgTypes[16]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *LecturaOfertaRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LecturaOfertaRequest) ProtoMessage() {}
+
+func (x *LecturaOfertaRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_cyberday_proto_msgTypes[16]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LecturaOfertaRequest.ProtoReflect.Descriptor instead.
+func (*LecturaOfertaRequest) Descriptor() ([]byte, []int) {
+	return file_proto_cyberday_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *LecturaOfertaRequest) GetOfertaId() string {
+	if x != nil {
+		return x.OfertaId
+	}
+	return ""
+}
+
+// ********* Mensajes para sincronizacion de nodos **********
+// La entidad envía la mayor secuencia que tiene guardada y recibe las
+// versiones con secuencia posterior.
+type SincronizacionRequest struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	EntidadId      string                 `protobuf:"bytes,1,opt,name=entidad_id,json=entidadId,proto3" json:"entidad_id,omitempty"`
+	Tipo           string                 `protobuf:"bytes,2,opt,name=tipo,proto3" json:"tipo,omitempty"`
+	DesdeSecuencia int64                  `protobuf:"varint,4,opt,name=desde_secuencia,json=desdeSecuencia,proto3" json:"desde_secuencia,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *SincronizacionRequest) Reset() {
+	*x = SincronizacionRequest{}
+	mi := &file_proto_cyberday_proto_msgTypes[17]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SincronizacionRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SincronizacionRequest) ProtoMessage() {}
+
+func (x *SincronizacionRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_cyberday_proto_msgTypes[17]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SincronizacionRequest.ProtoReflect.Descriptor instead.
+func (*SincronizacionRequest) Descriptor() ([]byte, []int) {
+	return file_proto_cyberday_proto_rawDescGZIP(), []int{17}
+}
+
+func (x *SincronizacionRequest) GetEntidadId() string {
+	if x != nil {
+		return x.EntidadId
+	}
+	return ""
+}
+
+func (x *SincronizacionRequest) GetTipo() string {
+	if x != nil {
+		return x.Tipo
+	}
+	return ""
+}
+
+func (x *SincronizacionRequest) GetDesdeSecuencia() int64 {
+	if x != nil {
+		return x.DesdeSecuencia
+	}
+	return 0
+}
+
+// Los hints pendientes del nodo van aparte: siguen en la cola del broker
+// hasta que el nodo confirma que los guardó.
+type SincronizacionResponse struct {
+	state            protoimpl.MessageState `protogen:"open.v1"`
+	OfertasFaltantes []*OfertaRequest       `protobuf:"bytes,1,rep,name=ofertas_faltantes,json=ofertasFaltantes,proto3" json:"ofertas_faltantes,omitempty"`
+	Exito            bool                   `protobuf:"varint,2,opt,name=exito,proto3" json:"exito,omitempty"`
+	Hints            []*OfertaRequest       `protobuf:"bytes,3,rep,name=hints,proto3" json:"hints,omitempty"`
+	unknownFields    protoimpl.UnknownFields
+	sizeCache        protoimpl.SizeCache
+}
+
+func (x *SincronizacionResponse) Reset() {
+	*x = SincronizacionResponse{}
+	mi := &file_proto_cyberday_proto_msgTypes[18]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SincronizacionResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SincronizacionResponse) ProtoMessage() {}
+
+func (x *SincronizacionResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_cyberday_proto_msgTypes[18]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SincronizacionResponse.ProtoReflect.Descriptor instead.
+func (*SincronizacionResponse) Descriptor() ([]byte, []int) {
+	return file_proto_cyberday_proto_rawDescGZIP(), []int{18}
+}
+
+func (x *SincronizacionResponse) GetOfertasFaltantes() []*OfertaRequest {
+	if x != nil {
+		return x.OfertasFaltantes
+	}
+	return nil
+}
+
+func (x *SincronizacionResponse) GetExito() bool {
+	if x != nil {
+		return x.Exito
+	}
+	return false
+}
+
+func (x *SincronizacionResponse) GetHints() []*OfertaRequest {
+	if x != nil {
+		return x.Hints
+	}
+	return nil
+}
+
+type HintEntregado struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	OfertaId      string                 `protobuf:"bytes,1,opt,name=oferta_id,json=ofertaId,proto3" json:"oferta_id,omitempty"`
+	Version       int64                  `protobuf:"varint,2,opt,name=version,proto3" json:"version,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *HintEntregado) Reset() {
+	*x = HintEntregado{}
+	mi := &file_proto_cyberday_proto_msgTypes[19]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *HintEntregado) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*HintEntregado) ProtoMessage() {}
+
+func (x *HintEntregado) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_cyberday_proto_msgTypes[19]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use HintEntregado.ProtoReflect.Descriptor instead.
+func (*HintEntregado) Descriptor() ([]byte, []int) {
+	return file_proto_cyberday_proto_rawDescGZIP(), []int{19}
+}
+
+func (x *HintEntregado) GetOfertaId() string {
+	if x != nil {
+		return x.OfertaId
+	}
+	return ""
+}
+
+func (x *HintEntregado) GetVersion() int64 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+type ConfirmacionHintsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	NodoId        string                 `protobuf:"bytes,1,opt,name=nodo_id,json=nodoId,proto3" json:"nodo_id,omitempty"`
+	Hints         []*HintEntregado       `protobuf:"bytes,2,rep,name=hints,proto3" json:"hints,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ConfirmacionHintsRequest) Reset() {
+	*x = ConfirmacionHintsRequest{}
+	mi := &file_proto_cyberday_proto_msgTypes[20]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ConfirmacionHintsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ConfirmacionHintsRequest) ProtoMessage() {}
+
+func (x *ConfirmacionHintsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_cyberday_proto_msgTypes[20]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ConfirmacionHintsRequest.ProtoReflect.Descriptor instead.
+func (*ConfirmacionHintsRequest) Descriptor() ([]byte, []int) {
+	return file_proto_cyberday_proto_rawDescGZIP(), []int{20}
+}
+
+func (x *ConfirmacionHintsRequest) GetNodoId() string {
+	if x != nil {
+		return x.NodoId
+	}
+	return ""
+}
+
+func (x *ConfirmacionHintsRequest) GetHints() []*HintEntregado {
+	if x != nil {
+		return x.Hints
+	}
+	return nil
+}
+
+// ******** Mensajes para lectura **********
+// Los filtros vacíos no restringen. Las ofertas se entregan ordenadas por ID en
+// páginas: si la respuesta trae siguiente_token, se pide la página siguiente
+// repitiendo la lectura con ese token.
+type LecturaRequest struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	Tiendas        []string               `protobuf:"bytes,1,rep,name=tiendas,proto3" json:"tiendas,omitempty"`
+	Categorias     []string               `protobuf:"bytes,2,rep,name=categorias,proto3" json:"categorias,omitempty"`
+	PrecioMin      int32                  `protobuf:"varint,3,opt,name=precio_min,json=precioMin,proto3" json:"precio_min,omitempty"`
+	PrecioMax      int32                  `protobuf:"varint,4,opt,name=precio_max,json=precioMax,proto3" json:"precio_max,omitempty"`
+	DesdeFecha     string                 `protobuf:"bytes,5,opt,name=desde_fecha,json=desdeFecha,proto3" json:"desde_fecha,omitempty"` // "AAAA-MM-DD hh:mm:ss", inclusive
+	HastaFecha     string                 `protobuf:"bytes,6,opt,name=hasta_fecha,json=hastaFecha,proto3" json:"hasta_fecha,omitempty"` // exclusiva
+	PrefijoId      string                 `protobuf:"bytes,7,opt,name=prefijo_id,json=prefijoId,proto3" json:"prefijo_id,omitempty"`
+	TamanoPagina   int32                  `protobuf:"varint,8,opt,name=tamano_pagina,json=tamanoPagina,proto3" json:"tamano_pagina,omitempty"` // 0 usa el tamaño por defecto del nodo
+	Token          string                 `protobuf:"bytes,9,opt,name=token,proto3" json:"token,omitempty"`
+	DesdeSecuencia int64                  `protobuf:"varint,10,opt,name=desde_secuencia,json=desdeSecuencia,proto3" json:"desde_secuencia,omitempty"` // solo versiones con secuencia mayor
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *LecturaRequest) Reset() {
+	*x = LecturaRequest{}
+	mi := &file_proto_cyberday_proto_msgTypes[21]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *LecturaRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LecturaRequest) ProtoMessage() {}
+
+func (x *LecturaRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_cyberday_proto_msgTypes[21]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LecturaRequest.ProtoReflect.Descriptor instead.
+func (*LecturaRequest) Descriptor() ([]byte, []int) {
+	return file_proto_cyberday_proto_rawDescGZIP(), []int{21}
+}
+
+func (x *LecturaRequest) GetTiendas() []string {
+	if x != nil {
+		return x.Tiendas
+	}
+	return nil
+}
+
+func (x *LecturaRequest) GetCategorias() []string {
+	if x != nil {
+		return x.Categorias
+	}
+	return nil
+}
+
+func (x *LecturaRequest) GetPrecioMin() int32 {
+	if x != nil {
+		return x.PrecioMin
+	}
+	return 0
+}
+
+func (x *LecturaRequest) GetPrecioMax() int32 {
+	if x != nil {
+		return x.PrecioMax
+	}
+	return 0
+}
+
+func (x *LecturaRequest) GetDesdeFecha() string {
+	if x != nil {
+		return x.DesdeFecha
+	}
+	return ""
+}
+
+func (x *LecturaRequest) GetHastaFecha() string {
+	if x != nil {
+		return x.HastaFecha
+	}
+	return ""
+}
+
+func (x *LecturaRequest) GetPrefijoId() string {
+	if x != nil {
+		return x.PrefijoId
+	}
+	return ""
+}
+
+func (x *LecturaRequest) GetTamanoPagina() int32 {
+	if x != nil {
+		return x.TamanoPagina
+	}
+	return 0
+}
+
+func (x *LecturaRequest) GetToken() string {
+	if x != nil {
+		return x.Token
+	}
+	return ""
+}
+
+func (x *LecturaRequest) GetDesdeSecuencia() int64 {
+	if x != nil {
+		return x.DesdeSecuencia
+	}
+	return 0
+}
+
+type LecturaResponse struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	Ofertas         []*OfertaRequest       `protobuf:"bytes,1,rep,name=ofertas,proto3" json:"ofertas,omitempty"`
+	Exito           bool                   `protobuf:"varint,2,opt,name=exito,proto3" json:"exito,omitempty"`
+	SiguienteToken  string                 `protobuf:"bytes,3,opt,name=siguiente_token,json=siguienteToken,proto3" json:"siguiente_token,omitempty"`
+	UltimaSecuencia int64                  `protobuf:"varint,4,opt,name=ultima_secuencia,json=ultimaSecuencia,proto3" json:"ultima_secuencia,omitempty"` // mayor secuencia que guarda el nodo
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *LecturaResponse) Reset() {
+	*x = LecturaResponse{}
+	mi := &file_proto_cyberday_proto_msgTypes[22]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *LecturaResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LecturaResponse) ProtoMessage() {}
+
+func (x *LecturaResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_cyberday_proto_msgTypes[22]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LecturaResponse.ProtoReflect.Descriptor instead.
+func (*LecturaResponse) Descriptor() ([]byte, []int) {
+	return file_proto_cyberday_proto_rawDescGZIP(), []int{22}
+}
+
+func (x *LecturaResponse) GetOfertas() []*OfertaRequest {
+	if x != nil {
+		return x.Ofertas
+	}
+	return nil
+}
+
+func (x *LecturaResponse) GetExito() bool {
+	if x != nil {
+		return x.Exito
+	}
+	return false
+}
+
+func (x *LecturaResponse) GetSiguienteToken() string {
+	if x != nil {
+		return x.SiguienteToken
+	}
+	return ""
+}
+
+func (x *LecturaResponse) GetUltimaSecuencia() int64 {
+	if x != nil {
+		return x.UltimaSecuencia
+	}
+	return 0
+}
+
+type BusquedaRequest struct {
+	state             protoimpl.MessageState `protogen:"open.v1"`
+	Filtro            *FiltroOferta          `protobuf:"bytes,1,opt,name=filtro,proto3" json:"filtro,omitempty"`
+	Orden             OrdenBusqueda          `protobuf:"varint,2,opt,name=orden,proto3,enum=cyberday.OrdenBusqueda" json:"orden,omitempty"`
+	Limite            int32                  `protobuf:"varint,3,opt,name=limite,proto3" json:"limite,omitempty"` // 0 usa el límite por defecto del broker
+	IncluirNoVigentes bool                   `protobuf:"varint,4,opt,name=incluir_no_vigentes,json=incluirNoVigentes,proto3" json:"incluir_no_vigentes,omitempty"`
+	unknownFields     protoimpl.UnknownFields
+	sizeCache         protoimpl.SizeCache
+}
+
+func (x *BusquedaRequest) Reset() {
+	*x = BusquedaRequest{}
+	mi := &file_proto_cyberday_proto_msgTypes[23]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BusquedaRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BusquedaRequest) ProtoMessage() {}
+
+func (x *BusquedaRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_cyberday_proto_msgTypes[23]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BusquedaRequest.ProtoReflect.Descriptor instead.
+func (*BusquedaRequest) Descriptor() ([]byte, []int) {
+	return file_proto_cyberday_proto_rawDescGZIP(), []int{23}
+}
+
+func (x *BusquedaRequest) GetFiltro() *FiltroOferta {
+	if x != nil {
+		return x.Filtro
+	}
+	return nil
+}
+
+func (x *BusquedaRequest) GetOrden() OrdenBusqueda {
+	if x != nil {
+		return x.Orden
+	}
+	return OrdenBusqueda_POR_ID
+}
+
+func (x *BusquedaRequest) GetLimite() int32 {
+	if x != nil {
+		return x.Limite
+	}
+	return 0
+}
+
+func (x *BusquedaRequest) GetIncluirNoVigentes() bool {
+	if x != nil {
+		return x.IncluirNoVigentes
+	}
+	return false
+}
+
+// Con parcial, el broker dejó de leer antes de recorrer todas las ofertas:
+// total y el resultado consideran solo las ofertas con ID hasta donde leyó.
+type BusquedaResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Ofertas       []*OfertaRequest       `protobuf:"bytes,1,rep,name=ofertas,proto3" json:"ofertas,omitempty"`
+	Exito         bool                   `protobuf:"varint,2,opt,name=exito,proto3" json:"exito,omitempty"`
+	Total         int32                  `protobuf:"varint,3,opt,name=total,proto3" json:"total,omitempty"`
+	Parcial       bool                   `protobuf:"varint,4,opt,name=parcial,proto3" json:"parcial,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BusquedaResponse) Reset() {
+	*x = BusquedaResponse{}
+	mi := &file_proto_cyberday_proto_msgTypes[24]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BusquedaResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BusquedaResponse) ProtoMessage() {}
+
+func (x *BusquedaResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_cyberday_proto_msgTypes[24]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BusquedaResponse.ProtoReflect.Descriptor instead.
+func (*BusquedaResponse) Descriptor() ([]byte, []int) {
+	return file_proto_cyberday_proto_rawDescGZIP(), []int{24}
+}
+
+func (x *BusquedaResponse) GetOfertas() []*OfertaRequest {
+	if x != nil {
+		return x.Ofertas
+	}
+	return nil
+}
+
+func (x *BusquedaResponse) GetExito() bool {
+	if x != nil {
+		return x.Exito
+	}
+	return false
+}
+
+func (x *BusquedaResponse) GetTotal() int32 {
+	if x != nil {
+		return x.Total
+	}
+	return 0
+}
+
+func (x *BusquedaResponse) GetParcial() bool {
+	if x != nil {
+		return x.Parcial
+	}
+	return false
+}
+
+// ******** Mensajes para anti-entropía **********
+// Las posiciones usan la numeración de un heap: 1 es la raíz y los hijos de p
+// son 2p y 2p+1. Las hojas (buckets de IDs) son las posiciones hojas..2*hojas-1.
+// Un tramo (inicio, fin] del anillo de hashing consistente. Si inicio >= fin el
+// tramo da la vuelta; si son iguales es el anillo completo.
+type RangoAnillo struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Inicio        uint64                 `protobuf:"varint,1,opt,name=inicio,proto3" json:"inicio,omitempty"`
+	Fin           uint64                 `protobuf:"varint,2,opt,name=fin,proto3" json:"fin,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RangoAnillo) Reset() {
+	*x = RangoAnillo{}
+	mi := &file_proto_cyberday_proto_msgTypes[25]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RangoAnillo) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RangoAnillo) ProtoMessage() {}
+
+func (x *RangoAnillo) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_cyberday_proto_msgTypes[25]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RangoAnillo.ProtoReflect.Descriptor instead.
+func (*RangoAnillo) Descriptor() ([]byte, []int) {
+	return file_proto_cyberday_proto_rawDescGZIP(), []int{25}
+}
+
+func (x *RangoAnillo) GetInicio() uint64 {
+	if x != nil {
+		return x.Inicio
+	}
+	return 0
+}
+
+func (x *RangoAnillo) GetFin() uint64 {
+	if x != nil {
+		return x.Fin
+	}
+	return 0
+}
+
+// Con rangos, el árbol se arma solo con las ofertas de esos tramos del anillo:
+// las que ambos nodos del par deben replicar.
+type HashesMerkleRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Posiciones    []int32                `protobuf:"varint,1,rep,packed,name=posiciones,proto3" json:"posiciones,omitempty"`
+	Rangos        []*RangoAnillo         `protobuf:"bytes,2,rep,name=rangos,proto3" json:"rangos,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *HashesMerkleRequest) Reset() {
+	*x = HashesMerkleRequest{}
+	mi := &file_proto_cyberday_proto_msgTypes[26]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *HashesMerkleRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*HashesMerkleRequest) ProtoMessage() {}
+
+func (x *HashesMerkleRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_cyberday_proto_msgTypes[26]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use HashesMerkleRequest.ProtoReflect.Descriptor instead.
+func (*HashesMerkleRequest) Descriptor() ([]byte, []int) {
+	return file_proto_cyberday_proto_rawDescGZIP(), []int{26}
+}
+
+func (x *HashesMerkleRequest) GetPosiciones() []int32 {
+	if x != nil {
+		return x.Posiciones
+	}
+	return nil
+}
+
+func (x *HashesMerkleRequest) GetRangos() []*RangoAnillo {
+	if x != nil {
+		return x.Rangos
+	}
+	return nil
+}
+
+type HashesMerkleResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Hashes        [][]byte               `protobuf:"bytes,1,rep,name=hashes,proto3" json:"hashes,omitempty"`
+	Hojas         int32                  `protobuf:"varint,2,opt,name=hojas,proto3" json:"hojas,omitempty"`
+	Exito         bool                   `protobuf:"varint,3,opt,name=exito,proto3" json:"exito,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *HashesMerkleResponse) Reset() {
+	*x = HashesMerkleResponse{}
+	mi := &file_proto_cyberday_proto_msgTypes[27]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *HashesMerkleResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*HashesMerkleResponse) ProtoMessage() {}
+
+func (x *HashesMerkleResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_cyberday_proto_msgTypes[27]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use HashesMerkleResponse.ProtoReflect.Descriptor instead.
+func (*HashesMerkleResponse) Descriptor() ([]byte, []int) {
+	return file_proto_cyberday_proto_rawDescGZIP(), []int{27}
+}
+
+func (x *HashesMerkleResponse) GetHashes() [][]byte {
+	if x != nil {
+		return x.Hashes
+	}
+	return nil
+}
+
+func (x *HashesMerkleResponse) GetHojas() int32 {
+	if x != nil {
+		return x.Hojas
+	}
+	return 0
+}
+
+func (x *HashesMerkleResponse) GetExito() bool {
+	if x != nil {
+		return x.Exito
+	}
+	return false
+}
+
+type LecturaBucketsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Buckets       []int32                `protobuf:"varint,1,rep,packed,name=buckets,proto3" json:"buckets,omitempty"`
+	Rangos        []*RangoAnillo         `protobuf:"bytes,2,rep,name=rangos,proto3" json:"rangos,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *LecturaBucketsRequest) Reset() {
+	*x = LecturaBucketsRequest{}
+	mi := &file_proto_cyberday_proto_msgTypes[28]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *LecturaBucketsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LecturaBucketsRequest) ProtoMessage() {}
+
+func (x *LecturaBucketsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_cyberday_proto_msgTypes[28]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LecturaBucketsRequest.ProtoReflect.Descriptor instead.
+func (*LecturaBucketsRequest) Descriptor() ([]byte, []int) {
+	return file_proto_cyberday_proto_rawDescGZIP(), []int{28}
+}
+
+func (x *LecturaBucketsRequest) GetBuckets() []int32 {
+	if x != nil {
+		return x.Buckets
+	}
+	return nil
+}
+
+func (x *LecturaBucketsRequest) GetRangos() []*RangoAnillo {
+	if x != nil {
+		return x.Rangos
+	}
+	return nil
+}
+
+// ******** Mensajes para suscripción de consumidores **********
+type SuscripcionRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	ConsumidorId  string                 `protobuf:"bytes,1,opt,name=consumidor_id,json=consumidorId,proto3" json:"consumidor_id,omitempty"`
+	Categorias    []string               `protobuf:"bytes,2,rep,name=categorias,proto3" json:"categorias,omitempty"`
+	Tiendas       []string               `protobuf:"bytes,3,rep,name=tiendas,proto3" json:"tiendas,omitempty"`
+	PrecioMax     int32                  `protobuf:"varint,4,opt,name=precio_max,json=precioMax,proto3" json:"precio_max,omitempty"`
+	DesdeOffset   int64                  `protobuf:"varint,5,opt,name=desde_offset,json=desdeOffset,proto3" json:"desde_offset,omitempty"`
+	Filtro        *FiltroOferta          `protobuf:"bytes,6,opt,name=filtro,proto3" json:"filtro,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SuscripcionRequest) Reset() {
+	*x = SuscripcionRequest{}
+	mi := &file_proto_cyberday_proto_msgTypes[29]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SuscripcionRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SuscripcionRequest) ProtoMessage() {}
+
+func (x *SuscripcionRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_cyberday_proto_msgTypes[29]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SuscripcionRequest.ProtoReflect.Descriptor instead.
+func (*SuscripcionRequest) Descriptor() ([]byte, []int) {
+	return file_proto_cyberday_proto_rawDescGZIP(), []int{29}
+}
+
+func (x *SuscripcionRequest) GetConsumidorId() string {
+	if x != nil {
+		return x.ConsumidorId
+	}
+	return ""
+}
+
+func (x *SuscripcionRequest) GetCategorias() []string {
+	if x != nil {
+		return x.Categorias
+	}
+	return nil
+}
+
+func (x *SuscripcionRequest) GetTiendas() []string {
+	if x != nil {
+		return x.Tiendas
+	}
+	return nil
+}
+
+func (x *SuscripcionRequest) GetPrecioMax() int32 {
+	if x != nil {
+		return x.PrecioMax
+	}
+	return 0
+}
+
+func (x *SuscripcionRequest) GetDesdeOffset() int64 {
+	if x != nil {
+		return x.DesdeOffset
+	}
+	return 0
+}
+
+func (x *SuscripcionRequest) GetFiltro() *FiltroOferta {
+	if x != nil {
+		return x.Filtro
+	}
+	return nil
+}
+
+// Con latido=true el mensaje no trae oferta: solo mantiene vivo el stream
+type NotificacionOferta struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Offset        int64                  `protobuf:"varint,1,opt,name=offset,proto3" json:"offset,omitempty"`
+	Oferta        *OfertaRequest         `protobuf:"bytes,2,opt,name=oferta,proto3" json:"oferta,omitempty"`
+	Latido        bool                   `protobuf:"varint,3,opt,name=latido,proto3" json:"latido,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *NotificacionOferta) Reset() {
+	*x = NotificacionOferta{}
+	mi := &file_proto_cyberday_proto_msgTypes[30]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *NotificacionOferta) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*NotificacionOferta) ProtoMessage() {}
+
+func (x *NotificacionOferta) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_cyberday_proto_msgTypes[30]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use NotificacionOferta.ProtoReflect.Descriptor instead.
+func (*NotificacionOferta) Descriptor() ([]byte, []int) {
+	return file_proto_cyberday_proto_rawDescGZIP(), []int{30}
+}
+
+func (x *NotificacionOferta) GetOffset() int64 {
+	if x != nil {
+		return x.Offset
+	}
+	return 0
+}
+
+func (x *NotificacionOferta) GetOferta() *OfertaRequest {
+	if x != nil {
+		return x.Oferta
+	}
+	return nil
+}
+
+func (x *NotificacionOferta) GetLatido() bool {
+	if x != nil {
+		return x.Latido
+	}
+	return false
+}
+
+type EntradaRaft struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Termino       int64                  `protobuf:"varint,1,opt,name=termino,proto3" json:"termino,omitempty"`
+	Indice        int64                  `protobuf:"varint,2,opt,name=indice,proto3" json:"indice,omitempty"`
+	Tipo          TipoEntradaRaft        `protobuf:"varint,3,opt,name=tipo,proto3,enum=cyberday.TipoEntradaRaft" json:"tipo,omitempty"`
+	Oferta        *OfertaRequest         `protobuf:"bytes,4,opt,name=oferta,proto3" json:"oferta,omitempty"`
+	Estado        []byte                 `protobuf:"bytes,5,opt,name=estado,proto3" json:"estado,omitempty"`
+	Confirmada    bool                   `protobuf:"varint,6,opt,name=confirmada,proto3" json:"confirmada,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *EntradaRaft) Reset() {
+	*x = EntradaRaft{}
+	mi := &file_proto_cyberday_proto_msgTypes[31]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *EntradaRaft) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*EntradaRaft) ProtoMessage() {}
+
+func (x *EntradaRaft) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_cyberday_proto_msgTypes[31]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use EntradaRaft.ProtoReflect.Descriptor instead.
+func (*EntradaRaft) Descriptor() ([]byte, []int) {
+	return file_proto_cyberday_proto_rawDescGZIP(), []int{31}
+}
+
+func (x *EntradaRaft) GetTermino() int64 {
+	if x != nil {
+		return x.Termino
+	}
+	return 0
+}
+
+func (x *EntradaRaft) GetIndice() int64 {
+	if x != nil {
+		return x.Indice
+	}
+	return 0
+}
+
+func (x *EntradaRaft) GetTipo() TipoEntradaRaft {
+	if x != nil {
+		return x.Tipo
+	}
+	return TipoEntradaRaft_VACIA
+}
+
+func (x *EntradaRaft) GetOferta() *OfertaRequest {
+	if x != nil {
+		return x.Oferta
+	}
+	return nil
+}
+
+func (x *EntradaRaft) GetEstado() []byte {
+	if x != nil {
+		return x.Estado
+	}
+	return nil
+}
+
+func (x *EntradaRaft) GetConfirmada() bool {
+	if x != nil {
+		return x.Confirmada
+	}
+	return false
+}
+
+type SolicitudVotoRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Termino       int64                  `protobuf:"varint,1,opt,name=termino,proto3" json:"termino,omitempty"`
+	Candidato     string                 `protobuf:"bytes,2,opt,name=candidato,proto3" json:"candidato,omitempty"`
+	UltimoIndice  int64                  `protobuf:"varint,3,opt,name=ultimo_indice,json=ultimoIndice,proto3" json:"ultimo_indice,omitempty"`
+	UltimoTermino int64                  `protobuf:"varint,4,opt,name=ultimo_termino,json=ultimoTermino,proto3" json:"ultimo_termino,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SolicitudVotoRequest) Reset() {
+	*x = SolicitudVotoRequest{}
+	mi := &file_proto_cyberday_proto_msgTypes[32]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SolicitudVotoRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SolicitudVotoRequest) ProtoMessage() {}
+
+func (x *SolicitudVotoRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_cyberday_proto_msgTypes[32]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -268,33 +2315,62 @@ func (x *InicioRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use InicioRequest.ProtoReflect.Descriptor instead.
-func (*InicioRequest) Descriptor() ([]byte, []int) {
-	return file_proto_cyberday_proto_rawDescGZIP(), []int{4}
+// Deprecated: Use SolicitudVotoRequest.ProtoReflect.Descriptor instead.
+func (*SolicitudVotoRequest) Descriptor() ([]byte, []int) {
+	return file_proto_cyberday_proto_rawDescGZIP(), []int{32}
 }
 
-type InicioResponse struct {
+func (x *SolicitudVotoRequest) GetTermino() int64 {
+	if x != nil {
+		return x.Termino
+	}
+	return 0
+}
+
+func (x *SolicitudVotoRequest) GetCandidato() string {
+	if x != nil {
+		return x.Candidato
+	}
+	return ""
+}
+
+func (x *SolicitudVotoRequest) GetUltimoIndice() int64 {
+	if x != nil {
+		return x.UltimoIndice
+	}
+	return 0
+}
+
+func (x *SolicitudVotoRequest) GetUltimoTermino() int64 {
+	if x != nil {
+		return x.UltimoTermino
+	}
+	return 0
+}
+
+type SolicitudVotoResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Inicio        bool                   `protobuf:"varint,1,opt,name=inicio,proto3" json:"inicio,omitempty"`
+	Termino       int64                  `protobuf:"varint,1,opt,name=termino,proto3" json:"termino,omitempty"`
+	VotoConcedido bool                   `protobuf:"varint,2,opt,name=voto_concedido,json=votoConcedido,proto3" json:"voto_concedido,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *InicioResponse) Reset() {
-	*x = InicioResponse{}
-	mi := &file_proto_cyberday_proto_msgTypes[5]
+func (x *SolicitudVotoResponse) Reset() {
+	*x = SolicitudVotoResponse{}
+	mi := &file_proto_cyberday_proto_msgTypes[33]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *InicioResponse) String() string {
+func (x *SolicitudVotoResponse) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*InicioResponse) ProtoMessage() {}
+func (*SolicitudVotoResponse) ProtoMessage() {}
 
-func (x *InicioResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_cyberday_proto_msgTypes[5]
+func (x *SolicitudVotoResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_cyberday_proto_msgTypes[33]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -305,46 +2381,53 @@ func (x *InicioResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use InicioResponse.ProtoReflect.Descriptor instead.
-func (*InicioResponse) Descriptor() ([]byte, []int) {
-	return file_proto_cyberday_proto_rawDescGZIP(), []int{5}
+// Deprecated: Use SolicitudVotoResponse.ProtoReflect.Descriptor instead.
+func (*SolicitudVotoResponse) Descriptor() ([]byte, []int) {
+	return file_proto_cyberday_proto_rawDescGZIP(), []int{33}
 }
 
-func (x *InicioResponse) GetInicio() bool {
+func (x *SolicitudVotoResponse) GetTermino() int64 {
 	if x != nil {
-		return x.Inicio
+		return x.Termino
+	}
+	return 0
+}
+
+func (x *SolicitudVotoResponse) GetVotoConcedido() bool {
+	if x != nil {
+		return x.VotoConcedido
 	}
 	return false
 }
 
-type OfertaRequest struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	OfertaId      string                 `protobuf:"bytes,1,opt,name=oferta_id,json=ofertaId,proto3" json:"oferta_id,omitempty"`
-	Tienda        string                 `protobuf:"bytes,2,opt,name=tienda,proto3" json:"tienda,omitempty"`
-	Categoria     string                 `protobuf:"bytes,3,opt,name=categoria,proto3" json:"categoria,omitempty"`
-	Producto      string                 `protobuf:"bytes,4,opt,name=producto,proto3" json:"producto,omitempty"`
-	Precio        int32                  `protobuf:"varint,5,opt,name=precio,proto3" json:"precio,omitempty"`
-	Stock         int32                  `protobuf:"varint,6,opt,name=stock,proto3" json:"stock,omitempty"`
-	Fecha         string                 `protobuf:"bytes,7,opt,name=fecha,proto3" json:"fecha,omitempty"`
-	unknownFields protoimpl.UnknownFields
-	sizeCache     protoimpl.SizeCache
+type AgregarEntradasRequest struct {
+	state            protoimpl.MessageState `protogen:"open.v1"`
+	Termino          int64                  `protobuf:"varint,1,opt,name=termino,proto3" json:"termino,omitempty"`
+	Lider            string                 `protobuf:"bytes,2,opt,name=lider,proto3" json:"lider,omitempty"`
+	DireccionLider   string                 `protobuf:"bytes,3,opt,name=direccion_lider,json=direccionLider,proto3" json:"direccion_lider,omitempty"`
+	IndicePrevio     int64                  `protobuf:"varint,4,opt,name=indice_previo,json=indicePrevio,proto3" json:"indice_previo,omitempty"`
+	TerminoPrevio    int64                  `protobuf:"varint,5,opt,name=termino_previo,json=terminoPrevio,proto3" json:"termino_previo,omitempty"`
+	Entradas         []*EntradaRaft         `protobuf:"bytes,6,rep,name=entradas,proto3" json:"entradas,omitempty"`
+	IndiceConfirmado int64                  `protobuf:"varint,7,opt,name=indice_confirmado,json=indiceConfirmado,proto3" json:"indice_confirmado,omitempty"`
+	unknownFields    protoimpl.UnknownFields
+	sizeCache        protoimpl.SizeCache
 }
 
-func (x *OfertaRequest) Reset() {
-	*x = OfertaRequest{}
-	mi := &file_proto_cyberday_proto_msgTypes[6]
+func (x *AgregarEntradasRequest) Reset() {
+	*x = AgregarEntradasRequest{}
+	mi := &file_proto_cyberday_proto_msgTypes[34]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *OfertaRequest) String() string {
+func (x *AgregarEntradasRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*OfertaRequest) ProtoMessage() {}
+func (*AgregarEntradasRequest) ProtoMessage() {}
 
-func (x *OfertaRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_cyberday_proto_msgTypes[6]
+func (x *AgregarEntradasRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_cyberday_proto_msgTypes[34]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -355,82 +2438,84 @@ func (x *OfertaRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use OfertaRequest.ProtoReflect.Descriptor instead.
-func (*OfertaRequest) Descriptor() ([]byte, []int) {
-	return file_proto_cyberday_proto_rawDescGZIP(), []int{6}
+// Deprecated: Use AgregarEntradasRequest.ProtoReflect.Descriptor instead.
+func (*AgregarEntradasRequest) Descriptor() ([]byte, []int) {
+	return file_proto_cyberday_proto_rawDescGZIP(), []int{34}
 }
 
-func (x *OfertaRequest) GetOfertaId() string {
+func (x *AgregarEntradasRequest) GetTermino() int64 {
 	if x != nil {
-		return x.OfertaId
+		return x.Termino
 	}
-	return ""
+	return 0
 }
 
-func (x *OfertaRequest) GetTienda() string {
+func (x *AgregarEntradasRequest) GetLider() string {
 	if x != nil {
-		return x.Tienda
+		return x.Lider
 	}
 	return ""
 }
 
-func (x *OfertaRequest) GetCategoria() string {
+func (x *AgregarEntradasRequest) GetDireccionLider() string {
 	if x != nil {
-		return x.Categoria
+		return x.DireccionLider
 	}
 	return ""
 }
 
-func (x *OfertaRequest) GetProducto() string {
+func (x *AgregarEntradasRequest) GetIndicePrevio() int64 {
 	if x != nil {
-		return x.Producto
+		return x.IndicePrevio
 	}
-	return ""
+	return 0
 }
 
-func (x *OfertaRequest) GetPrecio() int32 {
+func (x *AgregarEntradasRequest) GetTerminoPrevio() int64 {
 	if x != nil {
-		return x.Precio
+		return x.TerminoPrevio
 	}
 	return 0
 }
 
-func (x *OfertaRequest) GetStock() int32 {
+func (x *AgregarEntradasRequest) GetEntradas() []*EntradaRaft {
 	if x != nil {
-		return x.Stock
+		return x.Entradas
 	}
-	return 0
+	return nil
 }
 
-func (x *OfertaRequest) GetFecha() string {
+func (x *AgregarEntradasRequest) GetIndiceConfirmado() int64 {
 	if x != nil {
-		return x.Fecha
+		return x.IndiceConfirmado
 	}
-	return ""
+	return 0
 }
 
-type OfertaResponse struct {
+type AgregarEntradasResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Exito         bool                   `protobuf:"varint,1,opt,name=exito,proto3" json:"exito,omitempty"`
+	Termino       int64                  `protobuf:"varint,1,opt,name=termino,proto3" json:"termino,omitempty"`
+	Exito         bool                   `protobuf:"varint,2,opt,name=exito,proto3" json:"exito,omitempty"`
+	UltimoIndice  int64                  `protobuf:"varint,3,opt,name=ultimo_indice,json=ultimoIndice,proto3" json:"ultimo_indice,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *OfertaResponse) Reset() {
-	*x = OfertaResponse{}
-	mi := &file_proto_cyberday_proto_msgTypes[7]
+func (x *AgregarEntradasResponse) Reset() {
+	*x = AgregarEntradasResponse{}
+	mi := &file_proto_cyberday_proto_msgTypes[35]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *OfertaResponse) String() string {
+func (x *AgregarEntradasResponse) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*OfertaResponse) ProtoMessage() {}
+func (*AgregarEntradasResponse) ProtoMessage() {}
 
-func (x *OfertaResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_cyberday_proto_msgTypes[7]
+func (x *AgregarEntradasResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_cyberday_proto_msgTypes[35]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -441,43 +2526,62 @@ func (x *OfertaResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use OfertaResponse.ProtoReflect.Descriptor instead.
-func (*OfertaResponse) Descriptor() ([]byte, []int) {
-	return file_proto_cyberday_proto_rawDescGZIP(), []int{7}
+// Deprecated: Use AgregarEntradasResponse.ProtoReflect.Descriptor instead.
+func (*AgregarEntradasResponse) Descriptor() ([]byte, []int) {
+	return file_proto_cyberday_proto_rawDescGZIP(), []int{35}
 }
 
-func (x *OfertaResponse) GetExito() bool {
+func (x *AgregarEntradasResponse) GetTermino() int64 {
+	if x != nil {
+		return x.Termino
+	}
+	return 0
+}
+
+func (x *AgregarEntradasResponse) GetExito() bool {
 	if x != nil {
 		return x.Exito
 	}
 	return false
 }
 
-//********* Mensajes para sincronizacion de nodos **********
-type SincronizacionRequest struct {
-	state           protoimpl.MessageState `protogen:"open.v1"`
-	EntidadId       string                 `protobuf:"bytes,1,opt,name=entidad_id,json=entidadId,proto3" json:"entidad_id,omitempty"`
-	Tipo            string                 `protobuf:"bytes,2,opt,name=tipo,proto3" json:"tipo,omitempty"`
-	OfertasActuales []*OfertaRequest       `protobuf:"bytes,3,rep,name=ofertas_actuales,json=ofertasActuales,proto3" json:"ofertas_actuales,omitempty"`
-	unknownFields   protoimpl.UnknownFields
-	sizeCache       protoimpl.SizeCache
+func (x *AgregarEntradasResponse) GetUltimoIndice() int64 {
+	if x != nil {
+		return x.UltimoIndice
+	}
+	return 0
 }
 
-func (x *SincronizacionRequest) Reset() {
-	*x = SincronizacionRequest{}
-	mi := &file_proto_cyberday_proto_msgTypes[8]
+// Cada réplica compacta su log guardando un snapshot del estado que producen
+// las entradas hasta ultimo_indice. El líder se lo envía a la réplica que
+// necesita entradas que ya descartó.
+type InstalarSnapshotRequest struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	Termino        int64                  `protobuf:"varint,1,opt,name=termino,proto3" json:"termino,omitempty"`
+	Lider          string                 `protobuf:"bytes,2,opt,name=lider,proto3" json:"lider,omitempty"`
+	DireccionLider string                 `protobuf:"bytes,3,opt,name=direccion_lider,json=direccionLider,proto3" json:"direccion_lider,omitempty"`
+	UltimoIndice   int64                  `protobuf:"varint,4,opt,name=ultimo_indice,json=ultimoIndice,proto3" json:"ultimo_indice,omitempty"`
+	UltimoTermino  int64                  `protobuf:"varint,5,opt,name=ultimo_termino,json=ultimoTermino,proto3" json:"ultimo_termino,omitempty"`
+	Datos          []byte                 `protobuf:"bytes,6,opt,name=datos,proto3" json:"datos,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *InstalarSnapshotRequest) Reset() {
+	*x = InstalarSnapshotRequest{}
+	mi := &file_proto_cyberday_proto_msgTypes[36]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *SincronizacionRequest) String() string {
+func (x *InstalarSnapshotRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*SincronizacionRequest) ProtoMessage() {}
+func (*InstalarSnapshotRequest) ProtoMessage() {}
 
-func (x *SincronizacionRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_cyberday_proto_msgTypes[8]
+func (x *InstalarSnapshotRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_cyberday_proto_msgTypes[36]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -488,106 +2592,77 @@ func (x *SincronizacionRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use SincronizacionRequest.ProtoReflect.Descriptor instead.
-func (*SincronizacionRequest) Descriptor() ([]byte, []int) {
-	return file_proto_cyberday_proto_rawDescGZIP(), []int{8}
+// Deprecated: Use InstalarSnapshotRequest.ProtoReflect.Descriptor instead.
+func (*InstalarSnapshotRequest) Descriptor() ([]byte, []int) {
+	return file_proto_cyberday_proto_rawDescGZIP(), []int{36}
 }
 
-func (x *SincronizacionRequest) GetEntidadId() string {
+func (x *InstalarSnapshotRequest) GetTermino() int64 {
 	if x != nil {
-		return x.EntidadId
+		return x.Termino
 	}
-	return ""
+	return 0
 }
 
-func (x *SincronizacionRequest) GetTipo() string {
+func (x *InstalarSnapshotRequest) GetLider() string {
 	if x != nil {
-		return x.Tipo
+		return x.Lider
 	}
 	return ""
 }
 
-func (x *SincronizacionRequest) GetOfertasActuales() []*OfertaRequest {
+func (x *InstalarSnapshotRequest) GetDireccionLider() string {
 	if x != nil {
-		return x.OfertasActuales
+		return x.DireccionLider
 	}
-	return nil
-}
-
-type SincronizacionResponse struct {
-	state            protoimpl.MessageState `protogen:"open.v1"`
-	OfertasFaltantes []*OfertaRequest       `protobuf:"bytes,1,rep,name=ofertas_faltantes,json=ofertasFaltantes,proto3" json:"ofertas_faltantes,omitempty"`
-	Exito            bool                   `protobuf:"varint,2,opt,name=exito,proto3" json:"exito,omitempty"`
-	unknownFields    protoimpl.UnknownFields
-	sizeCache        protoimpl.SizeCache
-}
-
-func (x *SincronizacionResponse) Reset() {
-	*x = SincronizacionResponse{}
-	mi := &file_proto_cyberday_proto_msgTypes[9]
-	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
-	ms.StoreMessageInfo(mi)
-}
-
-func (x *SincronizacionResponse) String() string {
-	return protoimpl.X.MessageStringOf(x)
+	return ""
 }
 
-func (*SincronizacionResponse) ProtoMessage() {}
-
-func (x *SincronizacionResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_cyberday_proto_msgTypes[9]
+func (x *InstalarSnapshotRequest) GetUltimoIndice() int64 {
 	if x != nil {
-		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
-		if ms.LoadMessageInfo() == nil {
-			ms.StoreMessageInfo(mi)
-		}
-		return ms
+		return x.UltimoIndice
 	}
-	return mi.MessageOf(x)
-}
-
-// Deprecated: Use SincronizacionResponse.ProtoReflect.Descriptor instead.
-func (*SincronizacionResponse) Descriptor() ([]byte, []int) {
-	return file_proto_cyberday_proto_rawDescGZIP(), []int{9}
+	return 0
 }
 
-func (x *SincronizacionResponse) GetOfertasFaltantes() []*OfertaRequest {
+func (x *InstalarSnapshotRequest) GetUltimoTermino() int64 {
 	if x != nil {
-		return x.OfertasFaltantes
+		return x.UltimoTermino
 	}
-	return nil
+	return 0
 }
 
-func (x *SincronizacionResponse) GetExito() bool {
+func (x *InstalarSnapshotRequest) GetDatos() []byte {
 	if x != nil {
-		return x.Exito
+		return x.Datos
 	}
-	return false
+	return nil
 }
 
-//******** Mensajes para lectura **********
-type LecturaRequest struct {
+type SnapshotRaft struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
+	UltimoIndice  int64                  `protobuf:"varint,1,opt,name=ultimo_indice,json=ultimoIndice,proto3" json:"ultimo_indice,omitempty"`
+	UltimoTermino int64                  `protobuf:"varint,2,opt,name=ultimo_termino,json=ultimoTermino,proto3" json:"ultimo_termino,omitempty"`
+	Datos         []byte                 `protobuf:"bytes,3,opt,name=datos,proto3" json:"datos,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *LecturaRequest) Reset() {
-	*x = LecturaRequest{}
-	mi := &file_proto_cyberday_proto_msgTypes[10]
+func (x *SnapshotRaft) Reset() {
+	*x = SnapshotRaft{}
+	mi := &file_proto_cyberday_proto_msgTypes[37]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *LecturaRequest) String() string {
+func (x *SnapshotRaft) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*LecturaRequest) ProtoMessage() {}
+func (*SnapshotRaft) ProtoMessage() {}
 
-func (x *LecturaRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_cyberday_proto_msgTypes[10]
+func (x *SnapshotRaft) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_cyberday_proto_msgTypes[37]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -598,34 +2673,58 @@ func (x *LecturaRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use LecturaRequest.ProtoReflect.Descriptor instead.
-func (*LecturaRequest) Descriptor() ([]byte, []int) {
-	return file_proto_cyberday_proto_rawDescGZIP(), []int{10}
+// Deprecated: Use SnapshotRaft.ProtoReflect.Descriptor instead.
+func (*SnapshotRaft) Descriptor() ([]byte, []int) {
+	return file_proto_cyberday_proto_rawDescGZIP(), []int{37}
 }
 
-type LecturaResponse struct {
+func (x *SnapshotRaft) GetUltimoIndice() int64 {
+	if x != nil {
+		return x.UltimoIndice
+	}
+	return 0
+}
+
+func (x *SnapshotRaft) GetUltimoTermino() int64 {
+	if x != nil {
+		return x.UltimoTermino
+	}
+	return 0
+}
+
+func (x *SnapshotRaft) GetDatos() []byte {
+	if x != nil {
+		return x.Datos
+	}
+	return nil
+}
+
+// El estado del broker que construyen las entradas: el log de suscripciones,
+// las ofertas que alcanzaron quorum W y el último registro replicado.
+type SnapshotBroker struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Ofertas       []*OfertaRequest       `protobuf:"bytes,1,rep,name=ofertas,proto3" json:"ofertas,omitempty"`
-	Exito         bool                   `protobuf:"varint,2,opt,name=exito,proto3" json:"exito,omitempty"`
+	Confirmadas   []string               `protobuf:"bytes,2,rep,name=confirmadas,proto3" json:"confirmadas,omitempty"`
+	Estado        []byte                 `protobuf:"bytes,3,opt,name=estado,proto3" json:"estado,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *LecturaResponse) Reset() {
-	*x = LecturaResponse{}
-	mi := &file_proto_cyberday_proto_msgTypes[11]
+func (x *SnapshotBroker) Reset() {
+	*x = SnapshotBroker{}
+	mi := &file_proto_cyberday_proto_msgTypes[38]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *LecturaResponse) String() string {
+func (x *SnapshotBroker) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*LecturaResponse) ProtoMessage() {}
+func (*SnapshotBroker) ProtoMessage() {}
 
-func (x *LecturaResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_cyberday_proto_msgTypes[11]
+func (x *SnapshotBroker) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_cyberday_proto_msgTypes[38]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -636,26 +2735,33 @@ func (x *LecturaResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use LecturaResponse.ProtoReflect.Descriptor instead.
-func (*LecturaResponse) Descriptor() ([]byte, []int) {
-	return file_proto_cyberday_proto_rawDescGZIP(), []int{11}
+// Deprecated: Use SnapshotBroker.ProtoReflect.Descriptor instead.
+func (*SnapshotBroker) Descriptor() ([]byte, []int) {
+	return file_proto_cyberday_proto_rawDescGZIP(), []int{38}
 }
 
-func (x *LecturaResponse) GetOfertas() []*OfertaRequest {
+func (x *SnapshotBroker) GetOfertas() []*OfertaRequest {
 	if x != nil {
 		return x.Ofertas
 	}
 	return nil
 }
 
-func (x *LecturaResponse) GetExito() bool {
+func (x *SnapshotBroker) GetConfirmadas() []string {
 	if x != nil {
-		return x.Exito
+		return x.Confirmadas
 	}
-	return false
+	return nil
+}
+
+func (x *SnapshotBroker) GetEstado() []byte {
+	if x != nil {
+		return x.Estado
+	}
+	return nil
 }
 
-//******** Mensajes para Shutdown **********
+// ******** Mensajes para Shutdown **********
 type ConsultarEstadoRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	unknownFields protoimpl.UnknownFields
@@ -664,7 +2770,7 @@ type ConsultarEstadoRequest struct {
 
 func (x *ConsultarEstadoRequest) Reset() {
 	*x = ConsultarEstadoRequest{}
-	mi := &file_proto_cyberday_proto_msgTypes[12]
+	mi := &file_proto_cyberday_proto_msgTypes[39]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -676,7 +2782,7 @@ func (x *ConsultarEstadoRequest) String() string {
 func (*ConsultarEstadoRequest) ProtoMessage() {}
 
 func (x *ConsultarEstadoRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_cyberday_proto_msgTypes[12]
+	mi := &file_proto_cyberday_proto_msgTypes[39]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -689,7 +2795,7 @@ func (x *ConsultarEstadoRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use ConsultarEstadoRequest.ProtoReflect.Descriptor instead.
 func (*ConsultarEstadoRequest) Descriptor() ([]byte, []int) {
-	return file_proto_cyberday_proto_rawDescGZIP(), []int{12}
+	return file_proto_cyberday_proto_rawDescGZIP(), []int{39}
 }
 
 type ConsultarEstadoResponse struct {
@@ -701,7 +2807,7 @@ type ConsultarEstadoResponse struct {
 
 func (x *ConsultarEstadoResponse) Reset() {
 	*x = ConsultarEstadoResponse{}
-	mi := &file_proto_cyberday_proto_msgTypes[13]
+	mi := &file_proto_cyberday_proto_msgTypes[40]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -713,7 +2819,7 @@ func (x *ConsultarEstadoResponse) String() string {
 func (*ConsultarEstadoResponse) ProtoMessage() {}
 
 func (x *ConsultarEstadoResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_cyberday_proto_msgTypes[13]
+	mi := &file_proto_cyberday_proto_msgTypes[40]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -726,7 +2832,7 @@ func (x *ConsultarEstadoResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use ConsultarEstadoResponse.ProtoReflect.Descriptor instead.
 func (*ConsultarEstadoResponse) Descriptor() ([]byte, []int) {
-	return file_proto_cyberday_proto_rawDescGZIP(), []int{13}
+	return file_proto_cyberday_proto_rawDescGZIP(), []int{40}
 }
 
 func (x *ConsultarEstadoResponse) GetActivo() bool {
@@ -745,7 +2851,7 @@ const file_proto_cyberday_proto_rawDesc = "" +
 	"\x06nombre\x18\x01 \x01(\tR\x06nombre\"K\n" +
 	"\x13RegistroNodoRequest\x12\x16\n" +
 	"\x06nombre\x18\x01 \x01(\tR\x06nombre\x12\x1c\n" +
-	"\tdireccion\x18\x02 \x01(\tR\tdireccion\"\xb7\x01\n" +
+	"\tdireccion\x18\x02 \x01(\tR\tdireccion\"\xda\x01\n" +
 	"\x19RegistroConsumidorRequest\x12#\n" +
 	"\rconsumidor_id\x18\x01 \x01(\tR\fconsumidorId\x12\x1e\n" +
 	"\n" +
@@ -753,13 +2859,44 @@ const file_proto_cyberday_proto_rawDesc = "" +
 	"categorias\x12\x18\n" +
 	"\atiendas\x18\x03 \x03(\tR\atiendas\x12\x1d\n" +
 	"\n" +
-	"precio_max\x18\x04 \x01(\x05R\tprecioMax\x12\x1c\n" +
-	"\tdireccion\x18\x05 \x01(\tR\tdireccion\"(\n" +
+	"precio_max\x18\x04 \x01(\x05R\tprecioMax\x12.\n" +
+	"\x06filtro\x18\x06 \x01(\v2\x16.cyberday.FiltroOfertaR\x06filtroJ\x04\b\x05\x10\x06R\tdireccion\"\x8a\x03\n" +
+	"\fFiltroOferta\x12\x1e\n" +
+	"\n" +
+	"categorias\x18\x01 \x03(\tR\n" +
+	"categorias\x12\x18\n" +
+	"\atiendas\x18\x02 \x03(\tR\atiendas\x12\x1d\n" +
+	"\n" +
+	"precio_min\x18\x03 \x01(\x05R\tprecioMin\x12\x1d\n" +
+	"\n" +
+	"precio_max\x18\x04 \x01(\x05R\tprecioMax\x12#\n" +
+	"\rdescuento_min\x18\x05 \x01(\x05R\fdescuentoMin\x12\x1b\n" +
+	"\tstock_min\x18\x06 \x01(\x05R\bstockMin\x12+\n" +
+	"\x11producto_contiene\x18\a \x01(\tR\x10productoContiene\x12%\n" +
+	"\x0eproducto_regex\x18\b \x01(\tR\rproductoRegex\x124\n" +
+	"\boperador\x18\t \x01(\x0e2\x18.cyberday.OperadorFiltroR\boperador\x126\n" +
+	"\n" +
+	"subfiltros\x18\n" +
+	" \x03(\v2\x16.cyberday.FiltroOfertaR\n" +
+	"subfiltros\"(\n" +
 	"\x10RegistroResponse\x12\x14\n" +
-	"\x05exito\x18\x01 \x01(\bR\x05exito\"\x0f\n" +
+	"\x05exito\x18\x01 \x01(\bR\x05exito\"B\n" +
+	"\rSalidaRequest\x12\x1d\n" +
+	"\n" +
+	"entidad_id\x18\x01 \x01(\tR\tentidadId\x12\x12\n" +
+	"\x04tipo\x18\x02 \x01(\tR\x04tipo\"w\n" +
+	" ActualizacionPreferenciasRequest\x12#\n" +
+	"\rconsumidor_id\x18\x01 \x01(\tR\fconsumidorId\x12.\n" +
+	"\x06filtro\x18\x02 \x01(\v2\x16.cyberday.FiltroOfertaR\x06filtro\"\x94\x01\n" +
+	"!ActualizacionPreferenciasResponse\x12\x14\n" +
+	"\x05exito\x18\x01 \x01(\bR\x05exito\x12&\n" +
+	"\x0eresincronizado\x18\x02 \x01(\bR\x0eresincronizado\x121\n" +
+	"\aofertas\x18\x03 \x03(\v2\x17.cyberday.OfertaRequestR\aofertas\"<\n" +
+	"\x15BajaConsumidorRequest\x12#\n" +
+	"\rconsumidor_id\x18\x01 \x01(\tR\fconsumidorId\"\x0f\n" +
 	"\rInicioRequest\"(\n" +
 	"\x0eInicioResponse\x12\x16\n" +
-	"\x06inicio\x18\x01 \x01(\bR\x06inicio\"\xc2\x01\n" +
+	"\x06inicio\x18\x01 \x01(\bR\x06inicio\"\xe0\x02\n" +
 	"\rOfertaRequest\x12\x1b\n" +
 	"\toferta_id\x18\x01 \x01(\tR\bofertaId\x12\x16\n" +
 	"\x06tienda\x18\x02 \x01(\tR\x06tienda\x12\x1c\n" +
@@ -767,32 +2904,199 @@ const file_proto_cyberday_proto_rawDesc = "" +
 	"\bproducto\x18\x04 \x01(\tR\bproducto\x12\x16\n" +
 	"\x06precio\x18\x05 \x01(\x05R\x06precio\x12\x14\n" +
 	"\x05stock\x18\x06 \x01(\x05R\x05stock\x12\x14\n" +
-	"\x05fecha\x18\a \x01(\tR\x05fecha\"&\n" +
+	"\x05fecha\x18\a \x01(\tR\x05fecha\x12\x18\n" +
+	"\aversion\x18\b \x01(\x03R\aversion\x12\x1c\n" +
+	"\tdescuento\x18\t \x01(\x05R\tdescuento\x12.\n" +
+	"\x06estado\x18\n" +
+	" \x01(\x0e2\x16.cyberday.EstadoOfertaR\x06estado\x12\x16\n" +
+	"\x06expira\x18\v \x01(\x03R\x06expira\x12\x1c\n" +
+	"\tsecuencia\x18\f \x01(\x03R\tsecuencia\"&\n" +
 	"\x0eOfertaResponse\x12\x14\n" +
-	"\x05exito\x18\x01 \x01(\bR\x05exito\"\x8e\x01\n" +
+	"\x05exito\x18\x01 \x01(\bR\x05exito\"\x8b\x01\n" +
+	"\x19ActualizacionStockRequest\x12\x1b\n" +
+	"\toferta_id\x18\x01 \x01(\tR\bofertaId\x12\x14\n" +
+	"\x05delta\x18\x02 \x01(\x05R\x05delta\x12#\n" +
+	"\rconsumidor_id\x18\x03 \x01(\tR\fconsumidorId\x12\x16\n" +
+	"\x06tienda\x18\x04 \x01(\tR\x06tienda\"J\n" +
+	"\x13RetiroOfertaRequest\x12\x1b\n" +
+	"\toferta_id\x18\x01 \x01(\tR\bofertaId\x12\x16\n" +
+	"\x06tienda\x18\x02 \x01(\tR\x06tienda\"u\n" +
+	"\x14CambioOfertaResponse\x12\x14\n" +
+	"\x05exito\x18\x01 \x01(\bR\x05exito\x12/\n" +
+	"\x06oferta\x18\x02 \x01(\v2\x17.cyberday.OfertaRequestR\x06oferta\x12\x16\n" +
+	"\x06motivo\x18\x03 \x01(\tR\x06motivo\"3\n" +
+	"\x14LecturaOfertaRequest\x12\x1b\n" +
+	"\toferta_id\x18\x01 \x01(\tR\bofertaId\"y\n" +
 	"\x15SincronizacionRequest\x12\x1d\n" +
 	"\n" +
 	"entidad_id\x18\x01 \x01(\tR\tentidadId\x12\x12\n" +
-	"\x04tipo\x18\x02 \x01(\tR\x04tipo\x12B\n" +
-	"\x10ofertas_actuales\x18\x03 \x03(\v2\x17.cyberday.OfertaRequestR\x0fofertasActuales\"t\n" +
+	"\x04tipo\x18\x02 \x01(\tR\x04tipo\x12'\n" +
+	"\x0fdesde_secuencia\x18\x04 \x01(\x03R\x0edesdeSecuenciaJ\x04\b\x03\x10\x04\"\xa3\x01\n" +
 	"\x16SincronizacionResponse\x12D\n" +
 	"\x11ofertas_faltantes\x18\x01 \x03(\v2\x17.cyberday.OfertaRequestR\x10ofertasFaltantes\x12\x14\n" +
-	"\x05exito\x18\x02 \x01(\bR\x05exito\"\x10\n" +
-	"\x0eLecturaRequest\"Z\n" +
+	"\x05exito\x18\x02 \x01(\bR\x05exito\x12-\n" +
+	"\x05hints\x18\x03 \x03(\v2\x17.cyberday.OfertaRequestR\x05hints\"F\n" +
+	"\rHintEntregado\x12\x1b\n" +
+	"\toferta_id\x18\x01 \x01(\tR\bofertaId\x12\x18\n" +
+	"\aversion\x18\x02 \x01(\x03R\aversion\"b\n" +
+	"\x18ConfirmacionHintsRequest\x12\x17\n" +
+	"\anodo_id\x18\x01 \x01(\tR\x06nodoId\x12-\n" +
+	"\x05hints\x18\x02 \x03(\v2\x17.cyberday.HintEntregadoR\x05hints\"\xcd\x02\n" +
+	"\x0eLecturaRequest\x12\x18\n" +
+	"\atiendas\x18\x01 \x03(\tR\atiendas\x12\x1e\n" +
+	"\n" +
+	"categorias\x18\x02 \x03(\tR\n" +
+	"categorias\x12\x1d\n" +
+	"\n" +
+	"precio_min\x18\x03 \x01(\x05R\tprecioMin\x12\x1d\n" +
+	"\n" +
+	"precio_max\x18\x04 \x01(\x05R\tprecioMax\x12\x1f\n" +
+	"\vdesde_fecha\x18\x05 \x01(\tR\n" +
+	"desdeFecha\x12\x1f\n" +
+	"\vhasta_fecha\x18\x06 \x01(\tR\n" +
+	"hastaFecha\x12\x1d\n" +
+	"\n" +
+	"prefijo_id\x18\a \x01(\tR\tprefijoId\x12#\n" +
+	"\rtamano_pagina\x18\b \x01(\x05R\ftamanoPagina\x12\x14\n" +
+	"\x05token\x18\t \x01(\tR\x05token\x12'\n" +
+	"\x0fdesde_secuencia\x18\n" +
+	" \x01(\x03R\x0edesdeSecuencia\"\xae\x01\n" +
 	"\x0fLecturaResponse\x121\n" +
 	"\aofertas\x18\x01 \x03(\v2\x17.cyberday.OfertaRequestR\aofertas\x12\x14\n" +
-	"\x05exito\x18\x02 \x01(\bR\x05exito\"\x18\n" +
+	"\x05exito\x18\x02 \x01(\bR\x05exito\x12'\n" +
+	"\x0fsiguiente_token\x18\x03 \x01(\tR\x0esiguienteToken\x12)\n" +
+	"\x10ultima_secuencia\x18\x04 \x01(\x03R\x0fultimaSecuencia\"\xb8\x01\n" +
+	"\x0fBusquedaRequest\x12.\n" +
+	"\x06filtro\x18\x01 \x01(\v2\x16.cyberday.FiltroOfertaR\x06filtro\x12-\n" +
+	"\x05orden\x18\x02 \x01(\x0e2\x17.cyberday.OrdenBusquedaR\x05orden\x12\x16\n" +
+	"\x06limite\x18\x03 \x01(\x05R\x06limite\x12.\n" +
+	"\x13incluir_no_vigentes\x18\x04 \x01(\bR\x11incluirNoVigentes\"\x8b\x01\n" +
+	"\x10BusquedaResponse\x121\n" +
+	"\aofertas\x18\x01 \x03(\v2\x17.cyberday.OfertaRequestR\aofertas\x12\x14\n" +
+	"\x05exito\x18\x02 \x01(\bR\x05exito\x12\x14\n" +
+	"\x05total\x18\x03 \x01(\x05R\x05total\x12\x18\n" +
+	"\aparcial\x18\x04 \x01(\bR\aparcial\"7\n" +
+	"\vRangoAnillo\x12\x16\n" +
+	"\x06inicio\x18\x01 \x01(\x04R\x06inicio\x12\x10\n" +
+	"\x03fin\x18\x02 \x01(\x04R\x03fin\"d\n" +
+	"\x13HashesMerkleRequest\x12\x1e\n" +
+	"\n" +
+	"posiciones\x18\x01 \x03(\x05R\n" +
+	"posiciones\x12-\n" +
+	"\x06rangos\x18\x02 \x03(\v2\x15.cyberday.RangoAnilloR\x06rangos\"Z\n" +
+	"\x14HashesMerkleResponse\x12\x16\n" +
+	"\x06hashes\x18\x01 \x03(\fR\x06hashes\x12\x14\n" +
+	"\x05hojas\x18\x02 \x01(\x05R\x05hojas\x12\x14\n" +
+	"\x05exito\x18\x03 \x01(\bR\x05exito\"`\n" +
+	"\x15LecturaBucketsRequest\x12\x18\n" +
+	"\abuckets\x18\x01 \x03(\x05R\abuckets\x12-\n" +
+	"\x06rangos\x18\x02 \x03(\v2\x15.cyberday.RangoAnilloR\x06rangos\"\xe5\x01\n" +
+	"\x12SuscripcionRequest\x12#\n" +
+	"\rconsumidor_id\x18\x01 \x01(\tR\fconsumidorId\x12\x1e\n" +
+	"\n" +
+	"categorias\x18\x02 \x03(\tR\n" +
+	"categorias\x12\x18\n" +
+	"\atiendas\x18\x03 \x03(\tR\atiendas\x12\x1d\n" +
+	"\n" +
+	"precio_max\x18\x04 \x01(\x05R\tprecioMax\x12!\n" +
+	"\fdesde_offset\x18\x05 \x01(\x03R\vdesdeOffset\x12.\n" +
+	"\x06filtro\x18\x06 \x01(\v2\x16.cyberday.FiltroOfertaR\x06filtro\"u\n" +
+	"\x12NotificacionOferta\x12\x16\n" +
+	"\x06offset\x18\x01 \x01(\x03R\x06offset\x12/\n" +
+	"\x06oferta\x18\x02 \x01(\v2\x17.cyberday.OfertaRequestR\x06oferta\x12\x16\n" +
+	"\x06latido\x18\x03 \x01(\bR\x06latido\"\xd7\x01\n" +
+	"\vEntradaRaft\x12\x18\n" +
+	"\atermino\x18\x01 \x01(\x03R\atermino\x12\x16\n" +
+	"\x06indice\x18\x02 \x01(\x03R\x06indice\x12-\n" +
+	"\x04tipo\x18\x03 \x01(\x0e2\x19.cyberday.TipoEntradaRaftR\x04tipo\x12/\n" +
+	"\x06oferta\x18\x04 \x01(\v2\x17.cyberday.OfertaRequestR\x06oferta\x12\x16\n" +
+	"\x06estado\x18\x05 \x01(\fR\x06estado\x12\x1e\n" +
+	"\n" +
+	"confirmada\x18\x06 \x01(\bR\n" +
+	"confirmada\"\x9a\x01\n" +
+	"\x14SolicitudVotoRequest\x12\x18\n" +
+	"\atermino\x18\x01 \x01(\x03R\atermino\x12\x1c\n" +
+	"\tcandidato\x18\x02 \x01(\tR\tcandidato\x12#\n" +
+	"\rultimo_indice\x18\x03 \x01(\x03R\fultimoIndice\x12%\n" +
+	"\x0eultimo_termino\x18\x04 \x01(\x03R\rultimoTermino\"X\n" +
+	"\x15SolicitudVotoResponse\x12\x18\n" +
+	"\atermino\x18\x01 \x01(\x03R\atermino\x12%\n" +
+	"\x0evoto_concedido\x18\x02 \x01(\bR\rvotoConcedido\"\x9d\x02\n" +
+	"\x16AgregarEntradasRequest\x12\x18\n" +
+	"\atermino\x18\x01 \x01(\x03R\atermino\x12\x14\n" +
+	"\x05lider\x18\x02 \x01(\tR\x05lider\x12'\n" +
+	"\x0fdireccion_lider\x18\x03 \x01(\tR\x0edireccionLider\x12#\n" +
+	"\rindice_previo\x18\x04 \x01(\x03R\findicePrevio\x12%\n" +
+	"\x0etermino_previo\x18\x05 \x01(\x03R\rterminoPrevio\x121\n" +
+	"\bentradas\x18\x06 \x03(\v2\x15.cyberday.EntradaRaftR\bentradas\x12+\n" +
+	"\x11indice_confirmado\x18\a \x01(\x03R\x10indiceConfirmado\"n\n" +
+	"\x17AgregarEntradasResponse\x12\x18\n" +
+	"\atermino\x18\x01 \x01(\x03R\atermino\x12\x14\n" +
+	"\x05exito\x18\x02 \x01(\bR\x05exito\x12#\n" +
+	"\rultimo_indice\x18\x03 \x01(\x03R\fultimoIndice\"\xd4\x01\n" +
+	"\x17InstalarSnapshotRequest\x12\x18\n" +
+	"\atermino\x18\x01 \x01(\x03R\atermino\x12\x14\n" +
+	"\x05lider\x18\x02 \x01(\tR\x05lider\x12'\n" +
+	"\x0fdireccion_lider\x18\x03 \x01(\tR\x0edireccionLider\x12#\n" +
+	"\rultimo_indice\x18\x04 \x01(\x03R\fultimoIndice\x12%\n" +
+	"\x0eultimo_termino\x18\x05 \x01(\x03R\rultimoTermino\x12\x14\n" +
+	"\x05datos\x18\x06 \x01(\fR\x05datos\"p\n" +
+	"\fSnapshotRaft\x12#\n" +
+	"\rultimo_indice\x18\x01 \x01(\x03R\fultimoIndice\x12%\n" +
+	"\x0eultimo_termino\x18\x02 \x01(\x03R\rultimoTermino\x12\x14\n" +
+	"\x05datos\x18\x03 \x01(\fR\x05datos\"}\n" +
+	"\x0eSnapshotBroker\x121\n" +
+	"\aofertas\x18\x01 \x03(\v2\x17.cyberday.OfertaRequestR\aofertas\x12 \n" +
+	"\vconfirmadas\x18\x02 \x03(\tR\vconfirmadas\x12\x16\n" +
+	"\x06estado\x18\x03 \x01(\fR\x06estado\"\x18\n" +
 	"\x16ConsultarEstadoRequest\"1\n" +
 	"\x17ConsultarEstadoResponse\x12\x16\n" +
-	"\x06activo\x18\x01 \x01(\bR\x06activo2\x89\x05\n" +
+	"\x06activo\x18\x01 \x01(\bR\x06activo*&\n" +
+	"\x0eOperadorFiltro\x12\x05\n" +
+	"\x01Y\x10\x00\x12\x05\n" +
+	"\x01O\x10\x01\x12\x06\n" +
+	"\x02NO\x10\x02*D\n" +
+	"\fEstadoOferta\x12\v\n" +
+	"\aVIGENTE\x10\x00\x12\v\n" +
+	"\aAGOTADA\x10\x01\x12\f\n" +
+	"\bRETIRADA\x10\x02\x12\f\n" +
+	"\bEXPIRADA\x10\x03*P\n" +
+	"\rOrdenBusqueda\x12\n" +
+	"\n" +
+	"\x06POR_ID\x10\x00\x12\x0e\n" +
+	"\n" +
+	"PRECIO_ASC\x10\x01\x12\x0f\n" +
+	"\vPRECIO_DESC\x10\x02\x12\x12\n" +
+	"\x0eDESCUENTO_DESC\x10\x03*4\n" +
+	"\x0fTipoEntradaRaft\x12\t\n" +
+	"\x05VACIA\x10\x00\x12\n" +
+	"\n" +
+	"\x06OFERTA\x10\x01\x12\n" +
+	"\n" +
+	"\x06ESTADO\x10\x022\x94\x0e\n" +
 	"\x0fCyberDayService\x12T\n" +
 	"\x12RegistrarProductor\x12\".cyberday.RegistroProductorRequest\x1a\x1a.cyberday.RegistroResponse\x12J\n" +
 	"\rRegistrarNodo\x12\x1d.cyberday.RegistroNodoRequest\x1a\x1a.cyberday.RegistroResponse\x12V\n" +
-	"\x13RegistrarConsumidor\x12#.cyberday.RegistroConsumidorRequest\x1a\x1a.cyberday.RegistroResponse\x12D\n" +
+	"\x13RegistrarConsumidor\x12#.cyberday.RegistroConsumidorRequest\x1a\x1a.cyberday.RegistroResponse\x12G\n" +
+	"\x10AbandonarCluster\x12\x17.cyberday.SalidaRequest\x1a\x1a.cyberday.RegistroResponse\x12q\n" +
+	"\x16ActualizarPreferencias\x12*.cyberday.ActualizacionPreferenciasRequest\x1a+.cyberday.ActualizacionPreferenciasResponse\x12R\n" +
+	"\x13DarDeBajaConsumidor\x12\x1f.cyberday.BajaConsumidorRequest\x1a\x1a.cyberday.RegistroResponse\x12D\n" +
 	"\x0fSolicitarInicio\x12\x17.cyberday.InicioRequest\x1a\x18.cyberday.InicioResponse\x12A\n" +
-	"\fEnviarOferta\x12\x17.cyberday.OfertaRequest\x1a\x18.cyberday.OfertaResponse\x12W\n" +
-	"\x12SincronizarEntidad\x12\x1f.cyberday.SincronizacionRequest\x1a .cyberday.SincronizacionResponse\x12B\n" +
-	"\vLeerOfertas\x12\x18.cyberday.LecturaRequest\x1a\x19.cyberday.LecturaResponse\x12V\n" +
+	"\fEnviarOferta\x12\x17.cyberday.OfertaRequest\x1a\x18.cyberday.OfertaResponse\x12V\n" +
+	"\x0fActualizarStock\x12#.cyberday.ActualizacionStockRequest\x1a\x1e.cyberday.CambioOfertaResponse\x12N\n" +
+	"\rRetirarOferta\x12\x1d.cyberday.RetiroOfertaRequest\x1a\x1e.cyberday.CambioOfertaResponse\x12W\n" +
+	"\x12SincronizarEntidad\x12\x1f.cyberday.SincronizacionRequest\x1a .cyberday.SincronizacionResponse\x12P\n" +
+	"\x0eConfirmarHints\x12\".cyberday.ConfirmacionHintsRequest\x1a\x1a.cyberday.RegistroResponse\x12B\n" +
+	"\vLeerOfertas\x12\x18.cyberday.LecturaRequest\x1a\x19.cyberday.LecturaResponse\x12G\n" +
+	"\n" +
+	"LeerOferta\x12\x1e.cyberday.LecturaOfertaRequest\x1a\x19.cyberday.LecturaResponse\x12F\n" +
+	"\rBuscarOfertas\x12\x19.cyberday.BusquedaRequest\x1a\x1a.cyberday.BusquedaResponse\x12T\n" +
+	"\x13ObtenerHashesMerkle\x12\x1d.cyberday.HashesMerkleRequest\x1a\x1e.cyberday.HashesMerkleResponse\x12I\n" +
+	"\vLeerBuckets\x12\x1f.cyberday.LecturaBucketsRequest\x1a\x19.cyberday.LecturaResponse\x12I\n" +
+	"\tSuscribir\x12\x1c.cyberday.SuscripcionRequest\x1a\x1c.cyberday.NotificacionOferta0\x01\x12P\n" +
+	"\rSolicitarVoto\x12\x1e.cyberday.SolicitudVotoRequest\x1a\x1f.cyberday.SolicitudVotoResponse\x12V\n" +
+	"\x0fAgregarEntradas\x12 .cyberday.AgregarEntradasRequest\x1a!.cyberday.AgregarEntradasResponse\x12X\n" +
+	"\x10InstalarSnapshot\x12!.cyberday.InstalarSnapshotRequest\x1a!.cyberday.AgregarEntradasResponse\x12V\n" +
 	"\x0fConsultarEstado\x12 .cyberday.ConsultarEstadoRequest\x1a!.cyberday.ConsultarEstadoResponseB\bZ\x06/protob\x06proto3"
 
 var (
@@ -807,48 +3111,127 @@ func file_proto_cyberday_proto_rawDescGZIP() []byte {
 	return file_proto_cyberday_proto_rawDescData
 }
 
-var file_proto_cyberday_proto_msgTypes = make([]protoimpl.MessageInfo, 14)
+var file_proto_cyberday_proto_enumTypes = make([]protoimpl.EnumInfo, 4)
+var file_proto_cyberday_proto_msgTypes = make([]protoimpl.MessageInfo, 41)
 var file_proto_cyberday_proto_goTypes = []any{
-	(*RegistroProductorRequest)(nil),  // 0: cyberday.RegistroProductorRequest
-	(*RegistroNodoRequest)(nil),       // 1: cyberday.RegistroNodoRequest
-	(*RegistroConsumidorRequest)(nil), // 2: cyberday.RegistroConsumidorRequest
-	(*RegistroResponse)(nil),          // 3: cyberday.RegistroResponse
-	(*InicioRequest)(nil),             // 4: cyberday.InicioRequest
-	(*InicioResponse)(nil),            // 5: cyberday.InicioResponse
-	(*OfertaRequest)(nil),             // 6: cyberday.OfertaRequest
-	(*OfertaResponse)(nil),            // 7: cyberday.OfertaResponse
-	(*SincronizacionRequest)(nil),     // 8: cyberday.SincronizacionRequest
-	(*SincronizacionResponse)(nil),    // 9: cyberday.SincronizacionResponse
-	(*LecturaRequest)(nil),            // 10: cyberday.LecturaRequest
-	(*LecturaResponse)(nil),           // 11: cyberday.LecturaResponse
-	(*ConsultarEstadoRequest)(nil),    // 12: cyberday.ConsultarEstadoRequest
-	(*ConsultarEstadoResponse)(nil),   // 13: cyberday.ConsultarEstadoResponse
+	(OperadorFiltro)(0),                       // 0: cyberday.OperadorFiltro
+	(EstadoOferta)(0),                         // 1: cyberday.EstadoOferta
+	(OrdenBusqueda)(0),                        // 2: cyberday.OrdenBusqueda
+	(TipoEntradaRaft)(0),                      // 3: cyberday.TipoEntradaRaft
+	(*RegistroProductorRequest)(nil),          // 4: cyberday.RegistroProductorRequest
+	(*RegistroNodoRequest)(nil),               // 5: cyberday.RegistroNodoRequest
+	(*RegistroConsumidorRequest)(nil),         // 6: cyberday.RegistroConsumidorRequest
+	(*FiltroOferta)(nil),                      // 7: cyberday.FiltroOferta
+	(*RegistroResponse)(nil),                  // 8: cyberday.RegistroResponse
+	(*SalidaRequest)(nil),                     // 9: cyberday.SalidaRequest
+	(*ActualizacionPreferenciasRequest)(nil),  // 10: cyberday.ActualizacionPreferenciasRequest
+	(*ActualizacionPreferenciasResponse)(nil), // 11: cyberday.ActualizacionPreferenciasResponse
+	(*BajaConsumidorRequest)(nil),             // 12: cyberday.BajaConsumidorRequest
+	(*InicioRequest)(nil),                     // 13: cyberday.InicioRequest
+	(*InicioResponse)(nil),                    // 14: cyberday.InicioResponse
+	(*OfertaRequest)(nil),                     // 15: cyberday.OfertaRequest
+	(*OfertaResponse)(nil),                    // 16: cyberday.OfertaResponse
+	(*ActualizacionStockRequest)(nil),         // 17: cyberday.ActualizacionStockRequest
+	(*RetiroOfertaRequest)(nil),               // 18: cyberday.RetiroOfertaRequest
+	(*CambioOfertaResponse)(nil),              // 19: cyberday.CambioOfertaResponse
+	(*LecturaOfertaRequest)(nil),              // 20: cyberday.LecturaOfertaRequest
+	(*SincronizacionRequest)(nil),             // 21: cyberday.SincronizacionRequest
+	(*SincronizacionResponse)(nil),            // 22: cyberday.SincronizacionResponse
+	(*HintEntregado)(nil),                     // 23: cyberday.HintEntregado
+	(*ConfirmacionHintsRequest)(nil),          // 24: cyberday.ConfirmacionHintsRequest
+	(*LecturaRequest)(nil),                    // 25: cyberday.LecturaRequest
+	(*LecturaResponse)(nil),                   // 26: cyberday.LecturaResponse
+	(*BusquedaRequest)(nil),                   // 27: cyberday.BusquedaRequest
+	(*BusquedaResponse)(nil),                  // 28: cyberday.BusquedaResponse
+	(*RangoAnillo)(nil),                       // 29: cyberday.RangoAnillo
+	(*HashesMerkleRequest)(nil),               // 30: cyberday.HashesMerkleRequest
+	(*HashesMerkleResponse)(nil),              // 31: cyberday.HashesMerkleResponse
+	(*LecturaBucketsRequest)(nil),             // 32: cyberday.LecturaBucketsRequest
+	(*SuscripcionRequest)(nil),                // 33: cyberday.SuscripcionRequest
+	(*NotificacionOferta)(nil),                // 34: cyberday.NotificacionOferta
+	(*EntradaRaft)(nil),                       // 35: cyberday.EntradaRaft
+	(*SolicitudVotoRequest)(nil),              // 36: cyberday.SolicitudVotoRequest
+	(*SolicitudVotoResponse)(nil),             // 37: cyberday.SolicitudVotoResponse
+	(*AgregarEntradasRequest)(nil),            // 38: cyberday.AgregarEntradasRequest
+	(*AgregarEntradasResponse)(nil),           // 39: cyberday.AgregarEntradasResponse
+	(*InstalarSnapshotRequest)(nil),           // 40: cyberday.InstalarSnapshotRequest
+	(*SnapshotRaft)(nil),                      // 41: cyberday.SnapshotRaft
+	(*SnapshotBroker)(nil),                    // 42: cyberday.SnapshotBroker
+	(*ConsultarEstadoRequest)(nil),            // 43: cyberday.ConsultarEstadoRequest
+	(*ConsultarEstadoResponse)(nil),           // 44: cyberday.ConsultarEstadoResponse
 }
 var file_proto_cyberday_proto_depIdxs = []int32{
-	6,  // 0: cyberday.SincronizacionRequest.ofertas_actuales:type_name -> cyberday.OfertaRequest
-	6,  // 1: cyberday.SincronizacionResponse.ofertas_faltantes:type_name -> cyberday.OfertaRequest
-	6,  // 2: cyberday.LecturaResponse.ofertas:type_name -> cyberday.OfertaRequest
-	0,  // 3: cyberday.CyberDayService.RegistrarProductor:input_type -> cyberday.RegistroProductorRequest
-	1,  // 4: cyberday.CyberDayService.RegistrarNodo:input_type -> cyberday.RegistroNodoRequest
-	2,  // 5: cyberday.CyberDayService.RegistrarConsumidor:input_type -> cyberday.RegistroConsumidorRequest
-	4,  // 6: cyberday.CyberDayService.SolicitarInicio:input_type -> cyberday.InicioRequest
-	6,  // 7: cyberday.CyberDayService.EnviarOferta:input_type -> cyberday.OfertaRequest
-	8,  // 8: cyberday.CyberDayService.SincronizarEntidad:input_type -> cyberday.SincronizacionRequest
-	10, // 9: cyberday.CyberDayService.LeerOfertas:input_type -> cyberday.LecturaRequest
-	12, // 10: cyberday.CyberDayService.ConsultarEstado:input_type -> cyberday.ConsultarEstadoRequest
-	3,  // 11: cyberday.CyberDayService.RegistrarProductor:output_type -> cyberday.RegistroResponse
-	3,  // 12: cyberday.CyberDayService.RegistrarNodo:output_type -> cyberday.RegistroResponse
-	3,  // 13: cyberday.CyberDayService.RegistrarConsumidor:output_type -> cyberday.RegistroResponse
-	5,  // 14: cyberday.CyberDayService.SolicitarInicio:output_type -> cyberday.InicioResponse
-	7,  // 15: cyberday.CyberDayService.EnviarOferta:output_type -> cyberday.OfertaResponse
-	9,  // 16: cyberday.CyberDayService.SincronizarEntidad:output_type -> cyberday.SincronizacionResponse
-	11, // 17: cyberday.CyberDayService.LeerOfertas:output_type -> cyberday.LecturaResponse
-	13, // 18: cyberday.CyberDayService.ConsultarEstado:output_type -> cyberday.ConsultarEstadoResponse
-	11, // [11:19] is the sub-list for method output_type
-	3,  // [3:11] is the sub-list for method input_type
-	3,  // [3:3] is the sub-list for extension type_name
-	3,  // [3:3] is the sub-list for extension extendee
-	0,  // [0:3] is the sub-list for field type_name
+	7,  // 0: cyberday.RegistroConsumidorRequest.filtro:type_name -> cyberday.FiltroOferta
+	0,  // 1: cyberday.FiltroOferta.operador:type_name -> cyberday.OperadorFiltro
+	7,  // 2: cyberday.FiltroOferta.subfiltros:type_name -> cyberday.FiltroOferta
+	7,  // 3: cyberday.ActualizacionPreferenciasRequest.filtro:type_name -> cyberday.FiltroOferta
+	15, // 4: cyberday.ActualizacionPreferenciasResponse.ofertas:type_name -> cyberday.OfertaRequest
+	1,  // 5: cyberday.OfertaRequest.estado:type_name -> cyberday.EstadoOferta
+	15, // 6: cyberday.CambioOfertaResponse.oferta:type_name -> cyberday.OfertaRequest
+	15, // 7: cyberday.SincronizacionResponse.ofertas_faltantes:type_name -> cyberday.OfertaRequest
+	15, // 8: cyberday.SincronizacionResponse.hints:type_name -> cyberday.OfertaRequest
+	23, // 9: cyberday.ConfirmacionHintsRequest.hints:type_name -> cyberday.HintEntregado
+	15, // 10: cyberday.LecturaResponse.ofertas:type_name -> cyberday.OfertaRequest
+	7,  // 11: cyberday.BusquedaRequest.filtro:type_name -> cyberday.FiltroOferta
+	2,  // 12: cyberday.BusquedaRequest.orden:type_name -> cyberday.OrdenBusqueda
+	15, // 13: cyberday.BusquedaResponse.ofertas:type_name -> cyberday.OfertaRequest
+	29, // 14: cyberday.HashesMerkleRequest.rangos:type_name -> cyberday.RangoAnillo
+	29, // 15: cyberday.LecturaBucketsRequest.rangos:type_name -> cyberday.RangoAnillo
+	7,  // 16: cyberday.SuscripcionRequest.filtro:type_name -> cyberday.FiltroOferta
+	15, // 17: cyberday.NotificacionOferta.oferta:type_name -> cyberday.OfertaRequest
+	3,  // 18: cyberday.EntradaRaft.tipo:type_name -> cyberday.TipoEntradaRaft
+	15, // 19: cyberday.EntradaRaft.oferta:type_name -> cyberday.OfertaRequest
+	35, // 20: cyberday.AgregarEntradasRequest.entradas:type_name -> cyberday.EntradaRaft
+	15, // 21: cyberday.SnapshotBroker.ofertas:type_name -> cyberday.OfertaRequest
+	4,  // 22: cyberday.CyberDayService.RegistrarProductor:input_type -> cyberday.RegistroProductorRequest
+	5,  // 23: cyberday.CyberDayService.RegistrarNodo:input_type -> cyberday.RegistroNodoRequest
+	6,  // 24: cyberday.CyberDayService.RegistrarConsumidor:input_type -> cyberday.RegistroConsumidorRequest
+	9,  // 25: cyberday.CyberDayService.AbandonarCluster:input_type -> cyberday.SalidaRequest
+	10, // 26: cyberday.CyberDayService.ActualizarPreferencias:input_type -> cyberday.ActualizacionPreferenciasRequest
+	12, // 27: cyberday.CyberDayService.DarDeBajaConsumidor:input_type -> cyberday.BajaConsumidorRequest
+	13, // 28: cyberday.CyberDayService.SolicitarInicio:input_type -> cyberday.InicioRequest
+	15, // 29: cyberday.CyberDayService.EnviarOferta:input_type -> cyberday.OfertaRequest
+	17, // 30: cyberday.CyberDayService.ActualizarStock:input_type -> cyberday.ActualizacionStockRequest
+	18, // 31: cyberday.CyberDayService.RetirarOferta:input_type -> cyberday.RetiroOfertaRequest
+	21, // 32: cyberday.CyberDayService.SincronizarEntidad:input_type -> cyberday.SincronizacionRequest
+	24, // 33: cyberday.CyberDayService.ConfirmarHints:input_type -> cyberday.ConfirmacionHintsRequest
+	25, // 34: cyberday.CyberDayService.LeerOfertas:input_type -> cyberday.LecturaRequest
+	20, // 35: cyberday.CyberDayService.LeerOferta:input_type -> cyberday.LecturaOfertaRequest
+	27, // 36: cyberday.CyberDayService.BuscarOfertas:input_type -> cyberday.BusquedaRequest
+	30, // 37: cyberday.CyberDayService.ObtenerHashesMerkle:input_type -> cyberday.HashesMerkleRequest
+	32, // 38: cyberday.CyberDayService.LeerBuckets:input_type -> cyberday.LecturaBucketsRequest
+	33, // 39: cyberday.CyberDayService.Suscribir:input_type -> cyberday.SuscripcionRequest
+	36, // 40: cyberday.CyberDayService.SolicitarVoto:input_type -> cyberday.SolicitudVotoRequest
+	38, // 41: cyberday.CyberDayService.AgregarEntradas:input_type -> cyberday.AgregarEntradasRequest
+	40, // 42: cyberday.CyberDayService.InstalarSnapshot:input_type -> cyberday.InstalarSnapshotRequest
+	43, // 43: cyberday.CyberDayService.ConsultarEstado:input_type -> cyberday.ConsultarEstadoRequest
+	8,  // 44: cyberday.CyberDayService.RegistrarProductor:output_type -> cyberday.RegistroResponse
+	8,  // 45: cyberday.CyberDayService.RegistrarNodo:output_type -> cyberday.RegistroResponse
+	8,  // 46: cyberday.CyberDayService.RegistrarConsumidor:output_type -> cyberday.RegistroResponse
+	8,  // 47: cyberday.CyberDayService.AbandonarCluster:output_type -> cyberday.RegistroResponse
+	11, // 48: cyberday.CyberDayService.ActualizarPreferencias:output_type -> cyberday.ActualizacionPreferenciasResponse
+	8,  // 49: cyberday.CyberDayService.DarDeBajaConsumidor:output_type -> cyberday.RegistroResponse
+	14, // 50: cyberday.CyberDayService.SolicitarInicio:output_type -> cyberday.InicioResponse
+	16, // 51: cyberday.CyberDayService.EnviarOferta:output_type -> cyberday.OfertaResponse
+	19, // 52: cyberday.CyberDayService.ActualizarStock:output_type -> cyberday.CambioOfertaResponse
+	19, // 53: cyberday.CyberDayService.RetirarOferta:output_type -> cyberday.CambioOfertaResponse
+	22, // 54: cyberday.CyberDayService.SincronizarEntidad:output_type -> cyberday.SincronizacionResponse
+	8,  // 55: cyberday.CyberDayService.ConfirmarHints:output_type -> cyberday.RegistroResponse
+	26, // 56: cyberday.CyberDayService.LeerOfertas:output_type -> cyberday.LecturaResponse
+	26, // 57: cyberday.CyberDayService.LeerOferta:output_type -> cyberday.LecturaResponse
+	28, // 58: cyberday.CyberDayService.BuscarOfertas:output_type -> cyberday.BusquedaResponse
+	31, // 59: cyberday.CyberDayService.ObtenerHashesMerkle:output_type -> cyberday.HashesMerkleResponse
+	26, // 60: cyberday.CyberDayService.LeerBuckets:output_type -> cyberday.LecturaResponse
+	34, // 61: cyberday.CyberDayService.Suscribir:output_type -> cyberday.NotificacionOferta
+	37, // 62: cyberday.CyberDayService.SolicitarVoto:output_type -> cyberday.SolicitudVotoResponse
+	39, // 63: cyberday.CyberDayService.AgregarEntradas:output_type -> cyberday.AgregarEntradasResponse
+	39, // 64: cyberday.CyberDayService.InstalarSnapshot:output_type -> cyberday.AgregarEntradasResponse
+	44, // 65: cyberday.CyberDayService.ConsultarEstado:output_type -> cyberday.ConsultarEstadoResponse
+	44, // [44:66] is the sub-list for method output_type
+	22, // [22:44] is the sub-list for method input_type
+	22, // [22:22] is the sub-list for extension type_name
+	22, // [22:22] is the sub-list for extension extendee
+	0,  // [0:22] is the sub-list for field type_name
 }
 
 func init() { file_proto_cyberday_proto_init() }
@@ -861,13 +3244,14 @@ func file_proto_cyberday_proto_init() {
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_proto_cyberday_proto_rawDesc), len(file_proto_cyberday_proto_rawDesc)),
-			NumEnums:      0,
-			NumMessages:   14,
+			NumEnums:      4,
+			NumMessages:   41,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
 		GoTypes:           file_proto_cyberday_proto_goTypes,
 		DependencyIndexes: file_proto_cyberday_proto_depIdxs,
+		EnumInfos:         file_proto_cyberday_proto_enumTypes,
 		MessageInfos:      file_proto_cyberday_proto_msgTypes,
 	}.Build()
 	File_proto_cyberday_proto = out.File
@@ -8,11 +8,13 @@ import (
 	"fmt"
 	"math/rand"
 	"os"
+	"os/signal"
 	"strconv"
+	"syscall"
 	"time"
 
-	"google.golang.org/grpc"
-	"google.golang.org/grpc/credentials/insecure"
+	"lab2/conexion"
+	"lab2/metricas"
 	pb "lab2/productores/proto"
 )
 
@@ -31,6 +33,14 @@ type Productor struct {
 	ctx       		context.Context
 	catalogo  		[]*ProductoCatalogo
 	ofertasEnviadas int
+	// epoca y secuencia forman IDs de oferta únicos aunque el productor se
+	// reinicie: cada oferta generada consume un número, se confirme o no
+	epoca           int64
+	secuencia       int64
+	outbox          *Outbox
+	// Ofertas confirmadas que la tienda todavía puede retirar
+	confirmadas       []string
+	probabilidadRetiro float64
 }
 
 var categoriasValidas = []string{
@@ -112,25 +122,86 @@ func (p *Productor) iniciarGeneracionOfertas() {
 		producto := p.catalogo[rand.Intn(len(p.catalogo))]
 		
 		oferta := p.generarOferta(producto)
-		
-		resp, err := p.client.EnviarOferta(p.ctx, oferta)
-		if err != nil {
-			log.Printf("%s error enviando oferta: %v", p.nombre, err)
-		} else {
-			if resp.GetExito() {
-				p.ofertasEnviadas++
-				log.Printf("%s envió oferta #%d: %s - $%d (Stock: %d)", 
-					p.nombre, p.ofertasEnviadas, oferta.GetProducto(), oferta.GetPrecio(), oferta.GetStock())
-			} else {
-				log.Printf("%s: No se pudo enviar oferta", p.nombre)
-			}
+		// La oferta queda en el outbox hasta que el broker la confirme
+		if err := p.outbox.Agregar(oferta); err != nil {
+			log.Printf("%s error guardando oferta en outbox: %v", p.nombre, err)
 		}
 
+		p.enviarPendientes()
+		p.retirarAlAzar()
+
 		espera := time.Duration(1 + rand.Intn(3)) * time.Second
 		time.Sleep(espera)
 	}
 }
 
+// enviarPendientes envía las ofertas del outbox cuyo reintento ya venció. Las
+// que fallan se reprograman con backoff; las confirmadas salen del outbox.
+func (p *Productor) enviarPendientes() {
+	for _, oferta := range p.outbox.Pendientes(time.Now()) {
+		ctx, cancel := context.WithTimeout(p.ctx, 5*time.Second)
+		resp, err := p.client.EnviarOferta(ctx, oferta)
+		cancel()
+
+		switch {
+		case err != nil:
+			metricaEnvios.WithLabelValues("error").Inc()
+		case !resp.GetExito():
+			metricaEnvios.WithLabelValues("rechazada").Inc()
+		default:
+			metricaEnvios.WithLabelValues("exito").Inc()
+		}
+
+		if err == nil && resp.GetExito() {
+			p.ofertasEnviadas++
+			log.Printf("%s envió oferta #%d: %s - $%d (Stock: %d)", 
+				p.nombre, p.ofertasEnviadas, oferta.GetProducto(), oferta.GetPrecio(), oferta.GetStock())
+			if err := p.outbox.Confirmar(oferta.GetOfertaId()); err != nil {
+				log.Printf("%s error actualizando outbox: %v", p.nombre, err)
+			}
+			p.confirmadas = append(p.confirmadas, oferta.GetOfertaId())
+			continue
+		}
+
+		espera := p.outbox.ProgramarReintento(oferta.GetOfertaId())
+		if err != nil {
+			log.Printf("%s error enviando oferta %s: %v - reintento en %v", p.nombre, oferta.GetOfertaId(), err, espera)
+		} else {
+			log.Printf("%s: No se pudo enviar oferta %s - reintento en %v", p.nombre, oferta.GetOfertaId(), espera)
+		}
+	}
+
+	if pendientes := p.outbox.Profundidad(); pendientes > 0 {
+		log.Printf("%s outbox: %d ofertas pendientes, %d reintentos", p.nombre, pendientes, p.outbox.Reintentos())
+	}
+}
+
+// retirarAlAzar simula que la tienda retira una de sus ofertas, por ejemplo
+// porque se equivocó en el precio.
+func (p *Productor) retirarAlAzar() {
+	if len(p.confirmadas) == 0 || rand.Float64() >= p.probabilidadRetiro {
+		return
+	}
+
+	i := rand.Intn(len(p.confirmadas))
+	ofertaID := p.confirmadas[i]
+	p.confirmadas = append(p.confirmadas[:i], p.confirmadas[i+1:]...)
+
+	ctx, cancel := context.WithTimeout(p.ctx, 10*time.Second)
+	resp, err := p.client.RetirarOferta(ctx, &pb.RetiroOfertaRequest{
+		OfertaId: ofertaID,
+		Tienda:   p.nombre,
+	})
+	cancel()
+
+	if err != nil {
+		log.Printf("%s error retirando oferta %s: %v", p.nombre, ofertaID, err)
+	} else if !resp.GetExito() {
+		log.Printf("%s no pudo retirar oferta %s: %s", p.nombre, ofertaID, resp.GetMotivo())
+	} else {
+		log.Printf("%s retiró oferta %s", p.nombre, ofertaID)
+	}
+}
 
 func (p *Productor) registrarEnBroker() {
 	resp, err := p.client.RegistrarProductor(p.ctx, &pb.RegistroProductorRequest{
@@ -148,6 +219,21 @@ func (p *Productor) registrarEnBroker() {
 	}
 }
 
+func (p *Productor) abandonarCluster() {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	resp, err := p.client.AbandonarCluster(ctx, &pb.SalidaRequest{
+		EntidadId: p.nombre,
+		Tipo:      "productor",
+	})
+	if err != nil || !resp.GetExito() {
+		log.Printf("%s no pudo avisar su salida al broker: %v", p.nombre, err)
+		return
+	}
+	log.Printf("Tienda %s abandonó el cluster", p.nombre)
+}
+
 func (p *Productor) esperarInicio() {
 	log.Printf("%s esperando que el sistema esté listo...", p.nombre)
 	
@@ -188,12 +274,15 @@ func (p *Productor) generarOferta(producto *ProductoCatalogo) *pb.OfertaRequest
 		stockOferta = 1
 	}
 
+	p.secuencia++
+
 	return &pb.OfertaRequest{
-		OfertaId: fmt.Sprintf("%s-%d", p.nombre, p.ofertasEnviadas+1),
+		OfertaId: fmt.Sprintf("%s-%d-%d", p.nombre, p.epoca, p.secuencia),
 		Tienda:   p.nombre,
 		Categoria: producto.categoria,
 		Producto:  producto.producto,
 		Precio:    int32(precioConDescuento),
+		Descuento: int32(descuento),
 		Stock:     int32(stockOferta),
 		Fecha:     time.Now().Format("2006-01-02 15:04:05"),
 	}
@@ -201,7 +290,11 @@ func (p *Productor) generarOferta(producto *ProductoCatalogo) *pb.OfertaRequest
 
 func main() {
 	var tienda string
+	var dirDatos string
+	var dirMetricas string
 	flag.StringVar(&tienda, "tienda", "", "Nombre de la tienda (Riploy, Falabellox, Parisio)")
+	flag.StringVar(&dirDatos, "datos", os.Getenv("PRODUCTOR_DATOS"), "Directorio del outbox (por defecto datos/<tienda>)")
+	flag.StringVar(&dirMetricas, "metricas", os.Getenv("PRODUCTOR_METRICAS"), "Dirección de /metrics (por defecto 9101-9103 según la tienda, 9100 para otras)")
 	flag.Parse()
 
 	if tienda == "" {
@@ -210,12 +303,7 @@ func main() {
 
 	log.Printf("Iniciando productor: %s", tienda)
 
-	brokerHost := os.Getenv("BROKER_HOST")
-	if brokerHost == "" {
-		brokerHost = "broker"  // nombre del servicio en docker-compose
-	}
-	conn, err := grpc.Dial(brokerHost + ":50051", grpc.WithTransportCredentials(insecure.NewCredentials()))
-	
+	conn, err := conexion.Conectar(conexion.DireccionesDesdeEntorno())
 	if err != nil {
 		log.Fatalf("No se pudo conectar al broker: %v", err)
 	}
@@ -224,15 +312,46 @@ func main() {
 	client := pb.NewCyberDayServiceClient(conn)
 	ctx := context.Background()
 
+	if dirDatos == "" {
+		dirDatos = "datos/" + tienda
+	}
+	outbox, err := AbrirOutbox(dirDatos)
+	if err != nil {
+		log.Fatalf("Error recuperando outbox de %s: %v", tienda, err)
+	}
+
+	if dirMetricas == "" {
+		puerto, existe := puertosMetricas[tienda]
+		if !existe {
+			puerto = 9100
+		}
+		dirMetricas = fmt.Sprintf(":%d", puerto)
+	}
+	registrarMetricasOutbox(outbox)
+	metricas.Servir(dirMetricas)
+
 	productor := &Productor{
 		nombre: tienda,
 		client: client,
 		ctx:    ctx,
 		ofertasEnviadas: 0,
+		epoca:  time.Now().UnixMilli(),
+		outbox: outbox,
+		probabilidadRetiro: 0.05,
 	}
 
 	productor.registrarEnBroker()
 
+	go func() {
+		senales := make(chan os.Signal, 1)
+		signal.Notify(senales, syscall.SIGINT, syscall.SIGTERM)
+		<-senales
+		log.Printf("%s outbox al salir: %d ofertas pendientes, %d reintentos",
+			tienda, outbox.Profundidad(), outbox.Reintentos())
+		productor.abandonarCluster()
+		os.Exit(0)
+	}()
+
 	err = productor.cargarCatalogo()
 	if err != nil {
 		log.Fatalf("Error cargando catálogo: %v", err)
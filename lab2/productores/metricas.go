@@ -0,0 +1,37 @@
+package main
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// puertosMetricas asigna a cada tienda conocida su puerto de /metrics, para
+// que varios productores puedan correr en la misma máquina.
+var puertosMetricas = map[string]int{
+	"Riploy":     9101,
+	"Falabellox": 9102,
+	"Parisio":    9103,
+}
+
+var metricaEnvios = promauto.NewCounterVec(prometheus.CounterOpts{
+	Name: "cyberday_productor_envios_total",
+	Help: "Intentos de envío de ofertas al broker según su resultado.",
+}, []string{"resultado"})
+
+// registrarMetricasOutbox expone el tamaño del outbox y los reintentos, que se
+// leen del outbox en cada consulta.
+func registrarMetricasOutbox(outbox *Outbox) {
+	promauto.NewGaugeFunc(prometheus.GaugeOpts{
+		Name: "cyberday_productor_outbox_pendientes",
+		Help: "Ofertas en el outbox que el broker todavía no confirma.",
+	}, func() float64 {
+		return float64(outbox.Profundidad())
+	})
+
+	promauto.NewCounterFunc(prometheus.CounterOpts{
+		Name: "cyberday_productor_reintentos_total",
+		Help: "Envíos reprogramados desde que inició el productor.",
+	}, func() float64 {
+		return float64(outbox.Reintentos())
+	})
+}
@@ -0,0 +1,280 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	pb "lab2/productores/proto"
+	"lab2/registros"
+)
+
+const (
+	archivoOutbox      = "outbox"
+	archivoConfirmadas = "outbox.confirmadas"
+
+	esperaBaseReintento   = 500 * time.Millisecond
+	esperaMaximaReintento = 30 * time.Second
+
+	// compactarDesde es cuántas ofertas confirmadas pueden quedar en el archivo
+	// del outbox antes de reescribirlo solo con las pendientes.
+	compactarDesde = 256
+)
+
+type entradaOutbox struct {
+	oferta         *pb.OfertaRequest
+	intentos       int
+	proximoIntento time.Time
+}
+
+// Outbox guarda en disco las ofertas generadas que el broker todavía no
+// confirma, así al reiniciar el productor se vuelven a intentar con el mismo ID
+// y el broker las reconoce si ya las había recibido. Las ofertas nuevas se
+// agregan al final del archivo y las confirmaciones a un segundo archivo; el
+// primero se reescribe con las pendientes cuando acumula suficientes ofertas
+// confirmadas, para que una caída larga del broker no haga reescribir todo el
+// outbox en cada oferta.
+type Outbox struct {
+	ruta            string
+	rutaConfirmadas string
+	mu              sync.Mutex
+	entradas        []*entradaOutbox
+	archivo         *os.File
+	confirmadas     *os.File
+	// Ofertas confirmadas que siguen en el archivo del outbox
+	descartadas int
+	reintentos  int64
+}
+
+func AbrirOutbox(dir string) (*Outbox, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("no se pudo crear directorio %s: %v", dir, err)
+	}
+
+	o := &Outbox{
+		ruta:            filepath.Join(dir, archivoOutbox),
+		rutaConfirmadas: filepath.Join(dir, archivoConfirmadas),
+	}
+
+	ofertas, err := leerOutbox(o.ruta)
+	if err != nil {
+		return nil, err
+	}
+	confirmadas, err := leerOutbox(o.rutaConfirmadas)
+	if err != nil {
+		return nil, err
+	}
+	confirmada := make(map[string]bool, len(confirmadas))
+	for _, oferta := range confirmadas {
+		confirmada[oferta.GetOfertaId()] = true
+	}
+
+	ahora := time.Now()
+	for _, oferta := range ofertas {
+		if !confirmada[oferta.GetOfertaId()] {
+			o.entradas = append(o.entradas, &entradaOutbox{oferta: oferta, proximoIntento: ahora})
+		}
+	}
+
+	// Se compacta al abrir para descartar una posible cola corrupta antes de
+	// seguir agregando
+	if err := o.compactarLocked(); err != nil {
+		return nil, err
+	}
+
+	log.Printf("Outbox recuperado: %d ofertas pendientes", len(o.entradas))
+	return o, nil
+}
+
+// Agregar deja la oferta en el outbox antes de intentar enviarla.
+func (o *Outbox) Agregar(oferta *pb.OfertaRequest) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	o.entradas = append(o.entradas, &entradaOutbox{oferta: oferta, proximoIntento: time.Now()})
+	if err := o.agregarLocked(o.archivo, oferta); err != nil {
+		return err
+	}
+	return o.archivo.Sync()
+}
+
+// Confirmar quita del outbox una oferta aceptada por el broker. La
+// confirmación no se sincroniza a disco: si se pierde, la oferta se reenvía al
+// reiniciar y el broker la reconoce por su ID.
+func (o *Outbox) Confirmar(ofertaID string) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	for i, entrada := range o.entradas {
+		if entrada.oferta.GetOfertaId() != ofertaID {
+			continue
+		}
+		o.entradas = append(o.entradas[:i], o.entradas[i+1:]...)
+		o.descartadas++
+		if o.descartadas >= compactarDesde && o.descartadas >= len(o.entradas) {
+			return o.compactarLocked()
+		}
+		return o.agregarLocked(o.confirmadas, &pb.OfertaRequest{OfertaId: ofertaID})
+	}
+	return nil
+}
+
+// Pendientes retorna, en orden de generación, las ofertas cuyo próximo
+// intento ya venció.
+func (o *Outbox) Pendientes(ahora time.Time) []*pb.OfertaRequest {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	var ofertas []*pb.OfertaRequest
+	for _, entrada := range o.entradas {
+		if !entrada.proximoIntento.After(ahora) {
+			ofertas = append(ofertas, entrada.oferta)
+		}
+	}
+	return ofertas
+}
+
+// ProgramarReintento aplaza la oferta con backoff exponencial y jitter: la
+// espera se duplica en cada intento hasta el máximo, y se elige al azar entre
+// la mitad y el total para que los productores no reintenten todos a la vez.
+func (o *Outbox) ProgramarReintento(ofertaID string) time.Duration {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	for _, entrada := range o.entradas {
+		if entrada.oferta.GetOfertaId() != ofertaID {
+			continue
+		}
+
+		espera := esperaBaseReintento << min(entrada.intentos, 10)
+		if espera > esperaMaximaReintento {
+			espera = esperaMaximaReintento
+		}
+		espera = espera/2 + time.Duration(rand.Int63n(int64(espera/2)+1))
+
+		entrada.intentos++
+		entrada.proximoIntento = time.Now().Add(espera)
+		o.reintentos++
+		return espera
+	}
+	return 0
+}
+
+func (o *Outbox) Profundidad() int {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	return len(o.entradas)
+}
+
+func (o *Outbox) Reintentos() int64 {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	return o.reintentos
+}
+
+// agregarLocked escribe un registro al final del archivo. Si la escritura
+// falla a medias, lo que se agregue después quedaría tras un registro
+// incompleto y se perdería al reiniciar, así que se compacta desde memoria.
+func (o *Outbox) agregarLocked(archivo *os.File, oferta *pb.OfertaRequest) error {
+	err := registros.Escribir(archivo, oferta)
+	if err == nil {
+		return nil
+	}
+	if errCompactar := o.compactarLocked(); errCompactar != nil {
+		log.Printf("Error compactando outbox: %v", errCompactar)
+	}
+	return err
+}
+
+// compactarLocked reescribe el outbox con las ofertas pendientes en un archivo
+// temporal y lo renombra, para que una caída a mitad de camino deje el archivo
+// anterior intacto. Recién después se vacían las confirmaciones: si la caída
+// ocurre entre medio, las que quedan son de ofertas que ya no están.
+func (o *Outbox) compactarLocked() error {
+	if o.archivo != nil {
+		o.archivo.Close()
+		o.confirmadas.Close()
+		o.archivo, o.confirmadas = nil, nil
+	}
+
+	rutaTmp := o.ruta + ".tmp"
+	tmp, err := os.Create(rutaTmp)
+	if err != nil {
+		return err
+	}
+
+	writer := bufio.NewWriter(tmp)
+	for _, entrada := range o.entradas {
+		if err := registros.Escribir(writer, entrada.oferta); err != nil {
+			tmp.Close()
+			return err
+		}
+	}
+	if err := writer.Flush(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(rutaTmp, o.ruta); err != nil {
+		return err
+	}
+
+	archivo, err := os.OpenFile(o.ruta, os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	confirmadas, err := os.OpenFile(o.rutaConfirmadas, os.O_CREATE|os.O_TRUNC|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		archivo.Close()
+		return err
+	}
+	o.archivo, o.confirmadas = archivo, confirmadas
+	o.descartadas = 0
+	return nil
+}
+
+// leerOutbox lee las ofertas de uno de los archivos del outbox. Una cola
+// corrupta se descarta.
+func leerOutbox(ruta string) ([]*pb.OfertaRequest, error) {
+	file, err := os.Open(ruta)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	reader := bufio.NewReader(file)
+	var ofertas []*pb.OfertaRequest
+	for {
+		// Las ofertas que genera el productor son pequeñas: un largo sobre el
+		// límite por defecto de gRPC solo puede venir de un archivo dañado
+		oferta := &pb.OfertaRequest{}
+		_, err := registros.Leer(reader, oferta, registros.MaxMensajeGRPC)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			log.Printf("Archivo %s con cola corrupta (%v) - se descarta el resto", ruta, err)
+			break
+		}
+		ofertas = append(ofertas, oferta)
+	}
+	return ofertas, nil
+}
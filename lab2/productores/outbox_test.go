@@ -0,0 +1,95 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	pb "lab2/productores/proto"
+)
+
+func idsPendientes(o *Outbox) []string {
+	var ids []string
+	for _, oferta := range o.Pendientes(time.Now()) {
+		ids = append(ids, oferta.GetOfertaId())
+	}
+	return ids
+}
+
+// Las confirmaciones se agregan a su archivo y el outbox se compacta al pasar
+// el umbral; al reabrir quedan exactamente las pendientes.
+func TestOutboxRecuperaPendientesYCompacta(t *testing.T) {
+	dir := t.TempDir()
+	o, err := AbrirOutbox(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	total := 2*compactarDesde + 10
+	for i := 0; i < total; i++ {
+		if err := o.Agregar(&pb.OfertaRequest{OfertaId: fmt.Sprintf("Riploy-%d", i), Tienda: "Riploy"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	var esperadas []string
+	for i := 0; i < total; i++ {
+		id := fmt.Sprintf("Riploy-%d", i)
+		if i%50 == 0 {
+			esperadas = append(esperadas, id)
+			continue
+		}
+		if err := o.Confirmar(id); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if o.descartadas >= compactarDesde {
+		t.Fatalf("el outbox no se compactó: %d ofertas confirmadas en el archivo", o.descartadas)
+	}
+
+	o, err = AbrirOutbox(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := idsPendientes(o); !slices.Equal(got, esperadas) {
+		t.Fatalf("pendientes al reabrir = %v, se esperaban %v", got, esperadas)
+	}
+}
+
+// Una escritura interrumpida deja una cola corrupta que se descarta al abrir,
+// y lo que se agrega después sigue siendo legible.
+func TestOutboxDescartaColaCorrupta(t *testing.T) {
+	dir := t.TempDir()
+	o, err := AbrirOutbox(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := o.Agregar(&pb.OfertaRequest{OfertaId: "Riploy-1"}); err != nil {
+		t.Fatal(err)
+	}
+
+	archivo, err := os.OpenFile(filepath.Join(dir, archivoOutbox), os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	archivo.Write([]byte{0, 0, 0, 9, 1, 2})
+	archivo.Close()
+
+	o, err = AbrirOutbox(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := o.Agregar(&pb.OfertaRequest{OfertaId: "Riploy-2"}); err != nil {
+		t.Fatal(err)
+	}
+
+	o, err = AbrirOutbox(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := idsPendientes(o); !slices.Equal(got, []string{"Riploy-1", "Riploy-2"}) {
+		t.Fatalf("pendientes al reabrir = %v", got)
+	}
+}
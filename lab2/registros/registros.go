@@ -0,0 +1,74 @@
+// Package registros lee y escribe los archivos de registros que usan el WAL de
+// los nodos, las colas del broker y el outbox de los productores. Cada registro
+// se guarda como: largo (4 bytes) | crc32 (4 bytes) | mensaje serializado.
+package registros
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+
+	"google.golang.org/protobuf/proto"
+)
+
+const largoCabecera = 8
+
+// MaxMensajeGRPC es el tamaño máximo de mensaje que acepta por defecto un
+// servidor gRPC. Ningún mensaje que haya llegado por un servidor con esa
+// configuración puede ocupar más.
+const MaxMensajeGRPC = 4 << 20
+
+// ErrLargoExcedido indica un registro cuyo largo supera el máximo que admite
+// quien lee el archivo. Una escritura interrumpida deja un registro incompleto,
+// no un largo mayor, así que este error indica un archivo dañado o un máximo
+// que no corresponde.
+var ErrLargoExcedido = errors.New("largo de registro sobre el máximo")
+
+// Escribir agrega el mensaje como un registro.
+func Escribir(w io.Writer, mensaje proto.Message) error {
+	datos, err := proto.Marshal(mensaje)
+	if err != nil {
+		return err
+	}
+
+	cabecera := make([]byte, largoCabecera)
+	binary.BigEndian.PutUint32(cabecera[0:4], uint32(len(datos)))
+	binary.BigEndian.PutUint32(cabecera[4:8], crc32.ChecksumIEEE(datos))
+
+	_, err = w.Write(append(cabecera, datos...))
+	return err
+}
+
+// Leer lee el siguiente registro en mensaje y retorna cuántos bytes ocupaba.
+// Retorna io.EOF si el archivo termina justo antes del registro. El largo se
+// compara con maximo antes de reservar memoria; si lo supera el error envuelve
+// ErrLargoExcedido.
+func Leer(r io.Reader, mensaje proto.Message, maximo int) (int, error) {
+	cabecera := make([]byte, largoCabecera)
+	if _, err := io.ReadFull(r, cabecera); err != nil {
+		if err == io.EOF {
+			return 0, io.EOF
+		}
+		return 0, fmt.Errorf("cabecera incompleta: %v", err)
+	}
+
+	largo := binary.BigEndian.Uint32(cabecera[0:4])
+	if int64(largo) > int64(maximo) {
+		return 0, fmt.Errorf("%w (%d > %d)", ErrLargoExcedido, largo, maximo)
+	}
+
+	datos := make([]byte, largo)
+	if _, err := io.ReadFull(r, datos); err != nil {
+		return 0, fmt.Errorf("registro incompleto: %v", err)
+	}
+	if crc32.ChecksumIEEE(datos) != binary.BigEndian.Uint32(cabecera[4:8]) {
+		return 0, fmt.Errorf("checksum inválido")
+	}
+
+	if err := proto.Unmarshal(datos, mensaje); err != nil {
+		return 0, err
+	}
+	return largoCabecera + len(datos), nil
+}
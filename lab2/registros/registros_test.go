@@ -0,0 +1,84 @@
+package registros
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+func escribirTodos(t *testing.T, valores ...string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	for _, valor := range valores {
+		if err := Escribir(&buf, wrapperspb.String(valor)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return buf.Bytes()
+}
+
+func TestLeerLoEscrito(t *testing.T) {
+	datos := escribirTodos(t, "uno", "", "tres")
+	r := bytes.NewReader(datos)
+
+	total := 0
+	for _, esperado := range []string{"uno", "", "tres"} {
+		mensaje := &wrapperspb.StringValue{}
+		n, err := Leer(r, mensaje, MaxMensajeGRPC)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if mensaje.GetValue() != esperado {
+			t.Fatalf("se leyó %q, se esperaba %q", mensaje.GetValue(), esperado)
+		}
+		total += n
+	}
+	if _, err := Leer(r, &wrapperspb.StringValue{}, MaxMensajeGRPC); err != io.EOF {
+		t.Fatalf("al final del archivo se esperaba io.EOF, se obtuvo %v", err)
+	}
+	if total != len(datos) {
+		t.Fatalf("los registros suman %d bytes, el archivo tiene %d", total, len(datos))
+	}
+}
+
+func TestLeerRegistroCorrupto(t *testing.T) {
+	datos := escribirTodos(t, "una oferta")
+
+	casos := []struct {
+		nombre string
+		datos  []byte
+	}{
+		{"cabecera incompleta", datos[:5]},
+		{"registro incompleto", datos[:len(datos)-1]},
+		{"checksum inválido", append(bytes.Clone(datos[:len(datos)-1]), datos[len(datos)-1]^0xff)},
+	}
+	for _, c := range casos {
+		t.Run(c.nombre, func(t *testing.T) {
+			_, err := Leer(bytes.NewReader(c.datos), &wrapperspb.StringValue{}, MaxMensajeGRPC)
+			if err == nil || err == io.EOF || errors.Is(err, ErrLargoExcedido) {
+				t.Fatalf("error inesperado: %v", err)
+			}
+		})
+	}
+}
+
+func TestLeerRespetaElMaximo(t *testing.T) {
+	grande := wrapperspb.String(string(bytes.Repeat([]byte("x"), 1000)))
+	var buf bytes.Buffer
+	if err := Escribir(&buf, grande); err != nil {
+		t.Fatal(err)
+	}
+	datos := buf.Bytes()
+
+	if _, err := Leer(bytes.NewReader(datos), &wrapperspb.StringValue{}, 100); !errors.Is(err, ErrLargoExcedido) {
+		t.Fatalf("con máximo 100 se esperaba ErrLargoExcedido, se obtuvo %v", err)
+	}
+	leido := &wrapperspb.StringValue{}
+	if _, err := Leer(bytes.NewReader(datos), leido, len(datos)); err != nil || !proto.Equal(leido, grande) {
+		t.Fatalf("con máximo suficiente no se leyó el registro: %v", err)
+	}
+}